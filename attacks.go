@@ -0,0 +1,128 @@
+package chessongo
+
+// Returns the squares attacked by the given pawns of the given color
+func pawnAttacks(pawns Bitboard, color Color) Bitboard {
+	if color == WHITE {
+		return ((pawns & ^Bitboard(FILE_H_MASK)) >> 7) | ((pawns & ^Bitboard(FILE_A_MASK)) >> 9)
+	}
+	return ((pawns & ^Bitboard(FILE_A_MASK)) << 7) | ((pawns & ^Bitboard(FILE_H_MASK)) << 9)
+}
+
+// Returns the squares a slider on from attacks in the given directions, up to
+// and including the first blocker in occupied
+func slidingAttacks(from Square, occupied Bitboard, directions []Direction) Bitboard {
+	var attacks Bitboard
+	for _, direction := range directions {
+		targets := RAY_MASKS[direction][from]
+		blockers := targets & occupied
+		if blockers > 0 {
+			if DIRECTION_LSB_MSP[direction] == LSB {
+				targets ^= RAY_MASKS[direction][blockers.lsbIndex()]
+			} else {
+				targets ^= RAY_MASKS[direction][blockers.msbIndex()]
+			}
+		}
+		attacks |= targets
+	}
+	return attacks
+}
+
+// Returns the pieces of the given color
+func (p *Position) piecesOf(color Color) *[7]Bitboard {
+	if color == WHITE {
+		return &p.Whites
+	}
+	return &p.Blacks
+}
+
+// Returns the squares attacked by piece standing on sq, given the occupancy
+func pieceAttacks(piece Piece, sq Square, occupied Bitboard) Bitboard {
+	switch piece.Kind() {
+	case PAWN:
+		return pawnAttacks(Bitboard(1)<<sq, piece.Color())
+	case KNIGHT:
+		return KNIGHT_ATTACKS_FROM[sq]
+	case BISHOP:
+		return slidingAttacks(sq, occupied, BISHOP_DIRECTIONS[:])
+	case ROOK:
+		return slidingAttacks(sq, occupied, ROOK_DIRECTIONS[:])
+	case QUEEN:
+		return slidingAttacks(sq, occupied, ALL_DIRECTIONS[:])
+	case KING:
+		return KING_ATTACKS_FROM[sq]
+	}
+	return 0
+}
+
+// Returns the pieces of the given color attacking sq, treating occupied as
+// the set of blocking pieces
+func (p *Position) attackersOf(sq Square, by Color, occupied Bitboard) Bitboard {
+	pieces := p.piecesOf(by)
+	sqBB := Bitboard(1) << sq
+	attackers := pawnAttacks(sqBB, opponentColor(by)) & pieces[PAWN]
+	attackers |= KNIGHT_ATTACKS_FROM[sq] & pieces[KNIGHT]
+	attackers |= KING_ATTACKS_FROM[sq] & pieces[KING]
+	attackers |= slidingAttacks(sq, occupied, BISHOP_DIRECTIONS[:]) & (pieces[BISHOP] | pieces[QUEEN])
+	attackers |= slidingAttacks(sq, occupied, ROOK_DIRECTIONS[:]) & (pieces[ROOK] | pieces[QUEEN])
+	return attackers & occupied
+}
+
+// AttackersOf returns the pieces of the given color that attack sq. The
+// square may be empty or hold a piece of either color.
+func (p *Position) AttackersOf(sq Square, by Color) Bitboard {
+	return p.attackersOf(sq, by, p.Occupied)
+}
+
+// IsAttacked tells whether any piece of the given color attacks sq
+func (p *Position) IsAttacked(sq Square, by Color) bool {
+	return p.AttackersOf(sq, by) > 0
+}
+
+// AttacksFrom returns the squares attacked by the piece on sq, empty if the
+// square is empty. Squares holding friendly pieces are included: the piece
+// defends them.
+func (p *Position) AttacksFrom(sq Square) Bitboard {
+	return pieceAttacks(p.Squares[sq], sq, p.Occupied)
+}
+
+// AttackedSquares returns every square attacked by at least one piece of the
+// given color
+func (p *Position) AttackedSquares(color Color) Bitboard {
+	pieces := p.piecesOf(color)
+	attacked := pawnAttacks(pieces[PAWN], color)
+	for _, kind := range [5]Piece{KNIGHT, BISHOP, ROOK, QUEEN, KING} {
+		for bb := pieces[kind]; bb > 0; {
+			sq := Square(bb.popLSB())
+			attacked |= pieceAttacks(p.Squares[sq], sq, p.Occupied)
+		}
+	}
+	return attacked
+}
+
+// ControlCounts returns, for every square, how many white and how many black
+// pieces attack it
+func (p *Position) ControlCounts() (white, black [64]int) {
+	for bb := p.Occupied; bb > 0; {
+		sq := Square(bb.popLSB())
+		piece := p.Squares[sq]
+		counts := &white
+		if piece.Color() == BLACK {
+			counts = &black
+		}
+		for attacks := pieceAttacks(piece, sq, p.Occupied); attacks > 0; {
+			counts[attacks.popLSB()]++
+		}
+	}
+	return white, black
+}
+
+// Returns the squares strictly between a and b when they share a rank, file
+// or diagonal, 0 otherwise
+func squaresBetween(a, b Square) Bitboard {
+	for _, direction := range ALL_DIRECTIONS {
+		if RAY_MASKS[direction][a]&(Bitboard(1)<<b) > 0 {
+			return RAY_MASKS[direction][a] &^ RAY_MASKS[direction][b] &^ (Bitboard(1) << b)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func squaresBB(coords ...string) Bitboard {
+	var bb Bitboard
+	for _, c := range coords {
+		bb |= Bitboard(1) << COORDS_TO_SQUARE[c]
+	}
+	return bb
+}
+
+func TestAttackersOf(t *testing.T) {
+	g := &Game{}
+	require.NoError(t, g.LoadFen("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"))
+
+	// e6 is attacked by the d5 pawn, and defended by the f7 and d7 pawns and the e7 queen.
+	require.Equal(t, squaresBB("d5"), g.AttackersOf(COORDS_TO_SQUARE["e6"], WHITE))
+	require.Equal(t, squaresBB("f7", "d7", "e7"), g.AttackersOf(COORDS_TO_SQUARE["e6"], BLACK))
+	// f7 is attacked by the knight on e5; the queen on f3 is blocked by f6.
+	require.Equal(t, squaresBB("e5"), g.AttackersOf(COORDS_TO_SQUARE["f7"], WHITE))
+	// empty square attacked by the c2 pawn, the e2 bishop, the e5 knight and the queen
+	require.Equal(t, squaresBB("c2", "e2", "e5", "f3"), g.AttackersOf(COORDS_TO_SQUARE["d3"], WHITE))
+	require.True(t, g.IsAttacked(COORDS_TO_SQUARE["h3"], WHITE))
+	require.False(t, g.IsAttacked(COORDS_TO_SQUARE["a5"], WHITE))
+	require.True(t, g.IsAttacked(COORDS_TO_SQUARE["g2"], BLACK))
+}
+
+func TestAttackedSquares(t *testing.T) {
+	g := &Game{}
+	require.NoError(t, g.LoadFen("4k3/8/8/8/8/8/8/R3K3 w - - 0 1"))
+	expected := squaresBB("a2", "a3", "a4", "a5", "a6", "a7", "a8", "b1", "c1", "d1", "e1") |
+		KING_ATTACKS_FROM[COORDS_TO_SQUARE["e1"]]
+	require.Equal(t, expected, g.AttackedSquares(WHITE))
+	require.Equal(t, KING_ATTACKS_FROM[COORDS_TO_SQUARE["e8"]], g.AttackedSquares(BLACK))
+
+	g = NewGame()
+	require.Equal(t, RANK3_MASK|squaresBB("b1", "c1", "d1", "e1", "f1", "g1")|(RANK2_MASK), g.AttackedSquares(WHITE))
+}
+
+func TestControlCounts(t *testing.T) {
+	g := NewGame()
+	white, black := g.ControlCounts()
+	// d2 is defended by the king, queen, bishop and knight
+	require.Equal(t, 4, white[COORDS_TO_SQUARE["d2"]])
+	// c3 is covered by the b2 and d2 pawns and the b1 knight
+	require.Equal(t, 3, white[COORDS_TO_SQUARE["c3"]])
+	require.Equal(t, 0, black[COORDS_TO_SQUARE["c3"]])
+	require.Equal(t, 3, black[COORDS_TO_SQUARE["c6"]])
+
+	total := 0
+	for sq := Square(0); sq < 64; sq++ {
+		total += white[sq]
+		require.Equal(t, g.AttackersOf(sq, WHITE).NumberOfSetBits(), white[sq], sq.Coords())
+		require.Equal(t, g.AttackersOf(sq, BLACK).NumberOfSetBits(), black[sq], sq.Coords())
+	}
+	require.Greater(t, total, 0)
+}
@@ -122,3 +122,67 @@ func benchmarkPerft(b *testing.B, fen string, depth int) {
 		_ = perft(game, depth)
 	}
 }
+
+func BenchmarkGenerateLegal(b *testing.B) {
+	b.ReportAllocs()
+	game := &Game{}
+	if err := game.LoadFen("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"); err != nil {
+		b.Fatalf("init fen: %v", err)
+	}
+	var list MoveList
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		game.GenerateLegal(&list)
+	}
+}
+
+func BenchmarkCountLegalMoves(b *testing.B) {
+	b.ReportAllocs()
+	game := &Game{}
+	if err := game.LoadFen("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"); err != nil {
+		b.Fatalf("init fen: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		game.CountLegalMoves()
+	}
+}
+
+func benchmarkPositions(b *testing.B) []Position {
+	var positions []Position
+	for _, fen := range stagedTestFens {
+		p, err := NewPosition(fen)
+		if err != nil {
+			b.Fatalf("init fen: %v", err)
+		}
+		positions = append(positions, p)
+	}
+	return positions
+}
+
+func BenchmarkPackPositions(b *testing.B) {
+	b.ReportAllocs()
+	positions := benchmarkPositions(b)
+	packed := make([]PackedPosition, len(positions))
+	for i := 0; i < b.N; i++ {
+		if _, err := PackPositions(packed, positions); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N*len(positions))/b.Elapsed().Seconds(), "positions/s")
+}
+
+func BenchmarkUnpackPositions(b *testing.B) {
+	b.ReportAllocs()
+	positions := benchmarkPositions(b)
+	packed := make([]PackedPosition, len(positions))
+	if _, err := PackPositions(packed, positions); err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := UnpackPositions(positions, packed); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N*len(positions))/b.Elapsed().Seconds(), "positions/s")
+}
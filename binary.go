@@ -3,34 +3,227 @@ package chessongo
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
+	"hash/crc32"
+	"slices"
+	"time"
 )
 
-// MarshalBinary encodes the board state into a byte slice.
+/*
+* Binary game format, version 1. Integers are little endian, varints are
+* those of encoding/binary.
+*
+* magic          4 bytes  "CHGB"
+* version        1 byte
+* flags          1 byte   BINARY_FLAG_*
+* start position 75 bytes squares, turn, castling, en passant, half and full
+*                         moves, laid out as in the legacy format
+* move count     uvarint
+* moves          1 byte each, the index of the move among the legal moves of
+*                its position ordered by from, to and promotion (there are
+*                never more than 218 legal moves)
+* clock          if BINARY_FLAG_CLOCK: base, increment, white and black
+*                remaining time in nanoseconds as uvarints, the running color
+*                as 1 byte and, when running, the time it started running as
+*                a varint of Unix nanoseconds
+* tags           if BINARY_FLAG_TAGS: uvarint count, then for each tag its
+*                name and value as uvarint length and bytes
+* checksum       4 bytes  CRC-32 (IEEE) of everything before it
+*
+* The legacy format, still decoded by UnmarshalBinary, is the current
+* position followed by the repetition counts (see MarshalLegacyBinary). Its
+* first byte is a piece or EMPTY, which can never be the first byte of the
+* magic.
+ */
+
+const (
+	BINARY_VERSION = 1
+	// The game comes with a clock
+	BINARY_FLAG_CLOCK = 1 << 0
+	// The game comes with tags
+	BINARY_FLAG_TAGS = 1 << 1
+)
+
+const (
+	E_BINARY_TRUNCATED = "e:binary:truncated"
+	E_BINARY_VERSION   = "e:binary:version"
+	E_BINARY_CHECKSUM  = "e:binary:checksum"
+	E_BINARY_CORRUPT   = "e:binary:corrupt"
+)
+
+var binaryMagic = [4]byte{'C', 'H', 'G', 'B'}
+
+// Size of the position block shared by both formats
+const binaryPositionSize = 75
+
+// Tag is a name/value pair describing a game, such as a PGN tag
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// BinaryExtras is what the binary format can carry along with a game
+type BinaryExtras struct {
+	Clock *Clock
+	Tags  []Tag
+}
+
+// MarshalBinary encodes the game, including the moves played since its start
+// position, in the versioned binary format
 func (g *Game) MarshalBinary() ([]byte, error) {
-	// Fixed size: 64 (squares) + 1 (turn) + 1 (castling) + 1 (enpassant) + 4 (half) + 4 (full) + 4 (hist count) = 79 bytes
-	// Variable size: 9 * historyCount
-	buf := make([]byte, 79+len(g.PositionHistory)*9)
+	return g.MarshalBinaryWith(BinaryExtras{})
+}
+
+// MarshalBinaryWith encodes the game together with a clock and tags
+func (g *Game) MarshalBinaryWith(extras BinaryExtras) ([]byte, error) {
+	start := g.Position
+	if g.Fen != "" {
+		// History starts from the position the game was loaded from
+		var err error
+		if start, err = NewPosition(g.Fen); err != nil {
+			return nil, err
+		}
+	} else if len(g.History) > 0 {
+		return nil, fmt.Errorf(E_INVALID_FEN)
+	}
+	var flags byte
+	if extras.Clock != nil {
+		flags |= BINARY_FLAG_CLOCK
+	}
+	if len(extras.Tags) > 0 {
+		flags |= BINARY_FLAG_TAGS
+	}
 
-	copy(buf[0:64], fromPieces(g.Squares))
-	buf[64] = uint8(g.Turn)
-	buf[65] = uint8(g.Castling)
-	buf[66] = uint8(g.EnPassant)
-	binary.LittleEndian.PutUint32(buf[67:71], uint32(g.HalfMoves))
-	binary.LittleEndian.PutUint32(buf[71:75], uint32(g.FullMoves))
-	binary.LittleEndian.PutUint32(buf[75:79], uint32(len(g.PositionHistory)))
+	buf := make([]byte, 0, 6+binaryPositionSize+binary.MaxVarintLen64+len(g.History)+4)
+	buf = append(buf, binaryMagic[:]...)
+	buf = append(buf, BINARY_VERSION, flags)
+	buf = appendPosition(buf, &start)
 
-	offset := 79
-	for hash, count := range g.PositionHistory {
-		binary.LittleEndian.PutUint64(buf[offset:offset+8], hash)
-		buf[offset+8] = uint8(count)
-		offset += 9
+	buf = binary.AppendUvarint(buf, uint64(len(g.History)))
+	replay := NewGameFromPosition(start)
+	for _, state := range g.History {
+		index, ok := legalMoveIndex(replay.LegalMoves, state.Move)
+		if !ok {
+			return nil, fmt.Errorf(E_ILLEGAL_MOVE)
+		}
+		buf = append(buf, byte(index))
+		replay.MakeMove(state.Move)
 	}
 
-	return buf, nil
+	if c := extras.Clock; c != nil {
+		buf = binary.AppendUvarint(buf, uint64(c.Control.Base))
+		buf = binary.AppendUvarint(buf, uint64(c.Control.Increment))
+		buf = binary.AppendUvarint(buf, uint64(max(c.white, 0)))
+		buf = binary.AppendUvarint(buf, uint64(max(c.black, 0)))
+		buf = append(buf, byte(c.running))
+		if c.running != NO_COLOR {
+			buf = binary.AppendVarint(buf, c.since.UnixNano())
+		}
+	}
+	if len(extras.Tags) > 0 {
+		buf = binary.AppendUvarint(buf, uint64(len(extras.Tags)))
+		for _, tag := range extras.Tags {
+			buf = appendBinaryString(buf, tag.Name)
+			buf = appendBinaryString(buf, tag.Value)
+		}
+	}
+	return binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf)), nil
 }
 
-// UnmarshalBinary decodes the board state from a byte slice.
+// UnmarshalBinary decodes a game encoded by MarshalBinary, or by
+// MarshalLegacyBinary, discarding any clock and tags
 func (g *Game) UnmarshalBinary(data []byte) error {
+	_, err := g.UnmarshalBinaryWith(data)
+	return err
+}
+
+// UnmarshalBinaryWith decodes a game together with the clock and tags it was
+// encoded with. The moves are replayed from the start position, so they can
+// be undone and count towards repetitions. Legacy data has no moves and no
+// extras.
+func (g *Game) UnmarshalBinaryWith(data []byte) (BinaryExtras, error) {
+	if len(data) < len(binaryMagic) || [4]byte(data[:4]) != binaryMagic {
+		return BinaryExtras{}, g.unmarshalLegacyBinary(data)
+	}
+	if len(data) < 6+binaryPositionSize+4 {
+		return BinaryExtras{}, fmt.Errorf(E_BINARY_TRUNCATED)
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return BinaryExtras{}, fmt.Errorf(E_BINARY_CHECKSUM)
+	}
+	if body[4] != BINARY_VERSION {
+		return BinaryExtras{}, fmt.Errorf(E_BINARY_VERSION)
+	}
+	flags := body[5]
+	r := binaryReader{data: body[6+binaryPositionSize:]}
+	start, ok := readPosition(body[6 : 6+binaryPositionSize])
+	if !ok {
+		return BinaryExtras{}, fmt.Errorf(E_BINARY_CORRUPT)
+	}
+
+	// replay has no observers: ours hear about the decoded game as a whole
+	replay := NewGameFromPosition(start)
+	count := r.uvarint()
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		m, ok := legalMoveAt(replay.LegalMoves, int(r.byte()))
+		if !ok {
+			return BinaryExtras{}, fmt.Errorf(E_BINARY_CORRUPT)
+		}
+		replay.MakeMove(m)
+	}
+
+	var extras BinaryExtras
+	if flags&BINARY_FLAG_CLOCK > 0 {
+		c := &Clock{}
+		c.Control.Base = time.Duration(r.uvarint())
+		c.Control.Increment = time.Duration(r.uvarint())
+		c.white = time.Duration(r.uvarint())
+		c.black = time.Duration(r.uvarint())
+		c.running = Color(r.byte())
+		switch c.running {
+		case NO_COLOR:
+		case WHITE, BLACK:
+			c.since = time.Unix(0, r.varint())
+		default:
+			return BinaryExtras{}, fmt.Errorf(E_BINARY_CORRUPT)
+		}
+		extras.Clock = c
+	}
+	if flags&BINARY_FLAG_TAGS > 0 {
+		n := r.uvarint()
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			extras.Tags = append(extras.Tags, Tag{Name: r.string(), Value: r.string()})
+		}
+	}
+	if r.err != nil {
+		return BinaryExtras{}, r.err
+	}
+	if len(r.data) > 0 {
+		return BinaryExtras{}, fmt.Errorf(E_BINARY_CORRUPT)
+	}
+
+	g.replaceWith(replay)
+	g.emitLoaded(SOURCE_BINARY)
+	return extras, nil
+}
+
+// MarshalLegacyBinary encodes the current position and its repetition counts
+// in the original, unversioned layout, without moves.
+func (g *Game) MarshalLegacyBinary() ([]byte, error) {
+	// Fixed size: 64 (squares) + 1 (turn) + 1 (castling) + 1 (enpassant) + 4 (half) + 4 (full) + 4 (hist count) = 79 bytes
+	// Variable size: 9 * historyCount
+	buf := make([]byte, 0, 79+len(g.PositionHistory)*9)
+	buf = appendPosition(buf, &g.Position)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(g.PositionHistory)))
+	for hash, count := range g.PositionHistory {
+		buf = binary.LittleEndian.AppendUint64(buf, hash)
+		buf = append(buf, uint8(count))
+	}
+	return buf, nil
+}
+
+func (g *Game) unmarshalLegacyBinary(data []byte) error {
 	if len(data) < 79 {
 		return errors.New("insufficient data for board")
 	}
@@ -43,12 +236,7 @@ func (g *Game) UnmarshalBinary(data []byte) error {
 			g.addPiece(piece, i)
 		}
 	}
-
-	g.Turn = Color(data[64])
-	g.Castling = int(data[65])
-	g.EnPassant = Square(data[66])
-	g.HalfMoves = int(binary.LittleEndian.Uint32(data[67:71]))
-	g.FullMoves = int(binary.LittleEndian.Uint32(data[71:75]))
+	g.readPositionFields(data)
 
 	count := int(binary.LittleEndian.Uint32(data[75:79]))
 	expectedSize := 79 + count*9
@@ -69,23 +257,138 @@ func (g *Game) UnmarshalBinary(data []byte) error {
 	g.ZobristHash = g.computeZobrist()
 
 	// Update legal moves and check status
-	g.GenerateLegalMoves()
-	g.IsCheck = g.ComputeIsCheck()
-	g.IsCheckmate = g.IsCheck && !g.hasMoves()
-	g.IsStalement = !g.IsCheckmate && !g.hasMoves()
-	g.IsMaterialDraw = g.hasInsufficientMaterial()
-	g.IsThreefoldRepetition = g.checkThreefoldRepetition()
-	g.IsFiftyMoveRule = g.checkFiftyMoveRule()
-	g.IsSeventyFiveMoveRule = g.checkSeventyFiveMoveRule()
-	g.IsFinished = g.IsCheckmate || g.IsStalement || g.IsMaterialDraw || g.IsFivefoldRepetition() || g.IsSeventyFiveMoveRule
+	g.refreshStatus()
+	g.emitLoaded(SOURCE_BINARY)
 
 	return nil
 }
 
-func fromPieces(p [64]Piece) []byte {
-	b := make([]byte, 64)
-	for i, v := range p {
-		b[i] = uint8(v)
+// Appends the 75 byte position block
+func appendPosition(buf []byte, p *Position) []byte {
+	for _, piece := range p.Squares {
+		buf = append(buf, uint8(piece))
+	}
+	buf = append(buf, uint8(p.Turn), uint8(p.Castling), uint8(p.EnPassant))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(p.HalfMoves))
+	return binary.LittleEndian.AppendUint32(buf, uint32(p.FullMoves))
+}
+
+// Reads everything but the squares from a position block
+func (p *Position) readPositionFields(data []byte) {
+	p.Turn = Color(data[64])
+	p.Castling = int(data[65])
+	p.EnPassant = Square(data[66])
+	p.HalfMoves = int(binary.LittleEndian.Uint32(data[67:71]))
+	p.FullMoves = int(binary.LittleEndian.Uint32(data[71:75]))
+}
+
+// Reads a position block, checking the pieces, the turn and the en passant
+// square
+func readPosition(data []byte) (Position, bool) {
+	var p Position
+	for i := 0; i < 64; i++ {
+		piece := Piece(data[i])
+		if _, ok := PIECE_TO_RUNE[piece]; piece != EMPTY && !ok {
+			return Position{}, false
+		}
+		p.addPiece(piece, i)
+	}
+	p.readPositionFields(data)
+	if (p.Turn != WHITE && p.Turn != BLACK) || p.EnPassant > 63 {
+		return Position{}, false
+	}
+	p.ZobristHash = p.computeZobrist()
+	return p, true
+}
+
+// Orders moves by from, to and promotion, which unlike the generation order
+// is part of the binary format
+func binaryMoveKey(m Move) uint32 {
+	return uint32(m.From()) | uint32(m.To())<<6 | uint32(m.GetPromotionTo())<<12
+}
+
+// Returns the index of m among the sorted legal moves
+func legalMoveIndex(legal []Move, m Move) (int, bool) {
+	key := binaryMoveKey(m)
+	index, found := 0, false
+	for _, l := range legal {
+		switch k := binaryMoveKey(l); {
+		case k < key:
+			index++
+		case k == key:
+			found = true
+		}
+	}
+	return index, found
+}
+
+// Returns the legal move at index among the sorted legal moves
+func legalMoveAt(legal []Move, index int) (Move, bool) {
+	if index >= len(legal) {
+		return 0, false
+	}
+	sorted := slices.Clone(legal)
+	slices.SortFunc(sorted, func(a, b Move) int {
+		return int(binaryMoveKey(a)) - int(binaryMoveKey(b))
+	})
+	return sorted[index], true
+}
+
+func appendBinaryString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// Reads the variable part of the binary format, remembering the first error
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+func (r *binaryReader) fail() {
+	if r.err == nil {
+		r.err = fmt.Errorf(E_BINARY_TRUNCATED)
 	}
+	r.data = nil
+}
+
+func (r *binaryReader) byte() byte {
+	if len(r.data) == 0 {
+		r.fail()
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
 	return b
 }
+
+func (r *binaryReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *binaryReader) varint() int64 {
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *binaryReader) string() string {
+	n := r.uvarint()
+	if n > uint64(len(r.data)) {
+		r.fail()
+		return ""
+	}
+	s := string(r.data[:n])
+	r.data = r.data[n:]
+	return s
+}
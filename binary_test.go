@@ -1,7 +1,11 @@
 package chessongo
 
 import (
+	"encoding/binary"
+	"hash/crc32"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -44,3 +48,103 @@ func TestBinaryEncoding(t *testing.T) {
 func (g *Game) Occupation() uint64 {
 	return uint64(g.Occupied)
 }
+
+func TestBinaryKeepsMoves(t *testing.T) {
+	g, err := LoadPGNGame(`[FEN "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"]
+1. O-O-O O-O 2. Rd7 Rab8 3. Rhh7 Rbe8 4. Rh8+ Kxh8`)
+	require.NoError(t, err)
+	data, err := g.MarshalBinary()
+	require.NoError(t, err)
+	// header, start position, move count, one byte per move and checksum
+	require.Len(t, data, 6+75+1+8+4)
+
+	decoded := &Game{}
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, g.ToFen(), decoded.ToFen())
+	require.Equal(t, g.History, decoded.History)
+	require.Equal(t, g.PositionHistory, decoded.PositionHistory)
+
+	for i := len(g.History) - 1; i >= 0; i-- {
+		m := decoded.History[i].Move
+		decoded.UndoMove(m)
+		g.UndoMove(m)
+		require.Equal(t, g.ToFen(), decoded.ToFen())
+	}
+	require.Equal(t, "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", decoded.ToFen())
+}
+
+func TestBinaryExtras(t *testing.T) {
+	g, err := LoadPGNGame("1. d4 Nf6 2. c4 e6")
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 5)
+	clock := NewClock(TimeControl{Base: 5 * time.Minute, Increment: 3 * time.Second})
+	clock.Start(BLACK, now.Add(-2*time.Second))
+	clock.Press(now)
+	tags := []Tag{{"Event", "Casual"}, {"White", "Anne"}, {"Black", ""}}
+
+	data, err := g.MarshalBinaryWith(BinaryExtras{Clock: clock, Tags: tags})
+	require.NoError(t, err)
+	decoded := &Game{}
+	extras, err := decoded.UnmarshalBinaryWith(data)
+	require.NoError(t, err)
+	require.Equal(t, tags, extras.Tags)
+	require.Equal(t, clock.Control, extras.Clock.Control)
+	require.Equal(t, Color(WHITE), extras.Clock.Running())
+	later := now.Add(time.Second)
+	require.Equal(t, clock.Remaining(WHITE, later), extras.Clock.Remaining(WHITE, later))
+	require.Equal(t, clock.Remaining(BLACK, later), extras.Clock.Remaining(BLACK, later))
+	require.Equal(t, g.History, decoded.History)
+}
+
+func TestBinaryLegacyLayout(t *testing.T) {
+	g, err := LoadPGNGame("1. e4 e5 2. Nf3 Nc6")
+	require.NoError(t, err)
+	data, err := g.MarshalLegacyBinary()
+	require.NoError(t, err)
+	require.Len(t, data, 79+9*len(g.PositionHistory))
+
+	decoded := &Game{}
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, g.ToFen(), decoded.ToFen())
+	require.Equal(t, g.PositionHistory, decoded.PositionHistory)
+	require.Empty(t, decoded.History)
+
+	// A legacy game starts from its current position in the new format
+	data, err = decoded.MarshalBinary()
+	require.NoError(t, err)
+	again := &Game{}
+	require.NoError(t, again.UnmarshalBinary(data))
+	require.Equal(t, g.ToFen(), again.ToFen())
+}
+
+func TestBinaryRejectsDamagedData(t *testing.T) {
+	g, err := LoadPGNGame("1. e4 e5")
+	require.NoError(t, err)
+	data, err := g.MarshalBinaryWith(BinaryExtras{Tags: []Tag{{"Site", "Here"}}})
+	require.NoError(t, err)
+
+	flipped := slices.Clone(data)
+	flipped[len(flipped)-8] ^= 0xFF
+	require.EqualError(t, (&Game{}).UnmarshalBinary(flipped), E_BINARY_CHECKSUM)
+
+	require.EqualError(t, (&Game{}).UnmarshalBinary(data[:20]), E_BINARY_TRUNCATED)
+
+	future := slices.Clone(data[:len(data)-4])
+	future[4] = BINARY_VERSION + 1
+	future = binary.LittleEndian.AppendUint32(future, crc32.ChecksumIEEE(future))
+	require.EqualError(t, (&Game{}).UnmarshalBinary(future), E_BINARY_VERSION)
+
+	// a move index past the legal moves
+	bad := slices.Clone(data[:len(data)-4])
+	bad[6+75+1] = 200
+	bad = binary.LittleEndian.AppendUint32(bad, crc32.ChecksumIEEE(bad))
+	require.EqualError(t, (&Game{}).UnmarshalBinary(bad), E_BINARY_CORRUPT)
+
+	// a clock running for no color, its last byte when stopped
+	data, err = g.MarshalBinaryWith(BinaryExtras{Clock: NewClock(TimeControl{Base: time.Minute})})
+	require.NoError(t, err)
+	bad = slices.Clone(data[:len(data)-4])
+	bad[len(bad)-1] = 99
+	bad = binary.LittleEndian.AppendUint32(bad, crc32.ChecksumIEEE(bad))
+	require.EqualError(t, (&Game{}).UnmarshalBinary(bad), E_BINARY_CORRUPT)
+}
@@ -2,6 +2,7 @@ package chessongo
 
 import (
 	"math/rand"
+	"slices"
 	"sync"
 )
 
@@ -27,63 +28,59 @@ const (
 	BQS_ROOK_ORIGINAL_SQUARE = 0  // a8
 )
 
+// Game is a Position together with everything that depends on how it was
+// reached: the moves played, the repetition counts, the generated move lists
+// and the status of the game.
 type Game struct {
-	Fen         string
-	WhitePieces Bitboard
-	BlackPieces Bitboard
-	// _, pawns, knights, bishops, rooks, queens, king
-	Whites                [7]Bitboard
-	Blacks                [7]Bitboard
-	Occupied              Bitboard
-	Squares               [64]Piece
-	EnPassant             Square
-	Castling              int
-	HalfMoves             int
-	FullMoves             int
-	Turn                  Color
+	Position
+	Fen                   string
 	PseudoMoves           []Move
 	LegalMoves            []Move
 	PositionHistory       map[uint64]int
-	ZobristHash           uint64
 	IsCheck               bool
 	IsCheckmate           bool
 	IsStalement           bool
 	IsMaterialDraw        bool
+	IsDeadDraw            bool
 	IsThreefoldRepetition bool
 	IsFiftyMoveRule       bool
 	IsSeventyFiveMoveRule bool
 	IsFinished            bool
 	History               []GameState
+	redo                  []Move // moves taken back, the next one to replay last
+	premoves              []Move
+	premoveColor          Color
+	premoveMode           PremoveMode
+	observers             []observerEntry
+	nextObserverID        int
 }
 
 func (g *Game) Reset() {
 	g.Fen = ""
-	g.WhitePieces = 0
-	g.BlackPieces = 0
-	for _, kind := range [6]Piece{PAWN, KNIGHT, BISHOP, ROOK, QUEEN, KING} {
-		g.Whites[kind] = 0
-		g.Blacks[kind] = 0
-	}
-	g.Occupied = 0
-	g.Squares = [64]Piece{}
-	g.EnPassant = 0
-	g.Castling = 0
-	g.HalfMoves = 0
-	g.FullMoves = 0
-	g.Turn = WHITE
+	g.Position = Position{Turn: WHITE}
 	g.PseudoMoves = []Move{}
 	g.LegalMoves = []Move{}
 	g.PositionHistory = map[uint64]int{}
-	g.ZobristHash = 0
 	g.IsCheck = false
 	g.IsCheckmate = false
 	g.IsStalement = false
 	g.IsMaterialDraw = false
+	g.IsDeadDraw = false
 	g.IsThreefoldRepetition = false
 	g.IsFiftyMoveRule = false
 	g.IsSeventyFiveMoveRule = false
 	g.IsFinished = false
 	g.History = []GameState{}
+	g.redo = nil
+	g.premoves = nil
+}
+
+// Replaces the game with loaded, keeping what belongs to the game rather than
+// to its position: the observers and the premove mode
+func (g *Game) replaceWith(loaded *Game) {
+	observers, nextID, mode := g.observers, g.nextObserverID, g.premoveMode
+	*g = *loaded
+	g.observers, g.nextObserverID, g.premoveMode = observers, nextID, mode
 }
 
 func NewGame() *Game {
@@ -95,32 +92,28 @@ func NewGame() *Game {
 
 func CloneGame(g *Game) Game {
 	clone := Game{
-		Fen:             g.Fen,
-		WhitePieces:     g.WhitePieces,
-		BlackPieces:     g.BlackPieces,
-		Whites:          [7]Bitboard{},
-		Blacks:          [7]Bitboard{},
-		Squares:         [64]Piece{},
-		Occupied:        g.Occupied,
-		EnPassant:       g.EnPassant,
-		Castling:        g.Castling,
-		HalfMoves:       g.HalfMoves,
-		FullMoves:       g.FullMoves,
-		Turn:            g.Turn,
-		PseudoMoves:     []Move{},
-		LegalMoves:      []Move{},
-		PositionHistory: map[uint64]int{},
-		ZobristHash:     g.ZobristHash,
-		IsCheck:         g.IsCheck,
-		IsCheckmate:     g.IsCheckmate,
-		IsStalement:     g.IsStalement,
-		IsMaterialDraw:  g.IsMaterialDraw,
-		IsFinished:      g.IsFinished,
-		History:         make([]GameState, len(g.History)),
+		Position:              g.Position,
+		Fen:                   g.Fen,
+		PseudoMoves:           make([]Move, len(g.PseudoMoves)),
+		LegalMoves:            make([]Move, len(g.LegalMoves)),
+		PositionHistory:       make(map[uint64]int, len(g.PositionHistory)),
+		IsCheck:               g.IsCheck,
+		IsCheckmate:           g.IsCheckmate,
+		IsStalement:           g.IsStalement,
+		IsMaterialDraw:        g.IsMaterialDraw,
+		IsDeadDraw:            g.IsDeadDraw,
+		IsThreefoldRepetition: g.IsThreefoldRepetition,
+		IsFiftyMoveRule:       g.IsFiftyMoveRule,
+		IsSeventyFiveMoveRule: g.IsSeventyFiveMoveRule,
+		IsFinished:            g.IsFinished,
+		History:               make([]GameState, len(g.History)),
+		redo:                  slices.Clone(g.redo),
+		premoves:              slices.Clone(g.premoves),
+		premoveColor:          g.premoveColor,
+		premoveMode:           g.premoveMode,
 	}
-	copy(clone.Whites[:], g.Whites[:])
-	copy(clone.Blacks[:], g.Blacks[:])
-	copy(clone.Squares[:], g.Squares[:])
+	copy(clone.PseudoMoves, g.PseudoMoves)
+	copy(clone.LegalMoves, g.LegalMoves)
 	copy(clone.History, g.History)
 	for k, v := range g.PositionHistory {
 		clone.PositionHistory[k] = v
@@ -128,8 +121,8 @@ func CloneGame(g *Game) Game {
 	return clone
 }
 
-func (g *Game) addPiece(piece Piece, index int) {
-	g.Squares[index] = piece
+func (p *Position) addPiece(piece Piece, index int) {
+	p.Squares[index] = piece
 	if piece == EMPTY {
 		return
 	}
@@ -137,26 +130,26 @@ func (g *Game) addPiece(piece Piece, index int) {
 	kind := piece.Kind()
 	switch piece.Color() {
 	case WHITE:
-		g.Whites[kind] |= bit
-		g.WhitePieces |= bit
+		p.Whites[kind] |= bit
+		p.WhitePieces |= bit
 	case BLACK:
-		g.Blacks[kind] |= bit
-		g.BlackPieces |= bit
+		p.Blacks[kind] |= bit
+		p.BlackPieces |= bit
 	}
-	g.Occupied |= bit
+	p.Occupied |= bit
 }
 
 // Get our pawns and opponent's
-func (g *Game) GetPawns() (Bitboard, Bitboard) {
-	if g.Turn == WHITE {
-		return g.Whites[PAWN], g.Blacks[PAWN]
+func (p *Position) GetPawns() (Bitboard, Bitboard) {
+	if p.Turn == WHITE {
+		return p.Whites[PAWN], p.Blacks[PAWN]
 	}
-	return g.Blacks[PAWN], g.Whites[PAWN]
+	return p.Blacks[PAWN], p.Whites[PAWN]
 }
 
 // Get our color and opponent's
-func (g *Game) GetColors() (Color, Color) {
-	if g.Turn == WHITE {
+func (p *Position) GetColors() (Color, Color) {
+	if p.Turn == WHITE {
 		return WHITE, BLACK
 	}
 	return BLACK, WHITE
@@ -166,12 +159,12 @@ func (g *Game) hasMoves() bool {
 	return len(g.LegalMoves) > 0
 }
 
-func (g *Game) ShouldIncFullMoves(m Move) bool {
-	return g.Squares[m.From()].Color() == BLACK
+func (p *Position) ShouldIncFullMoves(m Move) bool {
+	return p.Squares[m.From()].Color() == BLACK
 }
 
-func (g *Game) ShouldResetPly(m Move) bool {
-	return m.GetCapturedPiece() > 0 || g.Squares[m.From()].Kind() == PAWN
+func (p *Position) ShouldResetPly(m Move) bool {
+	return m.GetCapturedPiece() > 0 || p.Squares[m.From()].Kind() == PAWN
 }
 
 var (
@@ -233,24 +226,26 @@ func zobristPieceIndex(p Piece) int {
 	}
 }
 
-func (g *Game) computeZobrist() uint64 {
+func (p *Position) computeZobrist() uint64 {
 	ensureZobrist()
 	h := uint64(0)
-	for sq, piece := range g.Squares {
+	for sq, piece := range p.Squares {
 		idx := zobristPieceIndex(piece)
 		if idx >= 0 {
 			h ^= zobristPiece[idx][sq]
 		}
 	}
 
-	h ^= zobristCastling[g.Castling&0xF]
+	h ^= zobristCastling[p.Castling&0xF]
 
-	if g.EnPassant != 0 {
-		file := g.EnPassant.File()
+	// Per FIDE 9.2 the en-passant square only distinguishes positions when
+	// the capture can actually be played.
+	if p.hasLegalEnPassant() {
+		file := p.EnPassant.File()
 		h ^= zobristEnPassant[file]
 	}
 
-	if g.Turn == BLACK {
+	if p.Turn == BLACK {
 		h ^= zobristTurnToMove
 	}
 
@@ -273,6 +268,28 @@ func (g *Game) IsFivefoldRepetition() bool {
 	return g.PositionHistory != nil && g.PositionHistory[g.ZobristHash] >= 5
 }
 
+// Regenerates legal moves and recomputes every status flag of the current position
+func (g *Game) refreshStatus() {
+	g.GenerateLegalMoves()
+	g.IsCheck = g.ComputeIsCheck()
+	g.IsCheckmate = g.IsCheck && !g.hasMoves()
+	g.IsStalement = !g.IsCheckmate && !g.hasMoves()
+	g.IsMaterialDraw = g.hasInsufficientMaterial()
+	g.IsDeadDraw = !g.IsCheckmate && g.IsDeadPosition()
+	g.IsThreefoldRepetition = g.checkThreefoldRepetition()
+	g.IsFiftyMoveRule = g.checkFiftyMoveRule()
+	g.IsSeventyFiveMoveRule = g.checkSeventyFiveMoveRule()
+	g.IsFinished = (g.IsCheckmate || g.IsStalement || g.IsMaterialDraw || g.IsDeadDraw || g.IsFivefoldRepetition() || g.IsSeventyFiveMoveRule)
+}
+
+// Returns a copy of the game with its legal moves and status computed, for
+// the queries that must leave the game untouched
+func (g *Game) withStatus() *Game {
+	clone := CloneGame(g)
+	clone.refreshStatus()
+	return &clone
+}
+
 func (g *Game) checkFiftyMoveRule() bool {
 	return g.HalfMoves >= 100
 }
@@ -282,6 +299,8 @@ func (g *Game) checkSeventyFiveMoveRule() bool {
 }
 
 type GameState struct {
+	// Move that left this state
+	Move          Move
 	CapturedPiece Piece
 	Castling      int
 	EnPassant     Square
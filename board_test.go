@@ -44,7 +44,7 @@ func TestRepetitionDetection(t *testing.T) {
 	require.True(t, g.IsFivefoldRepetition())
 }
 
-func TestRepetitionBrokenByEnPassantChange(t *testing.T) {
+func TestRepetitionIgnoresUncapturableEnPassant(t *testing.T) {
 	fen := "rnbqkbnr/pppppppp/8/4p3/3P4/8/PPP1PPPP/RNBQKBNR w KQkq e6 0 2"
 	g := &Game{}
 	require.NoError(t, g.LoadFen(fen))
@@ -61,7 +61,9 @@ func TestRepetitionBrokenByEnPassantChange(t *testing.T) {
 		play(mv[0], mv[1])
 	}
 
-	// After a full cycle the board pieces match but en-passant is cleared, so hash differs.
+	// No white pawn can capture on e6, so the en-passant square does not make
+	// the start position different from the one reached after the cycle.
+	require.Equal(t, 2, g.PositionHistory[g.ZobristHash])
 	require.False(t, g.IsThreefoldRepetition)
 }
 
@@ -0,0 +1,104 @@
+package chessongo
+
+import "time"
+
+// TimeControl is a Fischer time control: every player starts with Base and
+// gains Increment after each of their moves
+type TimeControl struct {
+	Base      time.Duration
+	Increment time.Duration
+}
+
+// Clock is a chess clock. It never reads the system time: every method takes
+// the current time, which keeps it deterministic and easy to drive from
+// tests, replays or a server's own time source. It is not safe for
+// concurrent use.
+type Clock struct {
+	Control      TimeControl
+	white, black time.Duration
+	// Color whose time is running, NO_COLOR while stopped
+	running Color
+	since   time.Time
+}
+
+func NewClock(control TimeControl) *Clock {
+	return &Clock{
+		Control: control,
+		white:   control.Base,
+		black:   control.Base,
+		running: NO_COLOR,
+	}
+}
+
+// Returns the remaining time of color as stored, not counting the running period
+func (c *Clock) stored(color Color) *time.Duration {
+	if color == WHITE {
+		return &c.white
+	}
+	return &c.black
+}
+
+// Running returns the color whose time is running, NO_COLOR while stopped
+func (c *Clock) Running() Color {
+	return c.running
+}
+
+// Start runs the clock of color from now on, stopping the other one
+func (c *Clock) Start(color Color, now time.Time) {
+	c.Stop(now)
+	c.running = color
+	c.since = now
+}
+
+// Stop stops the clock, charging the running side the time used so far
+func (c *Clock) Stop(now time.Time) {
+	if c.running != NO_COLOR {
+		*c.stored(c.running) -= now.Sub(c.since)
+	}
+	c.running = NO_COLOR
+}
+
+// Press ends the turn of the running side at now: it is charged the time it
+// used, credited the increment and the opponent's clock is started. It
+// returns the time the move took. Pressing a stopped clock starts white's
+// time and returns 0.
+func (c *Clock) Press(now time.Time) time.Duration {
+	return c.PressAfter(now, now.Sub(c.since))
+}
+
+// PressAfter is Press with the time the move took given explicitly instead
+// of measured, e.g. 0 for a move that was queued in advance
+func (c *Clock) PressAfter(now time.Time, used time.Duration) time.Duration {
+	mover := c.running
+	if mover == NO_COLOR {
+		c.Start(WHITE, now)
+		return 0
+	}
+	*c.stored(mover) += c.Control.Increment - used
+	c.running = opponentColor(mover)
+	c.since = now
+	return used
+}
+
+// Remaining returns the time color has left at now; it is negative once the
+// flag has fallen
+func (c *Clock) Remaining(color Color, now time.Time) time.Duration {
+	remaining := *c.stored(color)
+	if color == c.running {
+		remaining -= now.Sub(c.since)
+	}
+	return remaining
+}
+
+// Flagged returns the color that ran out of time at now, if any
+func (c *Clock) Flagged(now time.Time) (Color, bool) {
+	if c.running != NO_COLOR && c.Remaining(c.running, now) <= 0 {
+		return c.running, true
+	}
+	for _, color := range []Color{WHITE, BLACK} {
+		if *c.stored(color) <= 0 {
+			return color, true
+		}
+	}
+	return NO_COLOR, false
+}
@@ -0,0 +1,37 @@
+package chessongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	c := NewClock(TimeControl{Base: time.Minute, Increment: 2 * time.Second})
+	require.Equal(t, Color(NO_COLOR), c.Running())
+	require.Equal(t, time.Duration(0), c.Press(at(0)))
+	require.Equal(t, Color(WHITE), c.Running())
+
+	require.Equal(t, 10*time.Second, c.Press(at(10)))
+	require.Equal(t, 52*time.Second, c.Remaining(WHITE, at(10)))
+	require.Equal(t, Color(BLACK), c.Running())
+	require.Equal(t, 55*time.Second, c.Remaining(BLACK, at(15)))
+
+	// a move played in advance costs no time
+	require.Equal(t, time.Duration(0), c.PressAfter(at(15), 0))
+	require.Equal(t, 62*time.Second, c.Remaining(BLACK, at(15)))
+
+	c.Stop(at(20))
+	require.Equal(t, 47*time.Second, c.Remaining(WHITE, at(100)))
+	_, flagged := c.Flagged(at(1000))
+	require.False(t, flagged)
+
+	c.Start(WHITE, at(100))
+	color, flagged := c.Flagged(at(147))
+	require.True(t, flagged)
+	require.Equal(t, Color(WHITE), color)
+}
@@ -0,0 +1,21 @@
+// Command chessongo-server hosts chess games over HTTP and WebSocket; see
+// package server for the endpoints.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"chessongo/server"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	flag.Parse()
+
+	srv := server.New(server.Options{})
+	defer srv.Close()
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, srv))
+}
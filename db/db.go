@@ -0,0 +1,457 @@
+// Package db is an embedded database of chess games. It imports PGN
+// collections into a directory on disk and answers queries on game headers
+// and on the positions reached in the games.
+//
+// The directory holds two append-only files. games.dat holds one record per
+// game: its tags and its moves in the binary format of chessongo.Game.
+// positions.dat indexes the games by the Zobrist hash of every position they
+// reach, with fixed-size entries (hash, game, ply, move played from there).
+// Both are loaded into memory by Open; the index entries of a game are
+// written before the game, so a game interrupted by a crash leaves at most
+// index entries behind, which Open discards. A write that fails is undone
+// right away. A record that fails its checksum is not the trace of a crash:
+// Open reports it with ErrCorrupt and leaves the files alone.
+package db
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"chessongo"
+)
+
+const (
+	E_DB_CLOSED  = "e:db:closed"
+	E_DB_CORRUPT = "e:db:corrupt"
+	E_NO_GAME    = "e:db:no-game"
+	E_DB_BROKEN  = "e:db:broken"
+)
+
+var (
+	ErrClosed  = errors.New(E_DB_CLOSED)
+	ErrCorrupt = errors.New(E_DB_CORRUPT)
+	ErrNoGame  = errors.New(E_NO_GAME)
+	// ErrBroken is returned by AddGame once a failed write could not be undone
+	ErrBroken = errors.New(E_DB_BROKEN)
+)
+
+const (
+	gamesFile     = "games.dat"
+	positionsFile = "positions.dat"
+	// hash (8), game (4), ply (2), move (4)
+	positionEntrySize = 18
+	// Games longer than this are not indexed past it
+	maxIndexedPly = 0xFFFF
+	// Larger game records can only be garbage
+	maxRecordSize = 1 << 24
+)
+
+// GameID identifies a game of a DB. Games are numbered from 0 in the order
+// they were added.
+type GameID uint32
+
+// Header describes a stored game
+type Header struct {
+	ID GameID
+	// PGN tags, in order. Result is always set.
+	Tags  []chessongo.Tag
+	Plies int
+	// Where the record of the game starts in games.dat
+	offset int64
+}
+
+// Tag returns the value of the tag called name, or "" if the game has none
+func (h Header) Tag(name string) string {
+	for _, tag := range h.Tags {
+		if tag.Name == name {
+			return tag.Value
+		}
+	}
+	return ""
+}
+
+// An index entry: the game reached a position at ply and continued with move,
+// 0 at the end of the game
+type positionEntry struct {
+	game GameID
+	ply  uint16
+	move chessongo.Move
+}
+
+// DB is a game database stored in a directory. It is safe for concurrent use.
+type DB struct {
+	mu        sync.RWMutex
+	games     *os.File
+	positions *os.File
+	// End of games.dat
+	size int64
+	// End of positions.dat
+	indexed int64
+	headers []Header
+	index   map[uint64][]positionEntry
+	closed  bool
+	// Set when a failed write could not be undone
+	broken bool
+}
+
+// Open opens the database in dir, creating it if needed
+func Open(dir string) (*DB, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	games, err := os.OpenFile(filepath.Join(dir, gamesFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	positions, err := os.OpenFile(filepath.Join(dir, positionsFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		games.Close()
+		return nil, err
+	}
+	db := &DB{games: games, positions: positions, index: map[uint64][]positionEntry{}}
+	if err := db.load(); err != nil {
+		games.Close()
+		positions.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Reads both files, truncating the incomplete record or entry an interrupted
+// write left at their end
+func (db *DB) load() error {
+	r := bufio.NewReader(db.games)
+	for {
+		h, n, err := readGameRecord(r, db.size)
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		h.ID = GameID(len(db.headers))
+		db.headers = append(db.headers, h)
+		db.size += n
+	}
+	if err := truncate(db.games, db.size); err != nil {
+		return err
+	}
+
+	r = bufio.NewReader(db.positions)
+	var buf [positionEntrySize]byte
+	for {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return err
+		}
+		hash := binary.LittleEndian.Uint64(buf[0:8])
+		entry := positionEntry{
+			game: GameID(binary.LittleEndian.Uint32(buf[8:12])),
+			ply:  binary.LittleEndian.Uint16(buf[12:14]),
+			move: chessongo.Move(binary.LittleEndian.Uint32(buf[14:18])),
+		}
+		if int(entry.game) >= len(db.headers) {
+			// the game itself was never written
+			break
+		}
+		db.index[hash] = append(db.index[hash], entry)
+		db.indexed += positionEntrySize
+	}
+	return truncate(db.positions, db.indexed)
+}
+
+// Cuts f at size and moves its offset there
+func truncate(f *os.File, size int64) error {
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+	_, err := f.Seek(size, io.SeekStart)
+	return err
+}
+
+// Close flushes the database to disk and closes it
+func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return nil
+	}
+	db.closed = true
+	return errors.Join(db.positions.Sync(), db.games.Sync(), db.positions.Close(), db.games.Close())
+}
+
+// Len returns the number of games in the database
+func (db *DB) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return len(db.headers)
+}
+
+// AddGame stores g, with its moves from the position it was loaded from, and
+// indexes every position it reaches. The Result tag is added from the game
+// if tags lack it.
+func (db *DB) AddGame(g *chessongo.Game, tags []chessongo.Tag) (GameID, error) {
+	blob, err := g.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	h := Header{Tags: tags, Plies: len(g.History)}
+	if h.Tag("Result") == "" {
+		result, _ := g.Result()
+		h.Tags = append(append([]chessongo.Tag{}, tags...), chessongo.Tag{Name: "Result", Value: result})
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return 0, ErrClosed
+	}
+	if db.broken {
+		return 0, ErrBroken
+	}
+	h.ID = GameID(len(db.headers))
+	h.offset = db.size
+
+	hashes := make([]uint64, 0, len(g.History)+1)
+	entries := make([]positionEntry, 0, len(g.History)+1)
+	for ply, state := range g.History {
+		hashes = append(hashes, state.ZobristHash)
+		entries = append(entries, positionEntry{game: h.ID, ply: uint16(ply), move: state.Move})
+	}
+	hashes = append(hashes, g.ZobristHash)
+	entries = append(entries, positionEntry{game: h.ID, ply: uint16(len(g.History))})
+	if len(entries) > maxIndexedPly {
+		hashes, entries = hashes[:maxIndexedPly], entries[:maxIndexedPly]
+	}
+
+	buf := make([]byte, 0, len(entries)*positionEntrySize)
+	for i, entry := range entries {
+		buf = binary.LittleEndian.AppendUint64(buf, hashes[i])
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(entry.game))
+		buf = binary.LittleEndian.AppendUint16(buf, entry.ply)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(entry.move))
+	}
+	record := appendGameRecord(nil, h.Tags, h.Plies, blob)
+	if err := db.write(buf, record); err != nil {
+		return 0, err
+	}
+
+	db.size += int64(len(record))
+	db.indexed += int64(len(buf))
+	db.headers = append(db.headers, h)
+	for i, entry := range entries {
+		db.index[hashes[i]] = append(db.index[hashes[i]], entry)
+	}
+	return h.ID, nil
+}
+
+// Appends the index entries and then the record of a game. If either write
+// fails, both files are cut back to their previous end: index entries left
+// behind would be taken for those of the next game added, which gets the same
+// id. If that fails too, the database refuses new games.
+func (db *DB) write(entries, record []byte) error {
+	_, err := db.positions.Write(entries)
+	if err == nil {
+		_, err = db.games.Write(record)
+	}
+	if err == nil {
+		return nil
+	}
+	if undoErr := errors.Join(truncate(db.positions, db.indexed), truncate(db.games, db.size)); undoErr != nil {
+		db.broken = true
+		return errors.Join(err, undoErr)
+	}
+	return err
+}
+
+// ImportError tells why a game of a PGN collection could not be imported
+type ImportError struct {
+	// Position of the game in the collection, from 0
+	Index int
+	Err   error
+}
+
+func (e ImportError) Error() string {
+	return fmt.Sprintf("game %d: %v", e.Index, e.Err)
+}
+
+// ImportResult sums up an import
+type ImportResult struct {
+	Imported []GameID
+	// Games that could not be read; they do not stop the import
+	Failed []ImportError
+}
+
+// ImportPGN adds every game of a PGN collection. Games whose moves cannot be
+// played are skipped and reported in the result; errors reading r or writing
+// the database stop the import.
+func (db *DB) ImportPGN(r io.Reader) (ImportResult, error) {
+	var result ImportResult
+	s := chessongo.NewPGNScanner(r)
+	for i := 0; s.Scan(); i++ {
+		pgn := s.Text()
+		g, err := chessongo.LoadPGNGame(pgn)
+		if err != nil {
+			result.Failed = append(result.Failed, ImportError{Index: i, Err: err})
+			continue
+		}
+		id, err := db.AddGame(g, chessongo.ParsePGNTags(pgn))
+		if err != nil {
+			return result, err
+		}
+		result.Imported = append(result.Imported, id)
+	}
+	return result, s.Err()
+}
+
+// Header returns the header of game id
+func (db *DB) Header(id GameID) (Header, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if int(id) >= len(db.headers) {
+		return Header{}, false
+	}
+	return db.headers[id], true
+}
+
+// Game reads game id back from disk, with all its moves played
+func (db *DB) Game(id GameID) (*chessongo.Game, error) {
+	db.mu.RLock()
+	if db.closed {
+		db.mu.RUnlock()
+		return nil, ErrClosed
+	}
+	if int(id) >= len(db.headers) {
+		db.mu.RUnlock()
+		return nil, ErrNoGame
+	}
+	offset := db.headers[id].offset
+	end := db.size
+	if int(id)+1 < len(db.headers) {
+		end = db.headers[id+1].offset
+	}
+	record := make([]byte, end-offset)
+	_, err := db.games.ReadAt(record, offset)
+	db.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	_, blob, err := parseGameRecord(record)
+	if err != nil {
+		return nil, err
+	}
+	g := &chessongo.Game{}
+	if err := g.UnmarshalBinary(blob); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+/*
+* Game record layout:
+*
+* length   4 bytes  little endian length of the payload
+* payload  uvarint tag count, each tag as uvarint length and bytes for its
+*          name then its value, uvarint number of plies, then the game in the
+*          binary format of chessongo.Game
+* checksum 4 bytes  CRC-32 (IEEE) of the payload
+ */
+
+func appendGameRecord(buf []byte, tags []chessongo.Tag, plies int, blob []byte) []byte {
+	payload := binary.AppendUvarint(nil, uint64(len(tags)))
+	for _, tag := range tags {
+		payload = appendString(payload, tag.Name)
+		payload = appendString(payload, tag.Value)
+	}
+	payload = binary.AppendUvarint(payload, uint64(plies))
+	payload = append(payload, blob...)
+
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(payload)))
+	buf = append(buf, payload...)
+	return binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(payload))
+}
+
+// Reads the next record, returning its header and size
+func readGameRecord(r *bufio.Reader, offset int64) (Header, int64, error) {
+	var head [4]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return Header{}, 0, err
+	}
+	length := binary.LittleEndian.Uint32(head[:])
+	if length > maxRecordSize {
+		return Header{}, 0, ErrCorrupt
+	}
+	record := make([]byte, 4+int(length)+4)
+	copy(record, head[:])
+	if _, err := io.ReadFull(r, record[4:]); err != nil {
+		return Header{}, 0, err
+	}
+	h, _, err := parseGameRecord(record)
+	h.offset = offset
+	return h, int64(len(record)), err
+}
+
+// Splits a whole record into its header and its binary game
+func parseGameRecord(record []byte) (Header, []byte, error) {
+	if len(record) < 8 {
+		return Header{}, nil, ErrCorrupt
+	}
+	payload := record[4 : len(record)-4]
+	if int(binary.LittleEndian.Uint32(record[0:4])) != len(payload) ||
+		crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(record[len(record)-4:]) {
+		return Header{}, nil, ErrCorrupt
+	}
+	var h Header
+	count, ok := readUvarint(&payload)
+	if !ok {
+		return Header{}, nil, ErrCorrupt
+	}
+	for i := uint64(0); i < count; i++ {
+		name, okName := readString(&payload)
+		value, okValue := readString(&payload)
+		if !okName || !okValue {
+			return Header{}, nil, ErrCorrupt
+		}
+		h.Tags = append(h.Tags, chessongo.Tag{Name: name, Value: value})
+	}
+	plies, ok := readUvarint(&payload)
+	if !ok {
+		return Header{}, nil, ErrCorrupt
+	}
+	h.Plies = int(plies)
+	return h, payload, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func readUvarint(data *[]byte) (uint64, bool) {
+	v, n := binary.Uvarint(*data)
+	if n <= 0 {
+		return 0, false
+	}
+	*data = (*data)[n:]
+	return v, true
+}
+
+func readString(data *[]byte) (string, bool) {
+	n, ok := readUvarint(data)
+	if !ok || n > uint64(len(*data)) {
+		return "", false
+	}
+	s := string((*data)[:n])
+	*data = (*data)[n:]
+	return s, true
+}
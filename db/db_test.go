@@ -0,0 +1,192 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"chessongo"
+	"github.com/stretchr/testify/require"
+)
+
+const testCollection = `[Event "Casual"]
+[Site "Paris"]
+[Date "1858.10.21"]
+[White "Morphy, Paul"]
+[Black "Duke of Brunswick"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 d6 3. d4 Bg4 4. dxe5 Bxf3 5. Qxf3 dxe5 6. Bc4 Nf6 7. Qb3 Qe7
+8. Nc3 c6 9. Bg5 b5 10. Nxb5 cxb5 11. Bxb5+ Nbd7 12. O-O-O Rd8 13. Rxd7 Rxd7
+14. Rd1 Qe6 15. Bxd7+ Nxd7 16. Qb8+ Nxb8 17. Rd8# 1-0
+
+[Event "World Championship"]
+[Site "Reykjavik"]
+[Date "1972.07.23"]
+[White "Spassky, Boris"]
+[Black "Fischer, Robert James"]
+[Result "0-1"]
+
+1. c4 e6 2. Nf3 d5 3. d4 Nf6 4. Nc3 Be7 5. Bg5 O-O 0-1
+
+[Event "Broken"]
+
+1. e4 e5 2. Ke3 *
+
+[Event "Club"]
+[Date "1972.??.??"]
+[White "Anne"]
+[Black "Fischer, Robert James"]
+[Result "1/2-1/2"]
+
+1. e4 e5 2. Nf3 Nc6 3. Ng1 Nb8 4. Nf3 1/2-1/2
+
+[FEN "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"]
+
+1. e4 Kd7 *
+`
+
+func importTestCollection(t *testing.T) (*DB, string) {
+	dir := t.TempDir()
+	db, err := Open(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	result, err := db.ImportPGN(strings.NewReader(testCollection))
+	require.NoError(t, err)
+	require.Equal(t, []GameID{0, 1, 2, 3}, result.Imported)
+	require.Len(t, result.Failed, 1)
+	require.Equal(t, 2, result.Failed[0].Index)
+	return db, dir
+}
+
+func TestImportAndReopen(t *testing.T) {
+	db, dir := importTestCollection(t)
+	require.Equal(t, 4, db.Len())
+
+	h, ok := db.Header(1)
+	require.True(t, ok)
+	require.Equal(t, "Reykjavik", h.Tag("Site"))
+	require.Equal(t, 10, h.Plies)
+	// Result is added from the game when the tags lack it
+	h, _ = db.Header(3)
+	require.Equal(t, chessongo.RESULT_ONGOING, h.Tag("Result"))
+	_, ok = db.Header(4)
+	require.False(t, ok)
+
+	g, err := db.Game(0)
+	require.NoError(t, err)
+	require.True(t, g.IsCheckmate)
+	require.Len(t, g.History, 33)
+	require.NoError(t, db.Close())
+
+	reopened, err := Open(dir)
+	require.NoError(t, err)
+	defer reopened.Close()
+	require.Equal(t, 4, reopened.Len())
+	g, err = reopened.Game(3)
+	require.NoError(t, err)
+	require.Equal(t, "8/3k4/8/8/4P3/8/8/4K3 w - - 1 2", g.ToFen())
+	_, err = reopened.Game(9)
+	require.ErrorIs(t, err, ErrNoGame)
+
+	occurrences, err := reopened.FindPosition(chessongo.STARTING_POSITION_FEN)
+	require.NoError(t, err)
+	require.Len(t, occurrences, 3)
+}
+
+func TestOpenDiscardsInterruptedWrites(t *testing.T) {
+	db, dir := importTestCollection(t)
+	require.NoError(t, db.Close())
+
+	// A game whose index entries were written but not its record, and a
+	// record cut short
+	positions, err := os.OpenFile(filepath.Join(dir, positionsFile), os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	entry := make([]byte, positionEntrySize)
+	entry[8] = 4
+	_, err = positions.Write(entry)
+	require.NoError(t, err)
+	require.NoError(t, positions.Close())
+	games, err := os.OpenFile(filepath.Join(dir, gamesFile), os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = games.Write([]byte{200, 0, 0, 0, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, games.Close())
+
+	reopened, err := Open(dir)
+	require.NoError(t, err)
+	defer reopened.Close()
+	require.Equal(t, 4, reopened.Len())
+	require.Empty(t, reopened.FindHash(0))
+
+	g := chessongo.NewGame()
+	id, err := reopened.AddGame(g, []chessongo.Tag{{Name: "Event", Value: "Fresh"}})
+	require.NoError(t, err)
+	require.Equal(t, GameID(4), id)
+	loaded, err := reopened.Game(id)
+	require.NoError(t, err)
+	require.Equal(t, chessongo.STARTING_POSITION_FEN, loaded.ToFen())
+}
+
+func TestOpenRejectsCorruptRecord(t *testing.T) {
+	db, dir := importTestCollection(t)
+	h, _ := db.Header(1)
+	require.NoError(t, db.Close())
+
+	path := filepath.Join(dir, gamesFile)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[h.offset+10] ^= 0xFF
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	positions, err := os.ReadFile(filepath.Join(dir, positionsFile))
+	require.NoError(t, err)
+
+	_, err = Open(dir)
+	require.ErrorIs(t, err, ErrCorrupt)
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, data, after)
+	afterPositions, err := os.ReadFile(filepath.Join(dir, positionsFile))
+	require.NoError(t, err)
+	require.Equal(t, positions, afterPositions)
+}
+
+func TestFailedWriteIsUndone(t *testing.T) {
+	db, dir := importTestCollection(t)
+	info, err := os.Stat(filepath.Join(dir, positionsFile))
+	require.NoError(t, err)
+
+	// A read-only games.dat fails the write of the record, then cannot be cut
+	// back
+	games := db.games
+	readOnly, err := os.Open(filepath.Join(dir, gamesFile))
+	require.NoError(t, err)
+	db.games = readOnly
+	defer func() {
+		db.games = games
+		readOnly.Close()
+	}()
+	_, err = db.AddGame(chessongo.NewGame(), nil)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrBroken)
+	require.Equal(t, 4, db.Len())
+
+	// the index entries written before the record are gone
+	after, err := os.Stat(filepath.Join(dir, positionsFile))
+	require.NoError(t, err)
+	require.Equal(t, info.Size(), after.Size())
+	_, err = db.AddGame(chessongo.NewGame(), nil)
+	require.ErrorIs(t, err, ErrBroken)
+}
+
+func TestClosedDB(t *testing.T) {
+	db, err := Open(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	require.NoError(t, db.Close())
+	_, err = db.AddGame(chessongo.NewGame(), nil)
+	require.ErrorIs(t, err, ErrClosed)
+	_, err = db.Game(0)
+	require.ErrorIs(t, err, ErrClosed)
+}
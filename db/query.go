@@ -0,0 +1,215 @@
+package db
+
+import (
+	"slices"
+	"strings"
+
+	"chessongo"
+)
+
+// Query selects games by their tags. Empty fields match every game; text
+// fields match case-insensitive substrings of the tag.
+type Query struct {
+	// White or Black
+	Player string
+	White  string
+	Black  string
+	Event  string
+	Site   string
+	// Inclusive bounds on the Date tag, in PGN form "YYYY.MM.DD" or a prefix
+	// of it such as "1972" or "1972.07"
+	DateFrom string
+	DateTo   string
+	// Exact Result tag, e.g. "1-0"
+	Result string
+}
+
+func containsFold(s, substr string) bool {
+	return substr == "" || strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func (q Query) matches(h Header) bool {
+	white, black := h.Tag("White"), h.Tag("Black")
+	if q.Player != "" && !containsFold(white, q.Player) && !containsFold(black, q.Player) {
+		return false
+	}
+	if !containsFold(white, q.White) || !containsFold(black, q.Black) ||
+		!containsFold(h.Tag("Event"), q.Event) || !containsFold(h.Tag("Site"), q.Site) {
+		return false
+	}
+	if q.Result != "" && h.Tag("Result") != q.Result {
+		return false
+	}
+	date := knownDate(h.Tag("Date"))
+	if q.DateFrom != "" && (date == "" || compareDates(date, q.DateFrom) < 0) {
+		return false
+	}
+	if q.DateTo != "" && (date == "" || compareDates(date, q.DateTo) > 0) {
+		return false
+	}
+	return true
+}
+
+// Returns the part of a PGN date before its first unknown field, e.g. "1972"
+// for "1972.??.??"
+func knownDate(date string) string {
+	if i := strings.IndexByte(date, '?'); i >= 0 {
+		date = date[:i]
+	}
+	return strings.TrimRight(date, ".")
+}
+
+// Compares dates on the fields both know, so that a partly unknown date is
+// within any bound it could be within
+func compareDates(a, b string) int {
+	n := min(len(a), len(b))
+	return strings.Compare(a[:n], b[:n])
+}
+
+// Search returns the headers of the games matching q, in the order the games
+// were added
+func (db *DB) Search(q Query) []Header {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var found []Header
+	for _, h := range db.headers {
+		if q.matches(h) {
+			found = append(found, h)
+		}
+	}
+	return found
+}
+
+// Occurrence is a game reaching a position, at the given ply
+type Occurrence struct {
+	Game GameID
+	Ply  int
+}
+
+// Loads the position of fen, with its legal moves
+func loadPosition(fen string) (*chessongo.Game, error) {
+	g := &chessongo.Game{}
+	if err := g.LoadFen(fen); err != nil {
+		return nil, err
+	}
+	g.GenerateLegalMoves()
+	return g, nil
+}
+
+// FindPosition returns every time a game reached the position of fen, by
+// game then ply. Positions are matched by Zobrist hash, so the move counters
+// of fen do not matter.
+func (db *DB) FindPosition(fen string) ([]Occurrence, error) {
+	g, err := loadPosition(fen)
+	if err != nil {
+		return nil, err
+	}
+	return db.FindHash(g.ZobristHash), nil
+}
+
+// FindHash returns every time a game reached the position with the given
+// Zobrist hash, by game then ply
+func (db *DB) FindHash(hash uint64) []Occurrence {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	entries := db.index[hash]
+	found := make([]Occurrence, 0, len(entries))
+	for _, entry := range entries {
+		found = append(found, Occurrence{Game: entry.game, Ply: int(entry.ply)})
+	}
+	slices.SortFunc(found, func(a, b Occurrence) int {
+		if a.Game != b.Game {
+			return int(a.Game) - int(b.Game)
+		}
+		return a.Ply - b.Ply
+	})
+	return found
+}
+
+// MoveStats sums up the games that continued from a position with Move
+type MoveStats struct {
+	Move      chessongo.Move
+	Uci       string
+	San       string
+	Games     int
+	WhiteWins int
+	Draws     int
+	BlackWins int
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+// WhiteWinPercent returns the share of the games won by white, from 0 to 100
+func (s MoveStats) WhiteWinPercent() float64 {
+	return percent(s.WhiteWins, s.Games)
+}
+
+// DrawPercent returns the share of the games drawn, from 0 to 100
+func (s MoveStats) DrawPercent() float64 {
+	return percent(s.Draws, s.Games)
+}
+
+// BlackWinPercent returns the share of the games won by black, from 0 to 100
+func (s MoveStats) BlackWinPercent() float64 {
+	return percent(s.BlackWins, s.Games)
+}
+
+// NextMoves returns the moves played from the position of fen, most played
+// first. A game that played the same move from the position more than once
+// counts once; games without a decided result count in Games only.
+func (db *DB) NextMoves(fen string) ([]MoveStats, error) {
+	g, err := loadPosition(fen)
+	if err != nil {
+		return nil, err
+	}
+	type seen struct {
+		game GameID
+		move chessongo.Move
+	}
+	counted := map[seen]bool{}
+	byMove := map[chessongo.Move]*MoveStats{}
+
+	db.mu.RLock()
+	for _, entry := range db.index[g.ZobristHash] {
+		if entry.move == 0 || counted[seen{entry.game, entry.move}] {
+			continue
+		}
+		counted[seen{entry.game, entry.move}] = true
+		stats, ok := byMove[entry.move]
+		if !ok {
+			if !slices.Contains(g.LegalMoves, entry.move) {
+				// a hash collision
+				continue
+			}
+			stats = &MoveStats{Move: entry.move, Uci: entry.move.Uci(), San: g.GetMoveSan(entry.move)}
+			byMove[entry.move] = stats
+		}
+		stats.Games++
+		switch db.headers[entry.game].Tag("Result") {
+		case chessongo.RESULT_WHITE_WINS:
+			stats.WhiteWins++
+		case chessongo.RESULT_DRAW:
+			stats.Draws++
+		case chessongo.RESULT_BLACK_WINS:
+			stats.BlackWins++
+		}
+	}
+	db.mu.RUnlock()
+
+	moves := make([]MoveStats, 0, len(byMove))
+	for _, stats := range byMove {
+		moves = append(moves, *stats)
+	}
+	slices.SortFunc(moves, func(a, b MoveStats) int {
+		if a.Games != b.Games {
+			return b.Games - a.Games
+		}
+		return strings.Compare(a.Uci, b.Uci)
+	})
+	return moves, nil
+}
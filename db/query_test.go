@@ -0,0 +1,83 @@
+package db
+
+import (
+	"testing"
+
+	"chessongo"
+	"github.com/stretchr/testify/require"
+)
+
+func headerIDs(headers []Header) []GameID {
+	ids := []GameID{}
+	for _, h := range headers {
+		ids = append(ids, h.ID)
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	db, _ := importTestCollection(t)
+	for _, tc := range []struct {
+		query Query
+		want  []GameID
+	}{
+		{Query{}, []GameID{0, 1, 2, 3}},
+		{Query{Player: "fischer"}, []GameID{1, 2}},
+		{Query{White: "fischer"}, []GameID{}},
+		{Query{Black: "Fischer", Result: "0-1"}, []GameID{1}},
+		{Query{Event: "championship"}, []GameID{1}},
+		{Query{Site: "paris"}, []GameID{0}},
+		{Query{DateFrom: "1972"}, []GameID{1, 2}},
+		{Query{DateFrom: "1900", DateTo: "1972.07"}, []GameID{1, 2}},
+		{Query{DateTo: "1900.01.01"}, []GameID{0}},
+		{Query{DateFrom: "1972.08"}, []GameID{2}},
+		{Query{Result: chessongo.RESULT_DRAW}, []GameID{2}},
+	} {
+		require.Equal(t, tc.want, headerIDs(db.Search(tc.query)), "%+v", tc.query)
+	}
+}
+
+func TestFindPosition(t *testing.T) {
+	db, _ := importTestCollection(t)
+	// Reached by game 0 after 2. Nf3, and by game 2 twice, the knights
+	// having gone back and forth; the move counters do not matter
+	occurrences, err := db.FindPosition("rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 7 99")
+	require.NoError(t, err)
+	require.Equal(t, []Occurrence{{Game: 0, Ply: 3}, {Game: 2, Ply: 3}, {Game: 2, Ply: 7}}, occurrences)
+
+	occurrences, err = db.FindPosition("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3")
+	require.NoError(t, err)
+	require.Equal(t, []Occurrence{{Game: 2, Ply: 4}}, occurrences)
+
+	occurrences, err = db.FindPosition("8/8/8/8/8/8/8/K6k w - - 0 1")
+	require.NoError(t, err)
+	require.Empty(t, occurrences)
+
+	_, err = db.FindPosition("not a fen")
+	require.Error(t, err)
+}
+
+func TestNextMoves(t *testing.T) {
+	db, _ := importTestCollection(t)
+	stats, err := db.NextMoves(chessongo.STARTING_POSITION_FEN)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	require.Equal(t, MoveStats{Move: stats[0].Move, Uci: "e2e4", San: "e4", Games: 2, WhiteWins: 1, Draws: 1}, stats[0])
+	require.Equal(t, 50.0, stats[0].WhiteWinPercent())
+	require.Equal(t, 50.0, stats[0].DrawPercent())
+	require.Equal(t, 0.0, stats[0].BlackWinPercent())
+	require.Equal(t, "c4", stats[1].San)
+	require.Equal(t, 100.0, stats[1].BlackWinPercent())
+
+	// Game 2 reached the position a second time at its last move, where it
+	// has no next move
+	stats, err = db.NextMoves("rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2")
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	require.Equal(t, []string{"Nc6", "d6"}, []string{stats[0].San, stats[1].San})
+
+	// The last position of a game has no next move
+	stats, err = db.NextMoves("1n1Rk3/p4ppp/4q3/4p1B1/4P3/8/PPP2PPP/2K5 b - - 1 17")
+	require.NoError(t, err)
+	require.Empty(t, stats)
+}
@@ -0,0 +1,88 @@
+package chessongo
+
+// IsDeadPosition tells whether neither side can checkmate the opponent by
+// any sequence of legal moves (FIDE Article 5.2.2). The analysis is
+// conservative: it only reports positions it can prove dead.
+//
+// Recognised cases:
+//   - bare kings, or a single minor piece against a bare king
+//   - kings and bishops only, with every bishop on squares of one colour
+//   - kings and pawns only, where every pawn is blocked by another pawn, no
+//     pawn capture is available and no king can ever reach an opponent pawn
+//     it could take
+func (g *Game) IsDeadPosition() bool {
+	return g.hasDeadMaterial() || g.hasDeadPawnWall()
+}
+
+// Checks the material-only dead positions
+func (g *Game) hasDeadMaterial() bool {
+	heavy := g.Whites[PAWN] | g.Whites[ROOK] | g.Whites[QUEEN] | g.Blacks[PAWN] | g.Blacks[ROOK] | g.Blacks[QUEEN]
+	if heavy > 0 {
+		return false
+	}
+	knights := g.Whites[KNIGHT] | g.Blacks[KNIGHT]
+	bishops := g.Whites[BISHOP] | g.Blacks[BISHOP]
+	if knights > 0 {
+		// a lone knight cannot mate, anything more might
+		return bishops == 0 && knights.NumberOfSetBits() == 1
+	}
+	return bishops&LIGHT_SQUARES_MASK == 0 || bishops&DARK_SQUARES_MASK == 0
+}
+
+// Checks for a locked pawn structure that neither king can break through
+func (g *Game) hasDeadPawnWall() bool {
+	pawns := g.Whites[PAWN] | g.Blacks[PAWN]
+	if pawns == 0 {
+		return false
+	}
+	kings := g.Whites[KING] | g.Blacks[KING]
+	if g.Occupied != pawns|kings {
+		return false
+	}
+	if g.hasLegalEnPassant() {
+		return false
+	}
+	whiteAttacks := pawnAttacks(g.Whites[PAWN], WHITE)
+	blackAttacks := pawnAttacks(g.Blacks[PAWN], BLACK)
+	// every pawn must be stuck behind another pawn, and stay stuck while only
+	// the kings move
+	if (g.Whites[PAWN]>>8)&^pawns > 0 || (g.Blacks[PAWN]<<8)&^pawns > 0 {
+		return false
+	}
+	if whiteAttacks&g.Blacks[PAWN] > 0 || blackAttacks&g.Whites[PAWN] > 0 {
+		return false
+	}
+	if kingCanReach(g.Whites[KING], g.Whites[PAWN]|blackAttacks, g.Blacks[PAWN]) {
+		return false
+	}
+	if kingCanReach(g.Blacks[KING], g.Blacks[PAWN]|whiteAttacks, g.Whites[PAWN]) {
+		return false
+	}
+	return true
+}
+
+// Breadth-first search over king steps from king, never entering a square in
+// forbidden. Reports whether any square in targets can be reached. The search
+// ignores the opposing king, so it over-approximates what the king can reach;
+// it is bounded by the 64 squares of the board.
+func kingCanReach(king, forbidden, targets Bitboard) bool {
+	if king == 0 {
+		return false
+	}
+	visited := king
+	frontier := king
+	for frontier > 0 {
+		var next Bitboard
+		for frontier > 0 {
+			sq := frontier.popLSB()
+			next |= KING_ATTACKS_FROM[sq]
+		}
+		if next&targets&^forbidden > 0 {
+			return true
+		}
+		next &^= visited | forbidden | targets
+		visited |= next
+		frontier = next
+	}
+	return false
+}
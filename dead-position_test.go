@@ -0,0 +1,45 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsDeadPosition(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		expect bool
+	}{
+		{"start", STARTING_POSITION_FEN, false},
+		{"bareKings", "4k3/8/8/8/8/8/8/4K3 w - - 0 1", true},
+		{"loneKnight", "4k3/8/8/8/8/8/8/3NK3 w - - 0 1", true},
+		{"twoKnights", "4k3/8/8/8/8/8/8/2NNK3 w - - 0 1", false},
+		{"sameColoredBishops", "4kb2/8/8/8/8/4B3/8/2B1K3 w - - 0 1", true},
+		{"oppositeColoredBishops", "2b1k3/8/8/8/8/8/8/2B1K3 w - - 0 1", false},
+		{"lockedPawnWall", "4k3/8/8/p1p1p1p1/P1P1P1P1/8/8/4K3 w - - 0 1", true},
+		{"openFile", "4k3/8/8/p1p1p3/P1P1P3/8/8/4K3 w - - 0 1", false},
+		{"pawnCaptureAvailable", "4k3/8/8/1pp1p1p1/P1P1P1P1/8/8/4K3 w - - 0 1", false},
+		{"pawnWallWithRook", "4k3/8/8/p1p1p1p1/P1P1P1P1/8/8/R3K3 w - - 0 1", false},
+	}
+
+	for _, tt := range tests {
+		g := &Game{}
+		require.NoError(t, g.LoadFen(tt.fen))
+		require.Equalf(t, tt.expect, g.IsDeadPosition(), "IsDeadPosition %s", tt.name)
+	}
+}
+
+func TestDeadPositionFinishesGame(t *testing.T) {
+	g := &Game{}
+	require.NoError(t, g.LoadFen("4kb2/8/8/8/8/4B3/8/2B1K3 w - - 0 1"))
+	playCoords(g, "e1", "d2")
+
+	require.False(t, g.IsMaterialDraw)
+	require.True(t, g.IsDeadDraw)
+	require.True(t, g.IsFinished)
+	result, termination := g.Result()
+	require.Equal(t, RESULT_DRAW, result)
+	require.Equal(t, TERMINATION_DEAD_POSITION, termination)
+}
@@ -0,0 +1,179 @@
+package chessongo
+
+// EventKind tells what happened to a game
+type EventKind uint8
+
+const (
+	EVENT_MOVE_PLAYED EventKind = iota + 1
+	EVENT_MOVE_UNDONE
+	// The side to move is in check, checkmate included
+	EVENT_CHECK
+	EVENT_GAME_OVER
+	// The number of times the current position occurred changed
+	EVENT_REPETITION_CHANGED
+	EVENT_POSITION_LOADED
+	// The queued premoves were dropped because the first one was illegal
+	// when its turn came
+	EVENT_PREMOVES_DISCARDED
+)
+
+var EVENT_KIND_TO_STRING = map[EventKind]string{
+	EVENT_MOVE_PLAYED:        "move played",
+	EVENT_MOVE_UNDONE:        "move undone",
+	EVENT_CHECK:              "check",
+	EVENT_GAME_OVER:          "game over",
+	EVENT_REPETITION_CHANGED: "repetition changed",
+	EVENT_POSITION_LOADED:    "position loaded",
+	EVENT_PREMOVES_DISCARDED: "premoves discarded",
+}
+
+func (k EventKind) String() string {
+	return EVENT_KIND_TO_STRING[k]
+}
+
+// MoveFlags describe a played or undone move
+type MoveFlags uint8
+
+const (
+	MOVE_FLAG_CAPTURE MoveFlags = 1 << iota
+	MOVE_FLAG_EN_PASSANT
+	MOVE_FLAG_CASTLING
+	MOVE_FLAG_PROMOTION
+	MOVE_FLAG_CHECK
+	MOVE_FLAG_CHECKMATE
+	// The move was queued as a premove and played as soon as its turn came
+	MOVE_FLAG_PREMOVE
+)
+
+// PositionSource tells where a loaded position came from
+type PositionSource uint8
+
+const (
+	SOURCE_FEN PositionSource = iota + 1
+	SOURCE_PGN
+	SOURCE_BINARY
+	SOURCE_BUILDER
+	SOURCE_JSON
+)
+
+// GameEvent describes one change of a game. Only the fields relevant to the
+// kind of event are set.
+type GameEvent struct {
+	Kind EventKind
+	// Move played or undone, with its SAN, the piece it captured and its
+	// flags. For EVENT_PREMOVES_DISCARDED, the premove found illegal.
+	Move     Move
+	San      string
+	Captured Piece
+	Flags    MoveFlags
+	// Set for EVENT_GAME_OVER
+	Result      string
+	Termination Termination
+	// Set for EVENT_REPETITION_CHANGED
+	RepetitionCount int
+	// Set for EVENT_POSITION_LOADED
+	Source PositionSource
+}
+
+// Observer is notified of the changes of the games it is added to. It is
+// called synchronously, after the change is complete; it may read the game
+// but must not modify it.
+type Observer interface {
+	OnGameEvent(g *Game, e GameEvent)
+}
+
+// ObserverFunc adapts a function to the Observer interface
+type ObserverFunc func(g *Game, e GameEvent)
+
+func (f ObserverFunc) OnGameEvent(g *Game, e GameEvent) {
+	f(g, e)
+}
+
+type observerEntry struct {
+	id       int
+	observer Observer
+}
+
+// AddObserver registers o and returns an id for RemoveObserver. Observers
+// are kept when a new position is loaded into the game but not copied by
+// CloneGame.
+func (g *Game) AddObserver(o Observer) int {
+	g.nextObserverID++
+	g.observers = append(g.observers, observerEntry{g.nextObserverID, o})
+	return g.nextObserverID
+}
+
+// RemoveObserver unregisters the observer with the given id. It reports
+// whether it was registered.
+func (g *Game) RemoveObserver(id int) bool {
+	for i, entry := range g.observers {
+		if entry.id == id {
+			g.observers = append(g.observers[:i:i], g.observers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Game) hasObservers() bool {
+	return len(g.observers) > 0
+}
+
+func (g *Game) emit(e GameEvent) {
+	for _, entry := range g.observers {
+		entry.observer.OnGameEvent(g, e)
+	}
+}
+
+// What the game looked like before a move was played or undone, to tell
+// which events the change caused
+type eventState struct {
+	repetitions int
+	finished    bool
+}
+
+func (g *Game) eventStateBefore() eventState {
+	return eventState{repetitions: g.RepetitionCount(), finished: g.IsFinished}
+}
+
+// Emits the events of a move played or undone, in order: the move itself,
+// check (for played moves only), repetition count change and game over.
+// flags are reported along with the ones of the move itself.
+func (g *Game) emitMoveEvents(kind EventKind, m Move, san string, captured Piece, flags MoveFlags, before eventState) {
+	e := GameEvent{Kind: kind, Move: m, San: san, Captured: captured, Flags: flags}
+	if captured != EMPTY {
+		e.Flags |= MOVE_FLAG_CAPTURE
+	}
+	if m.IsEnPassant() {
+		e.Flags |= MOVE_FLAG_EN_PASSANT
+	}
+	if m.IsCastlingMove() {
+		e.Flags |= MOVE_FLAG_CASTLING
+	}
+	if m.IsPromotionMove() {
+		e.Flags |= MOVE_FLAG_PROMOTION
+	}
+	check := kind == EVENT_MOVE_PLAYED && g.IsCheck
+	if check {
+		e.Flags |= MOVE_FLAG_CHECK
+		if g.IsCheckmate {
+			e.Flags |= MOVE_FLAG_CHECKMATE
+		}
+	}
+	g.emit(e)
+	if check {
+		g.emit(GameEvent{Kind: EVENT_CHECK})
+	}
+	if count := g.RepetitionCount(); count != before.repetitions {
+		g.emit(GameEvent{Kind: EVENT_REPETITION_CHANGED, RepetitionCount: count})
+	}
+	if g.IsFinished && !before.finished {
+		result, termination := g.Result()
+		g.emit(GameEvent{Kind: EVENT_GAME_OVER, Result: result, Termination: termination})
+	}
+}
+
+// Emits EVENT_POSITION_LOADED
+func (g *Game) emitLoaded(source PositionSource) {
+	g.emit(GameEvent{Kind: EVENT_POSITION_LOADED, Source: source})
+}
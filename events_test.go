@@ -0,0 +1,115 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type eventRecorder struct {
+	events []GameEvent
+}
+
+func (r *eventRecorder) OnGameEvent(g *Game, e GameEvent) {
+	r.events = append(r.events, e)
+}
+
+func (r *eventRecorder) kinds() []EventKind {
+	kinds := make([]EventKind, len(r.events))
+	for i, e := range r.events {
+		kinds[i] = e.Kind
+	}
+	return kinds
+}
+
+func (r *eventRecorder) reset() {
+	r.events = nil
+}
+
+func TestMoveEvents(t *testing.T) {
+	g := NewGame()
+	g.GenerateLegalMoves()
+	r := &eventRecorder{}
+	g.AddObserver(r)
+
+	playCoords(g, "f2", "f3")
+	require.Equal(t, []GameEvent{{Kind: EVENT_MOVE_PLAYED, Move: coordsMove("f2", "f3"), San: "f3"}}, r.events)
+	playCoords(g, "e7", "e5")
+	playCoords(g, "g2", "g4")
+	r.reset()
+
+	playCoords(g, "d8", "h4")
+	require.Equal(t, []EventKind{EVENT_MOVE_PLAYED, EVENT_CHECK, EVENT_GAME_OVER}, r.kinds())
+	require.Equal(t, "Qh4#", r.events[0].San)
+	require.Equal(t, MOVE_FLAG_CHECK|MOVE_FLAG_CHECKMATE, r.events[0].Flags)
+	require.Equal(t, RESULT_BLACK_WINS, r.events[2].Result)
+	require.Equal(t, TERMINATION_CHECKMATE, r.events[2].Termination)
+	r.reset()
+
+	g.UndoMove(coordsMove("d8", "h4"))
+	require.Equal(t, []EventKind{EVENT_MOVE_UNDONE}, r.kinds())
+	require.Equal(t, "Qh4#", r.events[0].San)
+	require.Zero(t, r.events[0].Flags)
+}
+
+func TestCaptureAndRepetitionEvents(t *testing.T) {
+	g := &Game{}
+	require.NoError(t, g.LoadFen("rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 2"))
+	g.GenerateLegalMoves()
+	r := &eventRecorder{}
+	g.AddObserver(r)
+
+	playCoords(g, "f7", "f5")
+	g.MakeMove(findMove(t, g, "e5", "f6"))
+	require.Len(t, r.events, 2)
+	require.Equal(t, MOVE_FLAG_CAPTURE|MOVE_FLAG_EN_PASSANT, r.events[1].Flags)
+	require.Equal(t, Piece(B_PAWN), r.events[1].Captured)
+	require.Equal(t, "exf6", r.events[1].San)
+	r.reset()
+
+	for _, m := range [][2]string{{"g8", "h6"}, {"g1", "f3"}, {"h6", "g8"}, {"f3", "g1"}} {
+		playCoords(g, m[0], m[1])
+	}
+	require.Equal(t, []EventKind{EVENT_MOVE_PLAYED, EVENT_MOVE_PLAYED, EVENT_MOVE_PLAYED, EVENT_MOVE_PLAYED, EVENT_REPETITION_CHANGED}, r.kinds())
+	require.Equal(t, 2, r.events[4].RepetitionCount)
+	r.reset()
+
+	g.UndoMove(coordsMove("f3", "g1"))
+	require.Equal(t, []EventKind{EVENT_MOVE_UNDONE, EVENT_REPETITION_CHANGED}, r.kinds())
+	require.Equal(t, 1, r.events[1].RepetitionCount)
+}
+
+func TestPositionLoadedEvents(t *testing.T) {
+	g := &Game{}
+	r := &eventRecorder{}
+	var calls int
+	id := g.AddObserver(r)
+	g.AddObserver(ObserverFunc(func(*Game, GameEvent) { calls++ }))
+
+	require.NoError(t, g.LoadFen(STARTING_POSITION_FEN))
+	require.NoError(t, g.LoadPGN("1. e4 e5 2. Nf3 Nc6"))
+	data, err := g.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, g.UnmarshalBinary(data))
+	require.NoError(t, g.Builder().CommitTo(g))
+	require.Error(t, g.LoadPGN("1. e4 e5 2. Ke3"))
+
+	require.Equal(t, []GameEvent{
+		{Kind: EVENT_POSITION_LOADED, Source: SOURCE_FEN},
+		{Kind: EVENT_POSITION_LOADED, Source: SOURCE_PGN},
+		{Kind: EVENT_POSITION_LOADED, Source: SOURCE_BINARY},
+		{Kind: EVENT_POSITION_LOADED, Source: SOURCE_BUILDER},
+	}, r.events)
+	require.Equal(t, 4, calls)
+
+	require.True(t, g.RemoveObserver(id))
+	require.False(t, g.RemoveObserver(id))
+	g.GenerateLegalMoves()
+	playCoords(g, "f1", "c4")
+	require.Len(t, r.events, 4)
+	require.Equal(t, 5, calls)
+
+	clone := CloneGame(g)
+	clone.MakeMove(clone.LegalMoves[0])
+	require.Equal(t, 5, calls)
+}
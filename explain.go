@@ -0,0 +1,185 @@
+package chessongo
+
+// Reason tells why a move is illegal
+type Reason uint8
+
+const (
+	// The move is legal
+	REASON_LEGAL Reason = iota
+	REASON_GAME_FINISHED
+	REASON_NO_PIECE
+	REASON_OPPONENT_PIECE
+	// The target square holds a piece of the side to move
+	REASON_OWN_PIECE
+	// The piece does not move that way
+	REASON_INVALID_MOVEMENT
+	REASON_PATH_BLOCKED
+	// The piece is pinned to its king and may not leave the line of the pin
+	REASON_PINNED
+	// The move would leave or put the king in check
+	REASON_KING_IN_CHECK
+	// The king is in check and the move does not get it out
+	REASON_MUST_ESCAPE_CHECK
+	// The king or the rook has moved, or the rook was taken
+	REASON_CASTLING_RIGHTS_LOST
+	REASON_CASTLING_OUT_OF_CHECK
+	REASON_CASTLING_THROUGH_CHECK
+	// The move is a promotion and needs the piece to promote to
+	REASON_PROMOTION_PIECE_MISSING
+)
+
+var REASON_TO_STRING = map[Reason]string{
+	REASON_LEGAL:                   "legal",
+	REASON_GAME_FINISHED:           "the game is over",
+	REASON_NO_PIECE:                "there is no piece on that square",
+	REASON_OPPONENT_PIECE:          "that piece belongs to the opponent",
+	REASON_OWN_PIECE:               "a piece of your own is on the target square",
+	REASON_INVALID_MOVEMENT:        "the piece cannot move that way",
+	REASON_PATH_BLOCKED:            "the path is blocked",
+	REASON_PINNED:                  "the piece is pinned to the king",
+	REASON_KING_IN_CHECK:           "the king would be in check",
+	REASON_MUST_ESCAPE_CHECK:       "the king must get out of check",
+	REASON_CASTLING_RIGHTS_LOST:    "castling rights are lost",
+	REASON_CASTLING_OUT_OF_CHECK:   "cannot castle out of check",
+	REASON_CASTLING_THROUGH_CHECK:  "cannot castle through check",
+	REASON_PROMOTION_PIECE_MISSING: "choose a piece to promote to",
+}
+
+func (r Reason) String() string {
+	return REASON_TO_STRING[r]
+}
+
+// ExplainIllegal tells why moving the piece on from to to is illegal in the
+// current position, REASON_LEGAL if it is legal. A pawn move to the last rank
+// is reported as missing its promotion piece, since from and to alone do not
+// make the move. The game itself is left untouched.
+func (g *Game) ExplainIllegal(from, to Square) Reason {
+	g = g.withStatus()
+	if g.IsFinished {
+		return REASON_GAME_FINISHED
+	}
+	piece := g.Squares[from]
+	if piece == EMPTY {
+		return REASON_NO_PIECE
+	}
+	if piece.Color() != g.Turn {
+		return REASON_OPPONENT_PIECE
+	}
+	for _, m := range g.LegalMoves {
+		if m.From() == from && m.To() == to {
+			if m.IsPromotionMove() {
+				return REASON_PROMOTION_PIECE_MISSING
+			}
+			return REASON_LEGAL
+		}
+	}
+	if piece.Kind() == KING && from.Rank() == to.Rank() && abs(from.File()-to.File()) == 2 {
+		return g.explainCastling(from, to)
+	}
+	if g.Squares[to] != EMPTY && g.Squares[to].Color() == g.Turn {
+		return REASON_OWN_PIECE
+	}
+	if reason := g.explainMovement(piece, from, to); reason != REASON_LEGAL {
+		return reason
+	}
+
+	// The move is pseudo-legal: it exposes the king
+	if piece.Kind() == KING {
+		return REASON_KING_IN_CHECK
+	}
+	for _, pin := range g.Pins(g.Turn) {
+		if pin.Absolute && pin.Pinned == from && pin.Ray&(Bitboard(1)<<to) == 0 {
+			return REASON_PINNED
+		}
+	}
+	if g.IsCheck {
+		return REASON_MUST_ESCAPE_CHECK
+	}
+	// e.g. an en passant capture taking both pawns off the king's rank
+	return REASON_KING_IN_CHECK
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Explains why the king on from cannot castle to to
+func (g *Game) explainCastling(from, to Square) Reason {
+	var right int
+	var rook Square
+	switch to {
+	case WKS_KING_TO_SQUARE:
+		right, rook = CASTLE_WKS, WKS_ROOK_ORIGINAL_SQUARE
+	case WQS_KING_TO_SQUARE:
+		right, rook = CASTLE_WQS, WQS_ROOK_ORIGINAL_SQUARE
+	case BKS_KING_TO_SQUARE:
+		right, rook = CASTLE_BKS, BKS_ROOK_ORIGINAL_SQUARE
+	case BQS_KING_TO_SQUARE:
+		right, rook = CASTLE_BQS, BQS_ROOK_ORIGINAL_SQUARE
+	default:
+		return REASON_INVALID_MOVEMENT
+	}
+	kingSquare := Square(W_KING_INIT_SQUARE)
+	if g.Turn == BLACK {
+		kingSquare = B_KING_INIT_SQUARE
+	}
+	if castlingColor(right) != g.Turn || from != kingSquare {
+		return REASON_INVALID_MOVEMENT
+	}
+	if g.Castling&right == 0 {
+		return REASON_CASTLING_RIGHTS_LOST
+	}
+	if squaresBetween(from, rook)&g.Occupied > 0 {
+		return REASON_PATH_BLOCKED
+	}
+	enemy := opponentColor(g.Turn)
+	if g.IsCheck {
+		return REASON_CASTLING_OUT_OF_CHECK
+	}
+	if g.IsAttacked(Square((int(from)+int(to))/2), enemy) {
+		return REASON_CASTLING_THROUGH_CHECK
+	}
+	return REASON_KING_IN_CHECK
+}
+
+// Explains why piece cannot go from from to to regardless of checks,
+// REASON_LEGAL if it can
+func (g *Game) explainMovement(piece Piece, from, to Square) Reason {
+	toBB := Bitboard(1) << to
+	if piece.Kind() != PAWN {
+		// on an empty board, so that only the shape of the move counts
+		if pieceAttacks(piece, from, 0)&toBB == 0 {
+			return REASON_INVALID_MOVEMENT
+		}
+		if squaresBetween(from, to)&g.Occupied > 0 {
+			return REASON_PATH_BLOCKED
+		}
+		return REASON_LEGAL
+	}
+
+	forward, startRank := -8, 6
+	if piece.IsBlack() {
+		forward, startRank = 8, 1
+	}
+	switch {
+	case int(to) == int(from)+forward:
+		if g.Squares[to] != EMPTY {
+			return REASON_PATH_BLOCKED
+		}
+	case int(to) == int(from)+2*forward && from.Rank() == startRank:
+		if g.Squares[int(from)+forward] != EMPTY || g.Squares[to] != EMPTY {
+			return REASON_PATH_BLOCKED
+		}
+	case pawnAttacks(Bitboard(1)<<from, piece.Color())&toBB > 0:
+		// pawns move diagonally only to capture
+		if g.Squares[to] == EMPTY && (g.EnPassant == 0 || to != g.EnPassant) {
+			return REASON_INVALID_MOVEMENT
+		}
+	default:
+		return REASON_INVALID_MOVEMENT
+	}
+	return REASON_LEGAL
+}
@@ -0,0 +1,92 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainIllegal(t *testing.T) {
+	for _, c := range []struct {
+		fen      string
+		from, to string
+		reason   Reason
+	}{
+		{STARTING_POSITION_FEN, "e2", "e4", REASON_LEGAL},
+		{STARTING_POSITION_FEN, "e4", "e5", REASON_NO_PIECE},
+		{STARTING_POSITION_FEN, "e7", "e5", REASON_OPPONENT_PIECE},
+		{STARTING_POSITION_FEN, "d1", "d2", REASON_OWN_PIECE},
+		{STARTING_POSITION_FEN, "g1", "g3", REASON_INVALID_MOVEMENT},
+		{STARTING_POSITION_FEN, "e2", "e5", REASON_INVALID_MOVEMENT},
+		{STARTING_POSITION_FEN, "e2", "d3", REASON_INVALID_MOVEMENT},
+		{STARTING_POSITION_FEN, "f1", "c4", REASON_PATH_BLOCKED},
+		{STARTING_POSITION_FEN, "a1", "a3", REASON_PATH_BLOCKED},
+		{"4k3/8/8/8/8/4p3/4P3/4K3 w - - 0 1", "e2", "e3", REASON_PATH_BLOCKED},
+		{"4k3/8/8/8/8/4p3/4P3/4K3 w - - 0 1", "e2", "e4", REASON_PATH_BLOCKED},
+		// pins
+		{"4k3/4r3/8/8/8/8/4N3/4K3 w - - 0 1", "e2", "c3", REASON_PINNED},
+		{"4k3/4r3/8/8/8/8/4R3/4K3 w - - 0 1", "e2", "e7", REASON_LEGAL},
+		{"4k3/4r3/8/8/8/8/4R3/4K3 w - - 0 1", "e2", "d2", REASON_PINNED},
+		{"4k3/p7/8/8/b7/8/2B5/3K4 w - - 0 1", "c2", "a4", REASON_LEGAL},
+		{"4k3/p7/8/8/b7/8/2B5/3K4 w - - 0 1", "c2", "d3", REASON_PINNED},
+		// checks
+		{"4k3/4r3/8/8/8/8/8/3K4 w - - 0 1", "d1", "e1", REASON_KING_IN_CHECK},
+		{"4k3/4r3/8/8/8/8/3N4/4K3 w - - 0 1", "d2", "f3", REASON_MUST_ESCAPE_CHECK},
+		{"4k3/4r3/8/8/8/8/3N4/4K3 w - - 0 1", "d2", "e4", REASON_LEGAL},
+		// the en passant capture takes both pawns off the rank of the king
+		{"4k3/8/8/K2pP2r/8/8/8/8 w - d6 0 2", "e5", "d6", REASON_KING_IN_CHECK},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2", "e5", "d6", REASON_LEGAL},
+		// castling
+		{"r3k2r/8/8/8/8/8/8/R3K2R w Qk - 0 1", "e1", "g1", REASON_CASTLING_RIGHTS_LOST},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w Qk - 0 1", "e1", "c1", REASON_LEGAL},
+		{"r3k2r/8/8/8/8/8/8/RN2K2R w KQkq - 0 1", "e1", "c1", REASON_PATH_BLOCKED},
+		{"r3k2r/8/8/8/8/8/4r3/R3K2R w KQkq - 0 1", "e1", "g1", REASON_CASTLING_OUT_OF_CHECK},
+		{"r3kr2/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1", "g1", REASON_CASTLING_THROUGH_CHECK},
+		{"r3k1r1/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1", "g1", REASON_KING_IN_CHECK},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8", "c8", REASON_LEGAL},
+		{"4k3/8/8/8/8/8/8/R3K3 w - - 0 1", "a1", "c1", REASON_LEGAL},
+		{"4k3/p7/8/8/8/8/8/3K4 w - - 0 1", "d1", "f1", REASON_INVALID_MOVEMENT},
+		// promotion
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7", "b8", REASON_PROMOTION_PIECE_MISSING},
+		// finished
+		{"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", "e1", "f2", REASON_GAME_FINISHED},
+	} {
+		g := &Game{}
+		require.NoError(t, g.LoadFen(c.fen))
+		reason := g.ExplainIllegal(COORDS_TO_SQUARE[c.from], COORDS_TO_SQUARE[c.to])
+		require.Equal(t, c.reason, reason, "%s %s%s: %s", c.fen, c.from, c.to, reason)
+	}
+
+	// the status is computed on a copy, whatever the game has cached
+	g := NewGame()
+	require.Equal(t, REASON_LEGAL, g.ExplainIllegal(COORDS_TO_SQUARE["e2"], COORDS_TO_SQUARE["e4"]))
+	require.Empty(t, g.LegalMoves)
+}
+
+func TestExplainIllegalAgreesWithLegalMoves(t *testing.T) {
+	for _, fen := range []string{
+		STARTING_POSITION_FEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	} {
+		g := &Game{}
+		require.NoError(t, g.LoadFen(fen))
+		g.GenerateLegalMoves()
+		legal := map[[2]Square]bool{}
+		for _, m := range g.LegalMoves {
+			legal[[2]Square{m.From(), m.To()}] = true
+		}
+		for from := Square(0); from < 64; from++ {
+			for to := Square(0); to < 64; to++ {
+				reason := g.ExplainIllegal(from, to)
+				explainedLegal := reason == REASON_LEGAL || reason == REASON_PROMOTION_PIECE_MISSING
+				require.Equal(t, legal[[2]Square{from, to}], explainedLegal, "%s %s%s: %s", fen, from.Coords(), to.Coords(), reason)
+			}
+		}
+	}
+}
+
+func TestReasonString(t *testing.T) {
+	require.Equal(t, "the piece is pinned to the king", REASON_PINNED.String())
+}
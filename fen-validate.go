@@ -0,0 +1,359 @@
+package chessongo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// How thoroughly ValidateFen checks a FEN
+type FenStrictness int
+
+const (
+	// Only check that the FEN is well formed
+	FEN_PARSE_ONLY FenStrictness = iota
+	// Also check that the position can be played: one king per side, no pawns
+	// on the back ranks, castling rights and en-passant square that match the
+	// board, and the side not to move is not in check
+	FEN_LEGAL_FOR_PLAY
+	// Also check that the position looks reachable from the initial position:
+	// piece counts, promotions and clocks are plausible
+	FEN_REACHABLE
+)
+
+// FEN fields, in order
+const (
+	FEN_FIELD_PLACEMENT = iota
+	FEN_FIELD_TURN
+	FEN_FIELD_CASTLING
+	FEN_FIELD_EN_PASSANT
+	FEN_FIELD_HALF_MOVES
+	FEN_FIELD_FULL_MOVES
+	FEN_FIELD_COUNT
+)
+
+// FEN problem codes
+const (
+	E_FEN_FIELD_COUNT         = "e:fen:field-count"
+	E_FEN_PIECE_CHAR          = "e:fen:piece-char"
+	E_FEN_RANK_LENGTH         = "e:fen:rank-length"
+	E_FEN_RANK_COUNT          = "e:fen:rank-count"
+	E_FEN_TURN                = "e:fen:turn"
+	E_FEN_CASTLING            = "e:fen:castling"
+	E_FEN_EN_PASSANT          = "e:fen:en-passant"
+	E_FEN_HALF_MOVES          = "e:fen:half-moves"
+	E_FEN_FULL_MOVES          = "e:fen:full-moves"
+	E_FEN_MISSING_KING        = "e:fen:missing-king"
+	E_FEN_TOO_MANY_KINGS      = "e:fen:too-many-kings"
+	E_FEN_PAWN_ON_BACK_RANK   = "e:fen:pawn-on-back-rank"
+	E_FEN_CASTLING_RIGHTS     = "e:fen:castling-rights"
+	E_FEN_IMPOSSIBLE_EP       = "e:fen:impossible-en-passant"
+	E_FEN_OPPONENT_IN_CHECK   = "e:fen:opponent-in-check"
+	E_FEN_TOO_MANY_PIECES     = "e:fen:too-many-pieces"
+	E_FEN_TOO_MANY_PAWNS      = "e:fen:too-many-pawns"
+	E_FEN_TOO_MANY_PROMOTIONS = "e:fen:too-many-promotions"
+	E_FEN_CLOCKS              = "e:fen:clocks"
+)
+
+// FenProblem describes a single thing wrong with a FEN
+type FenProblem struct {
+	// One of the E_FEN_* codes
+	Code string
+	// Index of the offending FEN field, see FEN_FIELD_*. Missing fields are
+	// reported at the index of the first missing one.
+	Field int
+	// Character offset into the FEN string
+	Offset  int
+	Message string
+}
+
+func (p FenProblem) Error() string {
+	return fmt.Sprintf("%s: %s (field %d, offset %d)", p.Code, p.Message, p.Field, p.Offset)
+}
+
+type fenValidator struct {
+	fen      string
+	problems []FenProblem
+}
+
+func (v *fenValidator) report(code string, field, offset int, format string, args ...interface{}) {
+	v.problems = append(v.problems, FenProblem{
+		Code:    code,
+		Field:   field,
+		Offset:  offset,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// ValidateFen checks fen and returns every problem found, or nil when the
+// FEN passes all checks of the given strictness.
+func ValidateFen(fen string, strictness FenStrictness) []FenProblem {
+	v := &fenValidator{fen: fen}
+
+	start, end := 0, len(fen)
+	for start < end && fen[start] == ' ' {
+		start++
+	}
+	for end > start && fen[end-1] == ' ' {
+		end--
+	}
+	var fields []string
+	var offsets []int
+	for i := start; i <= end; {
+		j := strings.IndexByte(fen[i:end], ' ')
+		if j == -1 {
+			j = end - i
+		}
+		fields = append(fields, fen[i:i+j])
+		offsets = append(offsets, i)
+		i += j + 1
+	}
+	if len(fields) < FEN_FIELD_COUNT {
+		v.report(E_FEN_FIELD_COUNT, len(fields), end, "expected %d fields, got %d", FEN_FIELD_COUNT, len(fields))
+	} else if len(fields) > FEN_FIELD_COUNT {
+		v.report(E_FEN_FIELD_COUNT, FEN_FIELD_COUNT, offsets[FEN_FIELD_COUNT], "expected %d fields, got %d", FEN_FIELD_COUNT, len(fields))
+	}
+
+	ok := len(fields) == FEN_FIELD_COUNT
+	var squareOffsets [64]int
+	if len(fields) > FEN_FIELD_PLACEMENT {
+		ok = v.checkPlacement(fields[FEN_FIELD_PLACEMENT], offsets[FEN_FIELD_PLACEMENT], &squareOffsets) && ok
+	}
+	if len(fields) > FEN_FIELD_TURN && fields[FEN_FIELD_TURN] != "w" && fields[FEN_FIELD_TURN] != "b" {
+		v.report(E_FEN_TURN, FEN_FIELD_TURN, offsets[FEN_FIELD_TURN], "side to move must be 'w' or 'b', got %q", fields[FEN_FIELD_TURN])
+		ok = false
+	}
+	if len(fields) > FEN_FIELD_CASTLING {
+		ok = v.checkCastling(fields[FEN_FIELD_CASTLING], offsets[FEN_FIELD_CASTLING]) && ok
+	}
+	if len(fields) > FEN_FIELD_EN_PASSANT {
+		ep := fields[FEN_FIELD_EN_PASSANT]
+		if _, found := COORDS_TO_SQUARE[ep]; ep != "-" && !found {
+			v.report(E_FEN_EN_PASSANT, FEN_FIELD_EN_PASSANT, offsets[FEN_FIELD_EN_PASSANT], "en-passant square must be '-' or a square, got %q", ep)
+			ok = false
+		}
+	}
+	if len(fields) > FEN_FIELD_HALF_MOVES && !isFenNumber(fields[FEN_FIELD_HALF_MOVES]) {
+		v.report(E_FEN_HALF_MOVES, FEN_FIELD_HALF_MOVES, offsets[FEN_FIELD_HALF_MOVES], "half-move clock must be a number, got %q", fields[FEN_FIELD_HALF_MOVES])
+		ok = false
+	}
+	if len(fields) > FEN_FIELD_FULL_MOVES && !isFenNumber(fields[FEN_FIELD_FULL_MOVES]) {
+		v.report(E_FEN_FULL_MOVES, FEN_FIELD_FULL_MOVES, offsets[FEN_FIELD_FULL_MOVES], "full-move number must be a number, got %q", fields[FEN_FIELD_FULL_MOVES])
+		ok = false
+	}
+
+	if !ok || strictness == FEN_PARSE_ONLY {
+		return v.problems
+	}
+	g := &Game{}
+	if err := g.LoadFen(strings.Join(fields, " ")); err != nil {
+		v.report(E_INVALID_FEN, FEN_FIELD_PLACEMENT, start, "%s", err.Error())
+		return v.problems
+	}
+	v.checkPlayable(g, offsets, squareOffsets)
+	if strictness >= FEN_REACHABLE {
+		v.checkReachable(g, offsets)
+	}
+	return v.problems
+}
+
+// Validate checks the current position, see ValidateFen. Offsets refer to
+// the FEN returned by ToFen.
+func (g *Game) Validate(strictness FenStrictness) []FenProblem {
+	return ValidateFen(g.ToFen(), strictness)
+}
+
+func isFenNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 10, 31)
+	return err == nil
+}
+
+// Checks the piece placement field, recording the offset of every square
+func (v *fenValidator) checkPlacement(placement string, offset int, squareOffsets *[64]int) bool {
+	ok := true
+	ranks := strings.Split(placement, "/")
+	if len(ranks) != 8 {
+		v.report(E_FEN_RANK_COUNT, FEN_FIELD_PLACEMENT, offset, "expected 8 ranks, got %d", len(ranks))
+		ok = false
+	}
+	rankOffset := offset
+	for r, rank := range ranks {
+		files := 0
+		for i := 0; i < len(rank); i++ {
+			c := rank[i]
+			if c >= '1' && c <= '8' {
+				files += int(c - '0')
+				continue
+			}
+			if _, found := RUNE_TO_PIECE[rune(c)]; !found {
+				v.report(E_FEN_PIECE_CHAR, FEN_FIELD_PLACEMENT, rankOffset+i, "unexpected character %q in piece placement", c)
+				ok = false
+			} else if r < 8 && files < 8 {
+				squareOffsets[r*8+files] = rankOffset + i
+			}
+			files++
+		}
+		if files != 8 {
+			v.report(E_FEN_RANK_LENGTH, FEN_FIELD_PLACEMENT, rankOffset, "rank %d describes %d squares instead of 8", 8-r, files)
+			ok = false
+		}
+		rankOffset += len(rank) + 1
+	}
+	return ok
+}
+
+// Checks the syntax of the castling field
+func (v *fenValidator) checkCastling(castling string, offset int) bool {
+	if castling == "-" {
+		return true
+	}
+	if castling == "" {
+		v.report(E_FEN_CASTLING, FEN_FIELD_CASTLING, offset, "castling field is empty, use '-'")
+		return false
+	}
+	ok := true
+	seen := map[byte]bool{}
+	for i := 0; i < len(castling); i++ {
+		c := castling[i]
+		if !strings.ContainsRune("KQkq", rune(c)) || seen[c] {
+			v.report(E_FEN_CASTLING, FEN_FIELD_CASTLING, offset+i, "unexpected castling character %q", c)
+			ok = false
+		}
+		seen[c] = true
+	}
+	return ok
+}
+
+// Checks everything needed for the position to be playable
+func (v *fenValidator) checkPlayable(g *Game, offsets []int, squareOffsets [64]int) {
+	placementOffset := offsets[FEN_FIELD_PLACEMENT]
+	kingsOk := true
+	for _, side := range []struct {
+		name  string
+		kings Bitboard
+	}{{"white", g.Whites[KING]}, {"black", g.Blacks[KING]}} {
+		switch n := side.kings.NumberOfSetBits(); {
+		case n == 0:
+			v.report(E_FEN_MISSING_KING, FEN_FIELD_PLACEMENT, placementOffset, "%s has no king", side.name)
+			kingsOk = false
+		case n > 1:
+			kings := side.kings
+			kings.popLSB()
+			sq := kings.popLSB()
+			v.report(E_FEN_TOO_MANY_KINGS, FEN_FIELD_PLACEMENT, squareOffsets[sq], "%s has %d kings", side.name, n)
+			kingsOk = false
+		}
+	}
+
+	backRankPawns := (g.Whites[PAWN] | g.Blacks[PAWN]) & (RANK1_MASK | RANK8_MASK)
+	for backRankPawns > 0 {
+		sq := Square(backRankPawns.popLSB())
+		v.report(E_FEN_PAWN_ON_BACK_RANK, FEN_FIELD_PLACEMENT, squareOffsets[sq], "pawn on %s", sq.Coords())
+	}
+
+	castling := []struct {
+		right      int
+		char       byte
+		king, rook Piece
+		kingSq     Square
+		rookSq     Square
+	}{
+		{CASTLE_WKS, 'K', W_KING, W_ROOK, W_KING_INIT_SQUARE, WKS_ROOK_ORIGINAL_SQUARE},
+		{CASTLE_WQS, 'Q', W_KING, W_ROOK, W_KING_INIT_SQUARE, WQS_ROOK_ORIGINAL_SQUARE},
+		{CASTLE_BKS, 'k', B_KING, B_ROOK, B_KING_INIT_SQUARE, BKS_ROOK_ORIGINAL_SQUARE},
+		{CASTLE_BQS, 'q', B_KING, B_ROOK, B_KING_INIT_SQUARE, BQS_ROOK_ORIGINAL_SQUARE},
+	}
+	castlingField := v.fieldAt(offsets, FEN_FIELD_CASTLING)
+	for _, c := range castling {
+		if g.Castling&c.right == 0 {
+			continue
+		}
+		offset := offsets[FEN_FIELD_CASTLING] + strings.IndexByte(castlingField, c.char)
+		if g.Squares[c.kingSq] != c.king {
+			v.report(E_FEN_CASTLING_RIGHTS, FEN_FIELD_CASTLING, offset, "castling right %q without the king on %s", c.char, c.kingSq.Coords())
+		}
+		if g.Squares[c.rookSq] != c.rook {
+			v.report(E_FEN_CASTLING_RIGHTS, FEN_FIELD_CASTLING, offset, "castling right %q without the rook on %s", c.char, c.rookSq.Coords())
+		}
+	}
+
+	if ep := g.EnPassant; v.fieldAt(offsets, FEN_FIELD_EN_PASSANT) != "-" {
+		var wantRank int
+		var pushed Piece
+		var behind, origin Square
+		if g.Turn == WHITE {
+			wantRank, pushed, behind, origin = 2, B_PAWN, ep+8, ep-8
+		} else {
+			wantRank, pushed, behind, origin = 5, W_PAWN, ep-8, ep+8
+		}
+		switch {
+		case ep.Rank() != wantRank:
+			v.report(E_FEN_IMPOSSIBLE_EP, FEN_FIELD_EN_PASSANT, offsets[FEN_FIELD_EN_PASSANT], "en-passant square %s is on the wrong rank", ep.Coords())
+		case g.Squares[behind] != pushed:
+			v.report(E_FEN_IMPOSSIBLE_EP, FEN_FIELD_EN_PASSANT, offsets[FEN_FIELD_EN_PASSANT], "no pawn on %s that could have just moved past %s", behind.Coords(), ep.Coords())
+		case g.Squares[ep] != EMPTY || g.Squares[origin] != EMPTY:
+			v.report(E_FEN_IMPOSSIBLE_EP, FEN_FIELD_EN_PASSANT, offsets[FEN_FIELD_EN_PASSANT], "squares %s and %s must be empty", ep.Coords(), origin.Coords())
+		}
+	}
+
+	if kingsOk {
+		g.Turn = opponentColor(g.Turn)
+		if g.ComputeIsCheck() {
+			v.report(E_FEN_OPPONENT_IN_CHECK, FEN_FIELD_TURN, offsets[FEN_FIELD_TURN], "the side not to move is in check")
+		}
+		g.Turn = opponentColor(g.Turn)
+	}
+}
+
+// Checks that piece counts and clocks could result from a real game
+func (v *fenValidator) checkReachable(g *Game, offsets []int) {
+	placementOffset := offsets[FEN_FIELD_PLACEMENT]
+	for _, side := range []struct {
+		name   string
+		pieces [7]Bitboard
+		all    Bitboard
+	}{{"white", g.Whites, g.WhitePieces}, {"black", g.Blacks, g.BlackPieces}} {
+		if n := side.all.NumberOfSetBits(); n > 16 {
+			v.report(E_FEN_TOO_MANY_PIECES, FEN_FIELD_PLACEMENT, placementOffset, "%s has %d pieces", side.name, n)
+		}
+		pawns := side.pieces[PAWN].NumberOfSetBits()
+		if pawns > 8 {
+			v.report(E_FEN_TOO_MANY_PAWNS, FEN_FIELD_PLACEMENT, placementOffset, "%s has %d pawns", side.name, pawns)
+		}
+		promoted := 0
+		for kind, initial := range map[Piece]int{KNIGHT: 2, BISHOP: 2, ROOK: 2, QUEEN: 1} {
+			if extra := side.pieces[kind].NumberOfSetBits() - initial; extra > 0 {
+				promoted += extra
+			}
+		}
+		if pawns <= 8 && promoted > 8-pawns {
+			v.report(E_FEN_TOO_MANY_PROMOTIONS, FEN_FIELD_PLACEMENT, placementOffset, "%s has %d promoted pieces but only %d missing pawns", side.name, promoted, 8-pawns)
+		}
+	}
+
+	if g.FullMoves == 0 {
+		v.report(E_FEN_FULL_MOVES, FEN_FIELD_FULL_MOVES, offsets[FEN_FIELD_FULL_MOVES], "full-move number starts at 1")
+	}
+	if g.EnPassant != 0 && g.HalfMoves != 0 {
+		v.report(E_FEN_CLOCKS, FEN_FIELD_HALF_MOVES, offsets[FEN_FIELD_HALF_MOVES], "half-move clock must be 0 right after a double pawn push")
+	}
+	plies := 2*(g.FullMoves-1) + 1
+	if g.Turn == WHITE {
+		plies--
+	}
+	if g.FullMoves > 0 && g.HalfMoves > plies {
+		v.report(E_FEN_CLOCKS, FEN_FIELD_HALF_MOVES, offsets[FEN_FIELD_HALF_MOVES], "half-move clock %d exceeds the %d plies played", g.HalfMoves, plies)
+	}
+}
+
+func (v *fenValidator) fieldAt(offsets []int, field int) string {
+	start := offsets[field]
+	end := len(v.fen)
+	if field+1 < len(offsets) {
+		end = offsets[field+1] - 1
+	}
+	return strings.TrimRight(v.fen[start:end], " ")
+}
@@ -0,0 +1,103 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func problemCodes(problems []FenProblem) []string {
+	var codes []string
+	for _, p := range problems {
+		codes = append(codes, p.Code)
+	}
+	return codes
+}
+
+func TestValidateFenAcceptsValidPositions(t *testing.T) {
+	fens := []string{
+		STARTING_POSITION_FEN,
+		"rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 3",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	}
+	for _, fen := range fens {
+		require.Emptyf(t, ValidateFen(fen, FEN_REACHABLE), "ValidateFen %s", fen)
+	}
+}
+
+func TestValidateFenSyntax(t *testing.T) {
+	tests := []struct {
+		fen    string
+		code   string
+		field  int
+		offset int
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -", E_FEN_FIELD_COUNT, FEN_FIELD_HALF_MOVES, 52},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 x", E_FEN_FIELD_COUNT, FEN_FIELD_COUNT, 57},
+		{"rnbqkbnr/ppppxppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", E_FEN_PIECE_CHAR, FEN_FIELD_PLACEMENT, 13},
+		{"rnbqkbnr/ppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", E_FEN_RANK_LENGTH, FEN_FIELD_PLACEMENT, 9},
+		{"rnbqkbnr/pppppppp/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", E_FEN_RANK_COUNT, FEN_FIELD_PLACEMENT, 0},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1", E_FEN_TURN, FEN_FIELD_TURN, 44},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQxq - 0 1", E_FEN_CASTLING, FEN_FIELD_CASTLING, 48},
+		{"8/8/8/8/8/8/8/7K w  - 0 0", E_FEN_CASTLING, FEN_FIELD_CASTLING, 19},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e9 0 1", E_FEN_EN_PASSANT, FEN_FIELD_EN_PASSANT, 51},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - a 1", E_FEN_HALF_MOVES, FEN_FIELD_HALF_MOVES, 53},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 -1", E_FEN_FULL_MOVES, FEN_FIELD_FULL_MOVES, 55},
+	}
+	for _, tt := range tests {
+		problems := ValidateFen(tt.fen, FEN_PARSE_ONLY)
+		require.Lenf(t, problems, 1, "ValidateFen %s: %v", tt.fen, problems)
+		require.Equal(t, tt.code, problems[0].Code, tt.fen)
+		require.Equal(t, tt.field, problems[0].Field, tt.fen)
+		require.Equal(t, tt.offset, problems[0].Offset, tt.fen)
+	}
+}
+
+func TestValidateFenLegalForPlay(t *testing.T) {
+	tests := []struct {
+		fen   string
+		codes []string
+	}{
+		{"8/8/8/8/8/8/8/8 w - - 0 1", []string{E_FEN_MISSING_KING, E_FEN_MISSING_KING}},
+		{"4k3/8/8/8/8/8/8/K3K3 w - - 0 1", []string{E_FEN_TOO_MANY_KINGS}},
+		{"P3k3/8/8/8/8/8/8/4K2p w - - 0 1", []string{E_FEN_PAWN_ON_BACK_RANK, E_FEN_PAWN_ON_BACK_RANK}},
+		{"4k3/8/8/8/8/8/8/4K3 w K - 0 1", []string{E_FEN_CASTLING_RIGHTS}},
+		{"4k3/8/8/8/8/8/8/4K3 w - e6 0 1", []string{E_FEN_IMPOSSIBLE_EP}},
+		{"4k3/8/8/8/8/8/8/4K3 w - e3 0 1", []string{E_FEN_IMPOSSIBLE_EP}},
+		{"4k3/4R3/8/8/8/8/8/4K3 w - - 0 1", []string{E_FEN_OPPONENT_IN_CHECK}},
+	}
+	for _, tt := range tests {
+		require.Emptyf(t, ValidateFen(tt.fen, FEN_PARSE_ONLY), "parse only %s", tt.fen)
+		require.Equalf(t, tt.codes, problemCodes(ValidateFen(tt.fen, FEN_LEGAL_FOR_PLAY)), "legal %s", tt.fen)
+	}
+
+	problems := ValidateFen("P3k3/8/8/8/8/8/8/4K2p w - - 0 1", FEN_LEGAL_FOR_PLAY)
+	require.Equal(t, 0, problems[0].Offset)
+	require.Equal(t, 20, problems[1].Offset)
+}
+
+func TestValidateFenReachable(t *testing.T) {
+	tests := []struct {
+		fen   string
+		codes []string
+	}{
+		{"4k3/pppppppp/p7/8/8/8/8/4K3 w - - 0 1", []string{E_FEN_TOO_MANY_PAWNS}},
+		{"4k3/8/8/8/8/8/PPPPPPP1/QQQ1K3 w - - 0 1", []string{E_FEN_TOO_MANY_PROMOTIONS}},
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 0", []string{E_FEN_FULL_MOVES}},
+		{"4k3/8/8/8/8/8/8/4K3 w - - 30 5", []string{E_FEN_CLOCKS}},
+		{"4k3/8/8/8/4P3/8/8/4K3 b - e3 3 10", []string{E_FEN_CLOCKS}},
+	}
+	for _, tt := range tests {
+		require.Emptyf(t, ValidateFen(tt.fen, FEN_LEGAL_FOR_PLAY), "legal %s", tt.fen)
+		require.Equalf(t, tt.codes, problemCodes(ValidateFen(tt.fen, FEN_REACHABLE)), "reachable %s", tt.fen)
+	}
+}
+
+func TestGameValidate(t *testing.T) {
+	g := NewGame()
+	require.Empty(t, g.Validate(FEN_REACHABLE))
+	require.NoError(t, g.LoadFen("4k3/8/8/8/8/8/8/4K3 w KQkq - 0 1"))
+	require.Equal(t, []string{E_FEN_CASTLING_RIGHTS, E_FEN_CASTLING_RIGHTS, E_FEN_CASTLING_RIGHTS, E_FEN_CASTLING_RIGHTS},
+		problemCodes(g.Validate(FEN_LEGAL_FOR_PLAY)))
+}
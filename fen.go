@@ -216,24 +216,37 @@ func (g *Game) LoadFen(fen string) error {
 		g.PositionHistory = map[uint64]int{}
 	}
 	g.recordPosition()
+	g.emitLoaded(SOURCE_FEN)
 
 	return nil
 }
 
+// FenOptions tweaks how ToFenWithOptions renders a position.
+type FenOptions struct {
+	// LegalEnPassantOnly emits the en-passant square only when an
+	// en-passant capture is actually legal for the side to move.
+	LegalEnPassantOnly bool
+}
+
 // return FEN representation of board
-func (g *Game) ToFen() string {
+func (p *Position) ToFen() string {
+	return p.ToFenWithOptions(FenOptions{})
+}
+
+// ToFenWithOptions returns the FEN representation of board rendered according to opts
+func (p *Position) ToFenWithOptions(opts FenOptions) string {
 	var pieces, turn, castling, enPassant string
 
 	pieces = ""
 	var i, emptyCount int = 0, 0
 	for rank := 0; rank < 8; rank++ {
 		for file := 0; file < 8; file++ {
-			if g.Squares[i] != EMPTY {
-				pieces += string(PIECE_TO_RUNE[g.Squares[i]])
+			if p.Squares[i] != EMPTY {
+				pieces += string(PIECE_TO_RUNE[p.Squares[i]])
 				i++
 				continue
 			}
-			for emptyCount = 0; file < 8 && g.Squares[i] == EMPTY; {
+			for emptyCount = 0; file < 8 && p.Squares[i] == EMPTY; {
 				emptyCount++
 				i++
 				file++
@@ -248,35 +261,35 @@ func (g *Game) ToFen() string {
 		}
 	}
 
-	if g.Turn == WHITE {
+	if p.Turn == WHITE {
 		turn = "w"
 	} else {
 		turn = "b"
 	}
 
 	castling = ""
-	if (g.Castling & CASTLE_WKS) > 0 {
+	if (p.Castling & CASTLE_WKS) > 0 {
 		castling += "K"
 	}
-	if (g.Castling & CASTLE_WQS) > 0 {
+	if (p.Castling & CASTLE_WQS) > 0 {
 		castling += "Q"
 	}
-	if (g.Castling & CASTLE_BKS) > 0 {
+	if (p.Castling & CASTLE_BKS) > 0 {
 		castling += "k"
 	}
-	if (g.Castling & CASTLE_BQS) > 0 {
+	if (p.Castling & CASTLE_BQS) > 0 {
 		castling += "q"
 	}
 	if len(castling) == 0 {
 		castling = "-"
 	}
 
-	if g.EnPassant == 0 {
+	if p.EnPassant == 0 || (opts.LegalEnPassantOnly && !p.hasLegalEnPassant()) {
 		enPassant = "-"
 	} else {
-		rank, file := squareCoords(g.EnPassant)
+		rank, file := squareCoords(p.EnPassant)
 		enPassant = FILE_TO_STRING[file] + RANK_TO_STRING[rank]
 	}
 
-	return fmt.Sprintf("%s %s %s %s %d %d", pieces, turn, castling, enPassant, g.HalfMoves, g.FullMoves)
+	return fmt.Sprintf("%s %s %s %s %d %d", pieces, turn, castling, enPassant, p.HalfMoves, p.FullMoves)
 }
@@ -0,0 +1,106 @@
+package chessongo
+
+import (
+	"crypto/rand"
+	"slices"
+	"sync"
+)
+
+// GameManager keeps track of many live games by id. All of its methods, and
+// those of the SafeGames it hands out, are safe for concurrent use. The
+// manager only locks its index; each game is locked on its own, so moves in
+// different games never wait for each other.
+type GameManager struct {
+	mu    sync.RWMutex
+	games map[string]*SafeGame
+}
+
+func NewGameManager() *GameManager {
+	return &GameManager{games: map[string]*SafeGame{}}
+}
+
+// Add registers g under a new random id and returns its SafeGame. The
+// manager takes ownership of g.
+func (gm *GameManager) Add(g *Game) *SafeGame {
+	sg := NewSafeGame("", g)
+	gm.register(sg)
+	return sg
+}
+
+// Create starts a new game from fen and registers it
+func (gm *GameManager) Create(fen string) (*SafeGame, error) {
+	g := &Game{}
+	if err := g.LoadFen(fen); err != nil {
+		return nil, err
+	}
+	return gm.Add(g), nil
+}
+
+// CreateFromPGN loads the main line of pgn into a new game and registers it
+func (gm *GameManager) CreateFromPGN(pgn string) (*SafeGame, error) {
+	sg, err := NewSafeGameFromPGN("", pgn)
+	if err != nil {
+		return nil, err
+	}
+	gm.register(sg)
+	return sg, nil
+}
+
+// Gives sg a new random id and registers it
+func (gm *GameManager) register(sg *SafeGame) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+	id := newGameID()
+	for gm.games[id] != nil {
+		id = newGameID()
+	}
+	sg.id = id
+	gm.games[id] = sg
+}
+
+// Get returns the game registered under id, or ErrGameNotFound
+func (gm *GameManager) Get(id string) (*SafeGame, error) {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+	sg, ok := gm.games[id]
+	if !ok {
+		return nil, ErrGameNotFound
+	}
+	return sg, nil
+}
+
+// Remove unregisters the game and closes it, ending its subscriptions
+func (gm *GameManager) Remove(id string) error {
+	gm.mu.Lock()
+	sg, ok := gm.games[id]
+	delete(gm.games, id)
+	gm.mu.Unlock()
+	if !ok {
+		return ErrGameNotFound
+	}
+	sg.Close()
+	return nil
+}
+
+// IDs returns the ids of all registered games, sorted
+func (gm *GameManager) IDs() []string {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+	ids := make([]string, 0, len(gm.games))
+	for id := range gm.games {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (gm *GameManager) Len() int {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+	return len(gm.games)
+}
+
+// Returns a random id; rand.Text cannot fail, unlike rand.Read
+func newGameID() string {
+	return rand.Text()
+}
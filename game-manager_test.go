@@ -0,0 +1,95 @@
+package chessongo
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGameManagerRegistry(t *testing.T) {
+	gm := NewGameManager()
+	a, err := gm.Create(STARTING_POSITION_FEN)
+	require.NoError(t, err)
+	b := gm.Add(NewGame())
+	require.NotEqual(t, a.ID(), b.ID())
+	require.Equal(t, 2, gm.Len())
+	require.ElementsMatch(t, []string{a.ID(), b.ID()}, gm.IDs())
+
+	got, err := gm.Get(a.ID())
+	require.NoError(t, err)
+	require.Same(t, a, got)
+
+	_, err = gm.Create("not a fen")
+	require.Error(t, err)
+
+	require.NoError(t, gm.Remove(a.ID()))
+	require.ErrorIs(t, gm.Remove(a.ID()), ErrGameNotFound)
+	_, err = gm.Get(a.ID())
+	require.ErrorIs(t, err, ErrGameNotFound)
+	_, err = a.MakeMove(coordsMove("e2", "e4"), ANY_PLY)
+	require.ErrorIs(t, err, ErrGameClosed)
+}
+
+// Several players race to move in several games while readers take
+// snapshots. Run with -race.
+func TestGameManagerStress(t *testing.T) {
+	const games, players, readers, plies = 4, 4, 2, 24
+	gm := NewGameManager()
+	var wg sync.WaitGroup
+	for i := 0; i < games; i++ {
+		sg := gm.Add(NewGame())
+		events, unsubscribe := sg.Subscribe(plies + 1)
+		defer unsubscribe()
+
+		for p := 0; p < players; p++ {
+			wg.Go(func() {
+				for {
+					snapshot := sg.Snapshot()
+					if snapshot.Ply >= plies || snapshot.Result != RESULT_ONGOING {
+						return
+					}
+					m := snapshot.LegalMoves[(snapshot.Ply*7+p)%len(snapshot.LegalMoves)]
+					_, err := sg.MakeMove(m, snapshot.Ply)
+					if err != nil && !errors.Is(err, ErrStaleGame) && !errors.Is(err, ErrGameFinished) {
+						t.Error(err)
+						return
+					}
+				}
+			})
+		}
+		for r := 0; r < readers; r++ {
+			wg.Go(func() {
+				for j := 0; j < 50; j++ {
+					snapshot := sg.Snapshot()
+					if len(snapshot.San) != snapshot.Ply {
+						t.Errorf("snapshot at ply %d has %d moves", snapshot.Ply, len(snapshot.San))
+					}
+					sg.Read(func(g *Game) { _ = g.ToFen() })
+				}
+			})
+		}
+		wg.Go(func() {
+			for j := 0; j < 20; j++ {
+				gm.IDs()
+				gm.Get(sg.ID())
+			}
+		})
+		defer func() {
+			snapshot := sg.Snapshot()
+			for ply := 1; ply <= snapshot.Ply; ply++ {
+				event := <-events
+				require.Equal(t, ply, event.Ply)
+				require.Equal(t, snapshot.San[ply-1], event.San)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, id := range gm.IDs() {
+		sg, err := gm.Get(id)
+		require.NoError(t, err)
+		snapshot := sg.Snapshot()
+		require.True(t, snapshot.Ply == plies || snapshot.Result != RESULT_ONGOING)
+	}
+}
@@ -0,0 +1,109 @@
+package chessongo
+
+import "fmt"
+
+// GameTree is a game with variations, for publishing: the mainline is the
+// history of Game, and each variation is an alternative to one of its moves.
+type GameTree struct {
+	Game       *Game
+	Variations []Variation
+}
+
+// Variation is a line of moves played instead of the move at Ply, counted
+// from 1 like the plies of the game, with variations of its own
+type Variation struct {
+	Ply int
+	// Moves in SAN, the first one replacing the move at Ply
+	Moves []string
+	// Alternatives to the moves of this line, after its first one
+	Variations []Variation
+}
+
+// Replays the mainline from the start of the game, returning the start FEN
+// and each move with the position after it and the variations played
+// instead of it
+func (t *GameTree) publishedMoves() (string, []publishedMove, error) {
+	g := t.Game
+	start := g.Fen
+	if start == "" {
+		start = g.ToFen()
+	}
+	byPly := variationsByPly(t.Variations)
+	// the positions the variations start from
+	positions := map[int]*Game{}
+	if len(byPly[1]) > 0 {
+		first := &Game{}
+		if err := first.LoadFen(start); err != nil {
+			return "", nil, err
+		}
+		first.GenerateLegalMoves()
+		positions[1] = first
+	}
+	moves := make([]publishedMove, 0, len(g.History))
+	err := g.replayHistory(func(_ Move, san string, after *Game) {
+		moves = append(moves, newPublishedMove(san, after))
+		if ply := len(moves) + 1; len(byPly[ply]) > 0 {
+			position := CloneGame(after)
+			positions[ply] = &position
+		}
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	for ply, variations := range byPly {
+		if ply < 1 || ply > len(moves) {
+			return "", nil, fmt.Errorf(E_INVALID_PLY)
+		}
+		for _, v := range variations {
+			line, err := v.publishedMoves(positions[ply])
+			if err != nil {
+				return "", nil, err
+			}
+			moves[ply-1].Variations = append(moves[ply-1].Variations, line)
+		}
+	}
+	return start, moves, nil
+}
+
+// Plays the moves of v from before, the position before its first move
+func (v Variation) publishedMoves(before *Game) ([]publishedMove, error) {
+	byPly := variationsByPly(v.Variations)
+	for ply := range byPly {
+		if ply <= v.Ply || ply >= v.Ply+len(v.Moves) {
+			return nil, fmt.Errorf(E_INVALID_PLY)
+		}
+	}
+	g := CloneGame(before)
+	moves := make([]publishedMove, 0, len(v.Moves))
+	for i, san := range v.Moves {
+		var position Game
+		if len(byPly[v.Ply+i]) > 0 {
+			position = CloneGame(&g)
+		}
+		m, err := g.ParseSan(san)
+		if err != nil {
+			return nil, err
+		}
+		// written again with its check or mate suffix
+		san = g.GetMoveSan(m)
+		g.MakeMove(m)
+		moves = append(moves, newPublishedMove(san, &g))
+		for _, sub := range byPly[v.Ply+i] {
+			line, err := sub.publishedMoves(&position)
+			if err != nil {
+				return nil, err
+			}
+			moves[i].Variations = append(moves[i].Variations, line)
+		}
+	}
+	return moves, nil
+}
+
+// Groups variations by the ply they replace, keeping their order
+func variationsByPly(variations []Variation) map[int][]Variation {
+	byPly := map[int][]Variation{}
+	for _, v := range variations {
+		byPly[v.Ply] = append(byPly[v.Ply], v)
+	}
+	return byPly
+}
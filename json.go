@@ -0,0 +1,247 @@
+package chessongo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	E_INVALID_SQUARE    = "e:invalid:square"
+	E_INVALID_PIECE     = "e:invalid:piece"
+	E_INVALID_GAME_JSON = "e:invalid:game-json"
+)
+
+// MarshalText writes the square in coordinates, e.g. "e4"
+func (s Square) MarshalText() ([]byte, error) {
+	if s > 63 {
+		return nil, fmt.Errorf(E_INVALID_SQUARE)
+	}
+	return []byte(s.Coords()), nil
+}
+
+func (s *Square) UnmarshalText(text []byte) error {
+	sq, ok := COORDS_TO_SQUARE[strings.ToLower(string(text))]
+	if !ok {
+		return fmt.Errorf(E_INVALID_SQUARE)
+	}
+	*s = sq
+	return nil
+}
+
+// MarshalText writes the piece as its color and kind, e.g. "wN" or "bq", and
+// EMPTY as an empty string
+func (p Piece) MarshalText() ([]byte, error) {
+	if p == EMPTY {
+		return []byte{}, nil
+	}
+	r, ok := PIECE_TO_RUNE[Piece(uint(p.Kind())|WHITE)]
+	if !ok || p.Color() == NO_COLOR {
+		return nil, fmt.Errorf(E_INVALID_PIECE)
+	}
+	if p.IsWhite() {
+		return []byte("w" + string(r)), nil
+	}
+	return []byte("b" + string(r)), nil
+}
+
+// UnmarshalText reads a piece written by MarshalText; the kind letter may be
+// in either case
+func (p *Piece) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*p = EMPTY
+		return nil
+	}
+	if len(text) != 2 {
+		return fmt.Errorf(E_INVALID_PIECE)
+	}
+	kind, ok := STRING_TO_KIND[string(text[1])]
+	if !ok {
+		return fmt.Errorf(E_INVALID_PIECE)
+	}
+	switch text[0] {
+	case 'w':
+		*p = Piece(kind | WHITE)
+	case 'b':
+		*p = Piece(kind | BLACK)
+	default:
+		return fmt.Errorf(E_INVALID_PIECE)
+	}
+	return nil
+}
+
+// MarshalText writes the move in UCI notation, e.g. "e7e8q"
+func (m Move) MarshalText() ([]byte, error) {
+	return []byte(m.Uci()), nil
+}
+
+// UnmarshalText reads a move in UCI notation. The text only carries the
+// squares and the promotion, so the result has no captured piece nor
+// castling or en passant flag: use Game.ParseUci to get the complete move of
+// a position.
+func (m *Move) UnmarshalText(text []byte) error {
+	uci := strings.ToLower(string(text))
+	if len(uci) != 4 && len(uci) != 5 {
+		return fmt.Errorf(E_INVALID_UCI)
+	}
+	from, okFrom := COORDS_TO_SQUARE[uci[0:2]]
+	to, okTo := COORDS_TO_SQUARE[uci[2:4]]
+	if !okFrom || !okTo {
+		return fmt.Errorf(E_INVALID_UCI)
+	}
+	if len(uci) == 4 {
+		*m = NewMove(from, to, EMPTY)
+		return nil
+	}
+	kind, ok := STRING_TO_KIND[uci[4:]]
+	if !ok || kind == PAWN || kind == KING {
+		return fmt.Errorf(E_INVALID_UCI)
+	}
+	*m = NewPromotionMove(from, to, EMPTY, Piece(kind))
+	return nil
+}
+
+// MoveJSON is a move in both UCI and SAN notation
+type MoveJSON struct {
+	Uci string `json:"uci"`
+	San string `json:"san"`
+}
+
+// GameJSON is the JSON schema of a game snapshot:
+//
+//	{
+//	  "fen":          "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2",
+//	  "startFen":     "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+//	  "turn":         "w",
+//	  "legalMoves":   [{"uci": "g1f3", "san": "Nf3"}, ...],
+//	  "history":      [{"uci": "e2e4", "san": "e4"}, {"uci": "e7e5", "san": "e5"}],
+//	  "lastMove":     {"uci": "e7e5", "san": "e5"},
+//	  "check":        false,
+//	  "checkSquares": [],
+//	  "result":       "*",
+//	  "termination":  "none"
+//	}
+//
+// fen is the current position and startFen the one history starts from.
+// turn is "w" or "b". legalMoves is empty once the game is over. lastMove is
+// null before the first move. checkSquares holds the square of the king in
+// check followed by those of the pieces giving check. result is written as in
+// PGN ("1-0", "0-1", "1/2-1/2" or "*") and termination as Termination.String.
+//
+// Only fen, startFen and history are read back: the game is replayed from
+// startFen through the history, which may give the moves in either UCI or
+// SAN, and must end on fen when it is set. Without startFen the game starts
+// from fen.
+type GameJSON struct {
+	Fen          string     `json:"fen"`
+	StartFen     string     `json:"startFen,omitempty"`
+	Turn         string     `json:"turn"`
+	LegalMoves   []MoveJSON `json:"legalMoves"`
+	History      []MoveJSON `json:"history"`
+	LastMove     *MoveJSON  `json:"lastMove"`
+	Check        bool       `json:"check"`
+	CheckSquares []Square   `json:"checkSquares"`
+	Result       string     `json:"result"`
+	Termination  string     `json:"termination"`
+}
+
+// ToGameJSON returns a snapshot of the game in the schema of GameJSON. It
+// works on a copy of the game with its legal moves and status computed, so
+// the game need not be up to date after LoadFen and is left untouched.
+func (g *Game) ToGameJSON() (GameJSON, error) {
+	g = g.withStatus()
+	result, termination := g.Result()
+	snapshot := GameJSON{
+		Fen:          g.ToFen(),
+		StartFen:     g.Fen,
+		Turn:         "w",
+		LegalMoves:   []MoveJSON{},
+		History:      []MoveJSON{},
+		Check:        g.IsCheck,
+		CheckSquares: []Square{},
+		Result:       result,
+		Termination:  termination.String(),
+	}
+	if g.Turn == BLACK {
+		snapshot.Turn = "b"
+	}
+	if result == RESULT_ONGOING {
+		for _, m := range g.LegalMoves {
+			snapshot.LegalMoves = append(snapshot.LegalMoves, MoveJSON{Uci: m.Uci(), San: g.GetMoveSan(m)})
+		}
+	}
+	if g.IsCheck {
+		king := Square(g.piecesOf(g.Turn)[KING].lsbIndex())
+		snapshot.CheckSquares = append(snapshot.CheckSquares, king)
+		checkers := g.AttackersOf(king, opponentColor(g.Turn))
+		for checkers > 0 {
+			snapshot.CheckSquares = append(snapshot.CheckSquares, Square(checkers.popLSB()))
+		}
+	}
+	err := g.replayHistory(func(m Move, san string, _ *Game) {
+		snapshot.History = append(snapshot.History, MoveJSON{Uci: m.Uci(), San: san})
+	})
+	if err != nil {
+		return GameJSON{}, err
+	}
+	if len(snapshot.History) > 0 {
+		snapshot.LastMove = &snapshot.History[len(snapshot.History)-1]
+	}
+	return snapshot, nil
+}
+
+// Game builds the game described by the snapshot, see GameJSON
+func (j GameJSON) Game() (*Game, error) {
+	start := j.StartFen
+	if start == "" {
+		if len(j.History) > 0 {
+			return nil, fmt.Errorf(E_INVALID_GAME_JSON)
+		}
+		start = j.Fen
+	}
+	g := &Game{}
+	if err := g.LoadFen(start); err != nil {
+		return nil, err
+	}
+	g.refreshStatus()
+	for _, mv := range j.History {
+		notation := mv.Uci
+		if notation == "" {
+			notation = mv.San
+		}
+		m, err := g.ParseMove(notation)
+		if err != nil {
+			return nil, fmt.Errorf(E_ILLEGAL_MOVE)
+		}
+		g.MakeMove(m)
+	}
+	if j.Fen != "" && j.Fen != g.ToFen() {
+		return nil, fmt.Errorf(E_INVALID_GAME_JSON)
+	}
+	return g, nil
+}
+
+// MarshalJSON writes the game as a GameJSON snapshot
+func (g *Game) MarshalJSON() ([]byte, error) {
+	snapshot, err := g.ToGameJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(snapshot)
+}
+
+// UnmarshalJSON replaces the game with the one described by a GameJSON
+// snapshot. Observers are kept and notified as for LoadFen.
+func (g *Game) UnmarshalJSON(data []byte) error {
+	var snapshot GameJSON
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+	loaded, err := snapshot.Game()
+	if err != nil {
+		return err
+	}
+	g.replaceWith(loaded)
+	g.emitLoaded(SOURCE_JSON)
+	return nil
+}
@@ -0,0 +1,115 @@
+package chessongo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSquarePieceMoveText(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Square Square
+		Piece  Piece
+		Empty  Piece
+		Move   Move
+	}{COORDS_TO_SQUARE["e4"], W_KNIGHT, EMPTY, NewPromotionMove(COORDS_TO_SQUARE["e7"], COORDS_TO_SQUARE["e8"], EMPTY, QUEEN)})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Square":"e4","Piece":"wN","Empty":"","Move":"e7e8q"}`, string(data))
+
+	var sq Square
+	require.NoError(t, sq.UnmarshalText([]byte("h1")))
+	require.Equal(t, Square(63), sq)
+	require.Error(t, sq.UnmarshalText([]byte("i9")))
+	_, err = Square(64).MarshalText()
+	require.Error(t, err)
+
+	var p Piece
+	require.NoError(t, p.UnmarshalText([]byte("bq")))
+	require.Equal(t, Piece(B_QUEEN), p)
+	require.NoError(t, p.UnmarshalText([]byte("bQ")))
+	require.Equal(t, Piece(B_QUEEN), p)
+	require.Error(t, p.UnmarshalText([]byte("xQ")))
+	require.Error(t, p.UnmarshalText([]byte("wX")))
+
+	var m Move
+	require.NoError(t, m.UnmarshalText([]byte("g1f3")))
+	require.Equal(t, NewMove(COORDS_TO_SQUARE["g1"], COORDS_TO_SQUARE["f3"], EMPTY), m)
+	require.NoError(t, m.UnmarshalText([]byte("a2a1n")))
+	require.Equal(t, Piece(KNIGHT), m.GetPromotionTo())
+	require.Error(t, m.UnmarshalText([]byte("a2a1k")))
+	require.Error(t, m.UnmarshalText([]byte("e2")))
+}
+
+func TestGameJSON(t *testing.T) {
+	g := NewGame()
+	g.GenerateLegalMoves()
+	for _, san := range []string{"e4", "e5", "Qh5", "Nc6", "Bc4", "Nf6", "Qxf7"} {
+		m, err := g.ParseSan(san)
+		require.NoError(t, err)
+		g.MakeMove(m)
+	}
+	snapshot, err := g.ToGameJSON()
+	require.NoError(t, err)
+	require.Equal(t, "r1bqkb1r/pppp1Qpp/2n2n2/4p3/2B1P3/8/PPPP1PPP/RNB1K1NR b KQkq - 0 4", snapshot.Fen)
+	require.Equal(t, STARTING_POSITION_FEN, snapshot.StartFen)
+	require.Equal(t, "b", snapshot.Turn)
+	require.Empty(t, snapshot.LegalMoves)
+	require.Len(t, snapshot.History, 7)
+	require.Equal(t, MoveJSON{Uci: "h5f7", San: "Qxf7#"}, *snapshot.LastMove)
+	require.True(t, snapshot.Check)
+	require.Equal(t, []Square{COORDS_TO_SQUARE["e8"], COORDS_TO_SQUARE["f7"]}, snapshot.CheckSquares)
+	require.Equal(t, RESULT_WHITE_WINS, snapshot.Result)
+	require.Equal(t, "checkmate", snapshot.Termination)
+
+	data, err := json.Marshal(g)
+	require.NoError(t, err)
+	restored := &Game{}
+	require.NoError(t, json.Unmarshal(data, restored))
+	require.Equal(t, g.ToFen(), restored.ToFen())
+	require.Equal(t, g.History, restored.History)
+	require.True(t, restored.IsCheckmate)
+	again, err := json.Marshal(restored)
+	require.NoError(t, err)
+	require.JSONEq(t, string(data), string(again))
+}
+
+func TestGameJSONOngoing(t *testing.T) {
+	g := NewGame()
+	data, err := json.Marshal(g)
+	require.NoError(t, err)
+	var snapshot GameJSON
+	require.NoError(t, json.Unmarshal(data, &snapshot))
+	require.Len(t, snapshot.LegalMoves, 20)
+	require.Contains(t, snapshot.LegalMoves, MoveJSON{Uci: "g1f3", San: "Nf3"})
+	require.Nil(t, snapshot.LastMove)
+	require.Empty(t, snapshot.CheckSquares)
+	require.Equal(t, RESULT_ONGOING, snapshot.Result)
+}
+
+func TestGameJSONFromClient(t *testing.T) {
+	// Clients may send SAN only, and need not repeat the derived fields
+	data := `{"startFen": "` + STARTING_POSITION_FEN + `", "history": [{"san": "d4"}, {"uci": "d7d5"}],
+		"fen": "rnbqkbnr/ppp1pppp/8/3p4/3P4/8/PPP1PPPP/RNBQKBNR w KQkq d6 0 2"}`
+	g := &Game{}
+	require.NoError(t, json.Unmarshal([]byte(data), g))
+	require.Len(t, g.History, 2)
+	require.Equal(t, WHITE, int(g.Turn))
+
+	var loaded []GameEvent
+	g.AddObserver(ObserverFunc(func(_ *Game, e GameEvent) { loaded = append(loaded, e) }))
+	require.NoError(t, json.Unmarshal([]byte(`{"fen": "8/8/8/8/8/8/8/K6k w - - 0 1"}`), g))
+	require.Equal(t, []GameEvent{{Kind: EVENT_POSITION_LOADED, Source: SOURCE_JSON}}, loaded)
+	require.Empty(t, g.History)
+
+	for _, bad := range []string{
+		// the history does not lead to fen
+		`{"startFen": "` + STARTING_POSITION_FEN + `", "history": [{"uci": "e2e4"}], "fen": "8/8/8/8/8/8/8/K6k w - - 0 1"}`,
+		// illegal move
+		`{"startFen": "` + STARTING_POSITION_FEN + `", "history": [{"uci": "e2e5"}]}`,
+		// history without a start position
+		`{"history": [{"uci": "e2e4"}], "fen": "` + STARTING_POSITION_FEN + `"}`,
+	} {
+		require.Error(t, json.Unmarshal([]byte(bad), &Game{}), bad)
+	}
+}
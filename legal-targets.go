@@ -0,0 +1,38 @@
+package chessongo
+
+// LegalMovesFrom returns the legal moves of the piece on sq, in the order of
+// g.LegalMoves. g.LegalMoves must be generated.
+func (g *Game) LegalMovesFrom(sq Square) []Move {
+	var moves []Move
+	for _, m := range g.LegalMoves {
+		if m.From() == sq {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// LegalTargets returns the squares the piece on sq can legally move to.
+// Castling is given by the square the king moves to. g.LegalMoves must be
+// generated.
+func (g *Game) LegalTargets(sq Square) Bitboard {
+	var targets Bitboard
+	for _, m := range g.LegalMoves {
+		if m.From() == sq {
+			targets |= Bitboard(1) << m.To()
+		}
+	}
+	return targets
+}
+
+// NeedsPromotionChoice tells whether moving from from to to is a legal
+// promotion, for which the piece to promote to must be chosen. g.LegalMoves
+// must be generated.
+func (g *Game) NeedsPromotionChoice(from, to Square) bool {
+	for _, m := range g.LegalMoves {
+		if m.From() == from && m.To() == to && m.IsPromotionMove() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func squaresOf(bb Bitboard) []string {
+	var squares []string
+	for bb > 0 {
+		squares = append(squares, Square(bb.popLSB()).Coords())
+	}
+	return squares
+}
+
+func TestLegalTargets(t *testing.T) {
+	g := &Game{}
+	require.NoError(t, g.LoadFen("r3k2r/1P6/8/8/8/8/8/R3K2R w KQkq - 0 1"))
+	g.GenerateLegalMoves()
+
+	require.ElementsMatch(t, []string{"d1", "f1", "d2", "e2", "f2", "c1", "g1"}, squaresOf(g.LegalTargets(COORDS_TO_SQUARE["e1"])))
+	require.Len(t, g.LegalMovesFrom(COORDS_TO_SQUARE["e1"]), 7)
+	// four promotions on each of b8 and a8
+	require.Len(t, g.LegalMovesFrom(COORDS_TO_SQUARE["b7"]), 8)
+	require.ElementsMatch(t, []string{"a8", "b8"}, squaresOf(g.LegalTargets(COORDS_TO_SQUARE["b7"])))
+	require.Empty(t, g.LegalMovesFrom(COORDS_TO_SQUARE["e4"]))
+	require.Zero(t, g.LegalTargets(COORDS_TO_SQUARE["a8"]))
+
+	require.True(t, g.NeedsPromotionChoice(COORDS_TO_SQUARE["b7"], COORDS_TO_SQUARE["a8"]))
+	require.True(t, g.NeedsPromotionChoice(COORDS_TO_SQUARE["b7"], COORDS_TO_SQUARE["b8"]))
+	require.False(t, g.NeedsPromotionChoice(COORDS_TO_SQUARE["b7"], COORDS_TO_SQUARE["c8"]))
+	require.False(t, g.NeedsPromotionChoice(COORDS_TO_SQUARE["a1"], COORDS_TO_SQUARE["a8"]))
+}
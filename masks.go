@@ -18,6 +18,9 @@ const (
 	FILE_F_MASK = 0x2020202020202020
 	FILE_G_MASK = 0x4040404040404040
 	FILE_H_MASK = 0x8080808080808080 //1000000010000000100000001000000010000000100000001000000010000000
+
+	LIGHT_SQUARES_MASK Bitboard = 0xAA55AA55AA55AA55 // a8, c8, ..., b7, d7, ...
+	DARK_SQUARES_MASK  Bitboard = ^LIGHT_SQUARES_MASK
 )
 
 //Attack maps for every possible position
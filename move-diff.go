@@ -0,0 +1,129 @@
+package chessongo
+
+import "slices"
+
+// ChangeKind tells what happens to a piece in a PieceChange
+type ChangeKind uint8
+
+const (
+	// The piece goes from From to To
+	CHANGE_MOVED ChangeKind = iota
+	// The piece is taken off From
+	CHANGE_REMOVED
+	// The piece is put on To
+	CHANGE_ADDED
+)
+
+// PieceChange is a piece moving, leaving or arriving on the board. For
+// removals and additions From and To are both the square concerned.
+type PieceChange struct {
+	Kind     ChangeKind
+	Piece    Piece
+	From, To Square
+}
+
+func moved(piece Piece, from, to Square) PieceChange {
+	return PieceChange{Kind: CHANGE_MOVED, Piece: piece, From: from, To: to}
+}
+
+func removed(piece Piece, sq Square) PieceChange {
+	return PieceChange{Kind: CHANGE_REMOVED, Piece: piece, From: sq, To: sq}
+}
+
+func added(piece Piece, sq Square) PieceChange {
+	return PieceChange{Kind: CHANGE_ADDED, Piece: piece, From: sq, To: sq}
+}
+
+// Squares of the rook before and after castling, by square the king moves to
+var castlingRookHops = map[Square][2]Square{
+	WKS_KING_TO_SQUARE: {WKS_ROOK_ORIGINAL_SQUARE, WKS_KING_TO_SQUARE - 1},
+	WQS_KING_TO_SQUARE: {WQS_ROOK_ORIGINAL_SQUARE, WQS_KING_TO_SQUARE + 1},
+	BKS_KING_TO_SQUARE: {BKS_ROOK_ORIGINAL_SQUARE, BKS_KING_TO_SQUARE - 1},
+	BQS_KING_TO_SQUARE: {BQS_ROOK_ORIGINAL_SQUARE, BQS_KING_TO_SQUARE + 1},
+}
+
+// MoveDiff returns the changes legal move m makes to the board, in the order
+// to animate them: the captured piece is removed, including a pawn taken en
+// passant beside the target square, then the piece moves, along with the
+// rook when castling, and a promoting pawn is finally replaced by its new
+// piece.
+func (p *Position) MoveDiff(m Move) []PieceChange {
+	from, to := m.From(), m.To()
+	piece := p.Squares[from]
+	var changes []PieceChange
+	if m.IsEnPassant() {
+		captured := Square(from.Rank()*8 + to.File())
+		changes = append(changes, removed(p.Squares[captured], captured))
+	} else if p.Squares[to] != EMPTY {
+		changes = append(changes, removed(p.Squares[to], to))
+	}
+	changes = append(changes, moved(piece, from, to))
+	if m.IsCastlingMove() {
+		hop := castlingRookHops[to]
+		changes = append(changes, moved(p.Squares[hop[0]], hop[0], hop[1]))
+	}
+	if promoteTo := m.GetPromotionTo(); promoteTo != EMPTY {
+		changes = append(changes, removed(piece, to), added(Piece(uint(promoteTo)|uint(piece.Color())), to))
+	}
+	return changes
+}
+
+// Returns the larger of the file and rank distances between a and b
+func squareDistance(a, b Square) int {
+	return max(abs(a.File()-b.File()), abs(a.Rank()-b.Rank()))
+}
+
+// PositionDiff returns the changes that turn the board of before into the
+// board of after, such as two positions of a game a few plies apart, in the
+// order to animate them: removals, moves, then additions. Pieces leaving a
+// square are paired with identical pieces arriving elsewhere, the closest
+// first, to make moves; the others are removed or added.
+func PositionDiff(before, after *Position) []PieceChange {
+	var vacated, arrived []Square
+	for sq := Square(0); sq < 64; sq++ {
+		if b, a := before.Squares[sq], after.Squares[sq]; b != a {
+			if b != EMPTY {
+				vacated = append(vacated, sq)
+			}
+			if a != EMPTY {
+				arrived = append(arrived, sq)
+			}
+		}
+	}
+
+	type pair struct{ from, to Square }
+	var pairs []pair
+	for _, from := range vacated {
+		for _, to := range arrived {
+			if before.Squares[from] == after.Squares[to] {
+				pairs = append(pairs, pair{from, to})
+			}
+		}
+	}
+	// stable, so that ties go to the lowest squares
+	slices.SortStableFunc(pairs, func(a, b pair) int {
+		return squareDistance(a.from, a.to) - squareDistance(b.from, b.to)
+	})
+	var moves []PieceChange
+	usedFrom, usedTo := map[Square]bool{}, map[Square]bool{}
+	for _, pr := range pairs {
+		if !usedFrom[pr.from] && !usedTo[pr.to] {
+			usedFrom[pr.from], usedTo[pr.to] = true, true
+			moves = append(moves, moved(before.Squares[pr.from], pr.from, pr.to))
+		}
+	}
+
+	var changes []PieceChange
+	for _, sq := range vacated {
+		if !usedFrom[sq] {
+			changes = append(changes, removed(before.Squares[sq], sq))
+		}
+	}
+	changes = append(changes, moves...)
+	for _, sq := range arrived {
+		if !usedTo[sq] {
+			changes = append(changes, added(after.Squares[sq], sq))
+		}
+	}
+	return changes
+}
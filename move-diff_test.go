@@ -0,0 +1,75 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sq(coords string) Square {
+	return COORDS_TO_SQUARE[coords]
+}
+
+func TestMoveDiff(t *testing.T) {
+	for _, c := range []struct {
+		fen     string
+		uci     string
+		changes []PieceChange
+	}{
+		{STARTING_POSITION_FEN, "g1f3", []PieceChange{moved(W_KNIGHT, sq("g1"), sq("f3"))}},
+		{"4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1", "e4d5", []PieceChange{
+			removed(B_PAWN, sq("d5")), moved(W_PAWN, sq("e4"), sq("d5")),
+		}},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2", "e5d6", []PieceChange{
+			removed(B_PAWN, sq("d5")), moved(W_PAWN, sq("e5"), sq("d6")),
+		}},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8c8", []PieceChange{
+			moved(B_KING, sq("e8"), sq("c8")), moved(B_ROOK, sq("a8"), sq("d8")),
+		}},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", []PieceChange{
+			moved(W_KING, sq("e1"), sq("g1")), moved(W_ROOK, sq("h1"), sq("f1")),
+		}},
+		{"r3k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7a8n", []PieceChange{
+			removed(B_ROOK, sq("a8")), moved(W_PAWN, sq("b7"), sq("a8")),
+			removed(W_PAWN, sq("a8")), added(W_KNIGHT, sq("a8")),
+		}},
+	} {
+		g := &Game{}
+		require.NoError(t, g.LoadFen(c.fen))
+		g.GenerateLegalMoves()
+		m, err := g.ParseUci(c.uci)
+		require.NoError(t, err)
+		require.Equal(t, c.changes, g.MoveDiff(m), c.uci)
+
+		// the position diff finds the same changes, except that it cannot
+		// tell a promotion from a pawn leaving and a piece arriving
+		if !m.IsPromotionMove() {
+			after := g.Play(m)
+			require.ElementsMatch(t, c.changes, PositionDiff(&g.Position, &after), c.uci)
+		}
+	}
+}
+
+func TestPositionDiff(t *testing.T) {
+	before, err := LoadPGNGame("1. e4 e5 2. Nf3 Nc6")
+	require.NoError(t, err)
+	after, err := LoadPGNGame("1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 4. Bxc6 dxc6")
+	require.NoError(t, err)
+	require.Equal(t, []PieceChange{
+		removed(B_KNIGHT, sq("c6")),
+		removed(W_BISHOP, sq("f1")),
+		moved(B_PAWN, sq("a7"), sq("a6")),
+		moved(B_PAWN, sq("d7"), sq("c6")),
+	}, PositionDiff(&before.Position, &after.Position))
+
+	// identical pieces pair up with the closest ones
+	a, err := NewPosition("4k3/8/8/8/8/8/8/N3K2N w - - 0 1")
+	require.NoError(t, err)
+	b, err := NewPosition("4k3/8/8/8/8/8/1N4N1/4K3 w - - 0 1")
+	require.NoError(t, err)
+	require.Equal(t, []PieceChange{
+		moved(W_KNIGHT, sq("a1"), sq("b2")),
+		moved(W_KNIGHT, sq("h1"), sq("g2")),
+	}, PositionDiff(&a, &b))
+	require.Empty(t, PositionDiff(&a, &a))
+}
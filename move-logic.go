@@ -4,33 +4,59 @@ import "strings"
 
 const maxGeneratedMoves = 256
 
-var genMovesCalls uint = 0
+// Classes of moves the generators emit
+const (
+	// Captures (en passant and capturing promotions included) and promotions to a queen
+	GEN_CAPTURES = 1 << iota
+	// All other moves, under-promotions without capture included
+	GEN_QUIETS
+	GEN_ALL = GEN_CAPTURES | GEN_QUIETS
+)
 
 // Generate all peseudo moves
 func (g *Game) GeneratePseudoMoves() {
-	var ours [7]Bitboard
-	var oursAll Bitboard
-	if g.Turn == WHITE {
-		ours = g.Whites
-		oursAll = g.WhitePieces
-	} else {
-		ours = g.Blacks
-		oursAll = g.BlackPieces
-	}
 	// Reuse underlying array capacity if available
 	if cap(g.PseudoMoves) < maxGeneratedMoves {
 		g.PseudoMoves = make([]Move, 0, maxGeneratedMoves)
 	} else {
 		g.PseudoMoves = g.PseudoMoves[:0]
 	}
-	g.genPawnOneStep()
-	g.genPawnTwoSteps()
-	g.genPawnAttacks()
-	g.genFromMoves(ours[KING], oursAll, KING_ATTACKS_FROM[:])
-	g.genFromMoves(ours[KNIGHT], oursAll, KNIGHT_ATTACKS_FROM[:])
-	g.genRayMoves(ours[BISHOP]|ours[QUEEN], oursAll, BISHOP_DIRECTIONS[:])
-	g.genRayMoves(ours[ROOK]|ours[QUEEN], oursAll, ROOK_DIRECTIONS[:])
-	g.genCastling()
+	g.PseudoMoves = g.generateMoves(g.PseudoMoves, GEN_ALL)
+}
+
+// Appends the pseudo-legal moves of the given classes to dst
+func (p *Position) generateMoves(dst []Move, classes int) []Move {
+	var ours [7]Bitboard
+	var oursAll, theirsAll Bitboard
+	if p.Turn == WHITE {
+		ours = p.Whites
+		oursAll, theirsAll = p.WhitePieces, p.BlackPieces
+	} else {
+		ours = p.Blacks
+		oursAll, theirsAll = p.BlackPieces, p.WhitePieces
+	}
+	var targets Bitboard
+	if classes&GEN_CAPTURES > 0 {
+		targets |= theirsAll
+	}
+	if classes&GEN_QUIETS > 0 {
+		targets |= ^p.Occupied
+	}
+	dst = p.genPawnOneStep(dst, classes)
+	if classes&GEN_QUIETS > 0 {
+		dst = p.genPawnTwoSteps(dst)
+	}
+	if classes&GEN_CAPTURES > 0 {
+		dst = p.genPawnAttacks(dst)
+	}
+	dst = p.genFromMoves(dst, ours[KING], targets&^oursAll, KING_ATTACKS_FROM[:])
+	dst = p.genFromMoves(dst, ours[KNIGHT], targets&^oursAll, KNIGHT_ATTACKS_FROM[:])
+	dst = p.genRayMoves(dst, ours[BISHOP]|ours[QUEEN], targets&^oursAll, BISHOP_DIRECTIONS[:])
+	dst = p.genRayMoves(dst, ours[ROOK]|ours[QUEEN], targets&^oursAll, ROOK_DIRECTIONS[:])
+	if classes&GEN_QUIETS > 0 {
+		dst = p.genCastling(dst)
+	}
+	return dst
 }
 
 // Generate all legal moves
@@ -52,26 +78,26 @@ func (g *Game) GenerateLegalMoves() {
 }
 
 // Generates King & Knight pseudo-legal moves
-func (g *Game) genFromMoves(pieces, ours Bitboard, attackFrom []Bitboard) {
+func (p *Position) genFromMoves(dst []Move, pieces, allowed Bitboard, attackFrom []Bitboard) []Move {
 	for pieces > 0 {
 		from := pieces.popLSB()
-		targets := attackFrom[from] & ^ours
+		targets := attackFrom[from] & allowed
 		for targets > 0 {
 			to := targets.popLSB()
-			g.PseudoMoves = append(g.PseudoMoves, NewMove(Square(from), Square(to), g.Squares[to]))
+			dst = append(dst, NewMove(Square(from), Square(to), p.Squares[to]))
 		}
 	}
-
+	return dst
 }
 
 // Generate sliding-piece's pseudo-legal moves
-func (g *Game) genRayMoves(pieces, ours Bitboard, directions []Direction) {
+func (p *Position) genRayMoves(dst []Move, pieces, allowed Bitboard, directions []Direction) []Move {
 	for pieces > 0 {
 		from := pieces.popLSB()
 		var allTargets, targets Bitboard
 		for _, direction := range directions {
 			targets = RAY_MASKS[direction][from]
-			blockers := targets & g.Occupied
+			blockers := targets & p.Occupied
 			if blockers > 0 {
 				if DIRECTION_LSB_MSP[direction] == LSB {
 					targets ^= RAY_MASKS[direction][blockers.lsbIndex()]
@@ -79,163 +105,171 @@ func (g *Game) genRayMoves(pieces, ours Bitboard, directions []Direction) {
 					targets ^= RAY_MASKS[direction][blockers.msbIndex()]
 				}
 			}
-			allTargets |= targets & (^ours)
+			allTargets |= targets & allowed
 		}
 		for allTargets > 0 {
 			to := allTargets.popLSB()
-			g.PseudoMoves = append(g.PseudoMoves, NewMove(Square(from), Square(to), g.Squares[to]))
+			dst = append(dst, NewMove(Square(from), Square(to), p.Squares[to]))
 		}
 	}
+	return dst
 }
 
 // Generate castling pseudo-legal moves
-func (g *Game) genCastling() {
-	if g.Turn == WHITE && (g.Castling&CASTLE_WKS) > 0 && (g.Occupied&(0x3<<61)) == 0 {
-		from := Square(g.Whites[KING].lsbIndex())
+func (p *Position) genCastling(dst []Move) []Move {
+	if p.Turn == WHITE && (p.Castling&CASTLE_WKS) > 0 && (p.Occupied&(0x3<<61)) == 0 {
+		from := Square(p.Whites[KING].lsbIndex())
 		to := Square(WKS_KING_TO_SQUARE)
-		g.PseudoMoves = append(g.PseudoMoves, NewCastlingMove(from, to))
+		dst = append(dst, NewCastlingMove(from, to))
 
 	}
 
-	if g.Turn == WHITE && (g.Castling&CASTLE_WQS) > 0 && (g.Occupied&(0x7<<57)) == 0 {
-		from := Square(g.Whites[KING].lsbIndex())
+	if p.Turn == WHITE && (p.Castling&CASTLE_WQS) > 0 && (p.Occupied&(0x7<<57)) == 0 {
+		from := Square(p.Whites[KING].lsbIndex())
 		to := Square(WQS_KING_TO_SQUARE)
-		g.PseudoMoves = append(g.PseudoMoves, NewCastlingMove(from, to))
+		dst = append(dst, NewCastlingMove(from, to))
 	}
 
-	if g.Turn == BLACK && (g.Castling&CASTLE_BKS) > 0 && (g.Occupied&(0x3<<5)) == 0 {
-		from := Square(g.Blacks[KING].lsbIndex())
+	if p.Turn == BLACK && (p.Castling&CASTLE_BKS) > 0 && (p.Occupied&(0x3<<5)) == 0 {
+		from := Square(p.Blacks[KING].lsbIndex())
 		to := Square(BKS_KING_TO_SQUARE)
-		g.PseudoMoves = append(g.PseudoMoves, NewCastlingMove(from, to))
+		dst = append(dst, NewCastlingMove(from, to))
 	}
 
-	if g.Turn == BLACK && (g.Castling&CASTLE_BQS) > 0 && (g.Occupied&(0x7<<1)) == 0 {
-		from := Square(g.Blacks[KING].lsbIndex())
+	if p.Turn == BLACK && (p.Castling&CASTLE_BQS) > 0 && (p.Occupied&(0x7<<1)) == 0 {
+		from := Square(p.Blacks[KING].lsbIndex())
 		to := Square(BQS_KING_TO_SQUARE)
-		g.PseudoMoves = append(g.PseudoMoves, NewCastlingMove(from, to))
+		dst = append(dst, NewCastlingMove(from, to))
 	}
-	return
+	return dst
 }
 
-// Generate Pawn-one-step-forward pseudo-legal moves
-func (g *Game) genPawnOneStep() {
+// Generate Pawn-one-step-forward pseudo-legal moves. Promotions to a queen
+// belong to GEN_CAPTURES, the rest to GEN_QUIETS.
+func (p *Position) genPawnOneStep(dst []Move, classes int) []Move {
 	var targets Bitboard
 	var shift int = 8
-	if g.Turn == WHITE {
-		targets = (g.Whites[PAWN] >> 8) & ^g.Occupied
+	if p.Turn == WHITE {
+		targets = (p.Whites[PAWN] >> 8) & ^p.Occupied
 	} else {
-		targets = (g.Blacks[PAWN] << 8) & ^g.Occupied
+		targets = (p.Blacks[PAWN] << 8) & ^p.Occupied
 		shift = -8
 	}
 	for targets > 0 {
 		to := Square(targets.popLSB())
 		from := Square(int(to) + shift)
-		if g.IsToPromotionRank(to) {
-			g.PseudoMoves = append(g.PseudoMoves, NewPromotionMove(from, to, g.Squares[to], QUEEN))
-			g.PseudoMoves = append(g.PseudoMoves, NewPromotionMove(from, to, g.Squares[to], ROOK))
-			g.PseudoMoves = append(g.PseudoMoves, NewPromotionMove(from, to, g.Squares[to], KNIGHT))
-			g.PseudoMoves = append(g.PseudoMoves, NewPromotionMove(from, to, g.Squares[to], BISHOP))
-		} else {
-			g.PseudoMoves = append(g.PseudoMoves, NewMove(from, to, g.Squares[to]))
+		if p.IsToPromotionRank(to) {
+			if classes&GEN_CAPTURES > 0 {
+				dst = append(dst, NewPromotionMove(from, to, p.Squares[to], QUEEN))
+			}
+			if classes&GEN_QUIETS > 0 {
+				dst = append(dst, NewPromotionMove(from, to, p.Squares[to], ROOK))
+				dst = append(dst, NewPromotionMove(from, to, p.Squares[to], KNIGHT))
+				dst = append(dst, NewPromotionMove(from, to, p.Squares[to], BISHOP))
+			}
+		} else if classes&GEN_QUIETS > 0 {
+			dst = append(dst, NewMove(from, to, p.Squares[to]))
 		}
 	}
+	return dst
 }
 
 // Generate Pawn-two-step-forward pseudo-legal moves
-func (g *Game) genPawnTwoSteps() {
+func (p *Position) genPawnTwoSteps(dst []Move) []Move {
 	var targets Bitboard
 	var shift int
-	if g.Turn == WHITE {
-		rank3filtered := ((g.Whites[PAWN] & Bitboard(RANK2_MASK)) >> 8) &^ g.Occupied
-		targets = ((rank3filtered & Bitboard(RANK3_MASK)) >> 8) &^ g.Occupied
+	if p.Turn == WHITE {
+		rank3filtered := ((p.Whites[PAWN] & Bitboard(RANK2_MASK)) >> 8) &^ p.Occupied
+		targets = ((rank3filtered & Bitboard(RANK3_MASK)) >> 8) &^ p.Occupied
 		shift = 16
 	} else {
-		rank6filtered := ((g.Blacks[PAWN] & Bitboard(RANK7_MASK)) << 8) &^ g.Occupied
-		targets = ((rank6filtered & Bitboard(RANK6_MASK)) << 8) &^ g.Occupied
+		rank6filtered := ((p.Blacks[PAWN] & Bitboard(RANK7_MASK)) << 8) &^ p.Occupied
+		targets = ((rank6filtered & Bitboard(RANK6_MASK)) << 8) &^ p.Occupied
 		shift = -16
 	}
 	for targets > 0 {
 		to := targets.popLSB()
 		from := int(to) + shift
-		g.PseudoMoves = append(g.PseudoMoves, NewMove(Square(from), Square(to), g.Squares[to]))
+		dst = append(dst, NewMove(Square(from), Square(to), p.Squares[to]))
 	}
+	return dst
 }
 
 // Generate pawns left and right attacks
-func (g *Game) genPawnAttacks() {
-	ours, _ := g.GetPawns()
+func (p *Position) genPawnAttacks(dst []Move) []Move {
+	ours, _ := p.GetPawns()
 	var targets Bitboard
 	enPassant := Bitboard(0)
-	if g.EnPassant > 0 {
-		enPassant = Bitboard(0x1 << uint(g.EnPassant))
+	if p.EnPassant > 0 {
+		enPassant = Bitboard(0x1 << uint(p.EnPassant))
 	}
 	for _, shift := range [2]int{7, 9} {
-		if g.Turn == WHITE {
+		if p.Turn == WHITE {
 			if shift == 7 {
 				targets = (ours & ^Bitboard(FILE_H_MASK)) >> uint(shift)
 			} else {
 				targets = (ours & ^Bitboard(FILE_A_MASK)) >> uint(shift)
 			}
-			targets &= (g.BlackPieces | enPassant)
+			targets &= (p.BlackPieces | enPassant)
 		} else {
 			if shift == 7 {
 				targets = (ours & ^Bitboard(FILE_A_MASK)) << uint(shift)
 			} else {
 				targets = (ours & ^Bitboard(FILE_H_MASK)) << uint(shift)
 			}
-			targets &= (g.WhitePieces | enPassant)
+			targets &= (p.WhitePieces | enPassant)
 		}
 		for targets > 0 {
 			to := Square(targets.popLSB())
 			fromShift := shift
-			if g.Turn == BLACK {
+			if p.Turn == BLACK {
 				fromShift *= -1
 			}
 			from := Square(int(to) + fromShift)
-			if g.EnPassant > 0 && to == g.EnPassant {
+			if p.EnPassant > 0 && to == p.EnPassant {
 				var capturedPiece Piece
-				if g.Turn == WHITE {
-					capturedPiece = g.Squares[to+8]
+				if p.Turn == WHITE {
+					capturedPiece = p.Squares[to+8]
 				} else {
-					capturedPiece = g.Squares[to-8]
+					capturedPiece = p.Squares[to-8]
 				}
-				g.PseudoMoves = append(g.PseudoMoves, NewEnPassantMove(from, to, capturedPiece))
-			} else if g.IsToPromotionRank(to) {
-				g.PseudoMoves = append(g.PseudoMoves, NewPromotionMove(from, to, g.Squares[to], QUEEN))
-				g.PseudoMoves = append(g.PseudoMoves, NewPromotionMove(from, to, g.Squares[to], ROOK))
-				g.PseudoMoves = append(g.PseudoMoves, NewPromotionMove(from, to, g.Squares[to], KNIGHT))
-				g.PseudoMoves = append(g.PseudoMoves, NewPromotionMove(from, to, g.Squares[to], BISHOP))
+				dst = append(dst, NewEnPassantMove(from, to, capturedPiece))
+			} else if p.IsToPromotionRank(to) {
+				dst = append(dst, NewPromotionMove(from, to, p.Squares[to], QUEEN))
+				dst = append(dst, NewPromotionMove(from, to, p.Squares[to], ROOK))
+				dst = append(dst, NewPromotionMove(from, to, p.Squares[to], KNIGHT))
+				dst = append(dst, NewPromotionMove(from, to, p.Squares[to], BISHOP))
 			} else {
-				g.PseudoMoves = append(g.PseudoMoves, NewMove(from, to, g.Squares[to]))
+				dst = append(dst, NewMove(from, to, p.Squares[to]))
 			}
 		}
 	}
+	return dst
 }
 
-func (g *Game) IsToPromotionRank(to Square) bool {
-	return (g.Turn == WHITE && (Bitboard(0x1<<uint(to))&Bitboard(RANK8_MASK) > 0)) || (g.Turn == BLACK && (Bitboard(0x1<<uint(to))&Bitboard(RANK1_MASK) > 0))
+func (p *Position) IsToPromotionRank(to Square) bool {
+	return (p.Turn == WHITE && (Bitboard(0x1<<uint(to))&Bitboard(RANK8_MASK) > 0)) || (p.Turn == BLACK && (Bitboard(0x1<<uint(to))&Bitboard(RANK1_MASK) > 0))
 }
 
 // Checks whether our king is in check or not
-func (g *Game) ComputeIsCheck() bool {
-	genMovesCalls++
+func (p *Position) ComputeIsCheck() bool {
 	var kingBB, theirsAll, attackers Bitboard
 	var theirs []Bitboard
-	if g.Turn == WHITE {
-		kingBB, theirs, theirsAll = g.Whites[KING], g.Blacks[:], g.BlackPieces
+	if p.Turn == WHITE {
+		kingBB, theirs, theirsAll = p.Whites[KING], p.Blacks[:], p.BlackPieces
 	} else {
-		kingBB, theirs, theirsAll = g.Blacks[KING], g.Whites[:], g.WhitePieces
+		kingBB, theirs, theirsAll = p.Blacks[KING], p.Whites[:], p.WhitePieces
 	}
 	kingIdx := kingBB.lsbIndex()
 	possibleAttackers := theirsAll & ATTACKS_TO[kingIdx]
 
 	attackers = (theirs[ROOK] | theirs[QUEEN]) & possibleAttackers
-	if attackers > 0 && g.isCheckedFromRay(kingBB, attackers, ROOK_DIRECTIONS[:]) {
+	if attackers > 0 && p.isCheckedFromRay(kingBB, attackers, ROOK_DIRECTIONS[:]) {
 		return true
 	}
 
 	attackers = (theirs[BISHOP] | theirs[QUEEN]) & possibleAttackers
-	if attackers > 0 && g.isCheckedFromRay(kingBB, attackers, BISHOP_DIRECTIONS[:]) {
+	if attackers > 0 && p.isCheckedFromRay(kingBB, attackers, BISHOP_DIRECTIONS[:]) {
 		return true
 	}
 
@@ -247,16 +281,16 @@ func (g *Game) ComputeIsCheck() bool {
 		}
 	}
 
-	if g.Turn == WHITE {
+	if p.Turn == WHITE {
 		// Black pawns attack “down” the board (towards higher square indices).
-		if ((g.Blacks[PAWN]&^Bitboard(FILE_A_MASK))<<7)&kingBB > 0 ||
-			((g.Blacks[PAWN]&^Bitboard(FILE_H_MASK))<<9)&kingBB > 0 {
+		if ((p.Blacks[PAWN]&^Bitboard(FILE_A_MASK))<<7)&kingBB > 0 ||
+			((p.Blacks[PAWN]&^Bitboard(FILE_H_MASK))<<9)&kingBB > 0 {
 			return true
 		}
 	} else {
 		// White pawns attack “up” the board (towards lower square indices).
-		if ((g.Whites[PAWN]&^Bitboard(FILE_H_MASK))>>7)&kingBB > 0 ||
-			((g.Whites[PAWN]&^Bitboard(FILE_A_MASK))>>9)&kingBB > 0 {
+		if ((p.Whites[PAWN]&^Bitboard(FILE_H_MASK))>>7)&kingBB > 0 ||
+			((p.Whites[PAWN]&^Bitboard(FILE_A_MASK))>>9)&kingBB > 0 {
 			return true
 		}
 	}
@@ -272,14 +306,14 @@ func (g *Game) ComputeIsCheck() bool {
 }
 
 // checks whether target is attacked by one of the "attackers"
-func (g *Game) isCheckedFromRay(target, attackers Bitboard, directions []Direction) bool {
+func (p *Position) isCheckedFromRay(target, attackers Bitboard, directions []Direction) bool {
 	var targets Bitboard
 	var from uint
 	for attackers > 0 {
 		from = attackers.popLSB()
 		for _, direction := range directions {
 			targets = RAY_MASKS[direction][from]
-			blockers := targets & g.Occupied
+			blockers := targets & p.Occupied
 			if blockers > 0 {
 				if DIRECTION_LSB_MSP[direction] == LSB {
 					targets ^= RAY_MASKS[direction][blockers.lsbIndex()]
@@ -297,30 +331,30 @@ func (g *Game) isCheckedFromRay(target, attackers Bitboard, directions []Directi
 
 // Checks whether the given move is possible or not
 func (g *Game) CanMove(m Move) bool {
-	if m.IsCastlingMove() {
-		var inBetweenSq Square
-		if m.To() == WKS_KING_TO_SQUARE || m.To() == BKS_KING_TO_SQUARE {
-			inBetweenSq = m.From() + 1
-		} else if m.To() == WQS_KING_TO_SQUARE || m.To() == BQS_KING_TO_SQUARE {
-			inBetweenSq = m.From() - 1
-		}
-		inBetweenMove := NewMove(m.From(), inBetweenSq, EMPTY)
-		if g.IsCheck || g.WillMoveCauseCheck(inBetweenMove) {
-			return false
-		}
-	}
-	return !g.WillMoveCauseCheck(m)
+	return g.canMove(m, g.IsCheck)
 }
 
-func (g *Game) WillMoveCauseCheck(m Move) bool {
+func (p *Position) WillMoveCauseCheck(m Move) bool {
 	// Optimization: Stack-copy the board. accessing underlying arrays by value.
 	// Since justMove/ComputeIsCheck don't modify maps/slices (only arrays/primitives), this is safe and allocation-free.
-	clone := *g
+	clone := *p
 	clone.justMove(m)
 	return clone.ComputeIsCheck()
 }
 
 func (g *Game) MakeMove(m Move) {
+	g.makeMove(m, 0)
+}
+
+// Plays m, reporting flags along with the ones of the move to observers
+func (g *Game) makeMove(m Move, flags MoveFlags) {
+	var before eventState
+	var san string
+	if g.hasObservers() {
+		before = g.eventStateBefore()
+		san = g.GetMoveSan(m)
+	}
+
 	// Capture state for UndoMove
 	capturedPiece := g.Squares[m.To()]
 	if m.IsEnPassant() {
@@ -331,6 +365,7 @@ func (g *Game) MakeMove(m Move) {
 		}
 	}
 	g.History = append(g.History, GameState{
+		Move:          m,
 		CapturedPiece: capturedPiece,
 		Castling:      g.Castling,
 		EnPassant:     g.EnPassant,
@@ -338,131 +373,76 @@ func (g *Game) MakeMove(m Move) {
 		ZobristHash:   g.ZobristHash,
 	})
 
-	if g.ShouldResetPly(m) {
-		g.HalfMoves = 0
-	} else {
-		g.HalfMoves++
-	}
-	if g.ShouldIncFullMoves(m) {
-		g.FullMoves++
-	}
-
-	g.justMove(m)
-	kind := g.Squares[m.To()].Kind()
-	if kind == KING {
-		if g.Turn == WHITE {
-			g.Castling &= ^(CASTLE_WKS | CASTLE_WQS)
-		} else {
-			g.Castling &= ^(CASTLE_BKS | CASTLE_BQS)
-		}
-	}
-	if kind == ROOK {
-		switch m.From() {
-		case WKS_ROOK_ORIGINAL_SQUARE:
-			g.Castling &= ^CASTLE_WKS
-		case WQS_ROOK_ORIGINAL_SQUARE:
-			g.Castling &= ^CASTLE_WQS
-		case BKS_ROOK_ORIGINAL_SQUARE:
-			g.Castling &= ^CASTLE_BKS
-		case BQS_ROOK_ORIGINAL_SQUARE:
-			g.Castling &= ^CASTLE_BQS
-		}
-	}
-
-	switch m.To() {
-	case WKS_ROOK_ORIGINAL_SQUARE:
-		g.Castling &= ^CASTLE_WKS
-	case WQS_ROOK_ORIGINAL_SQUARE:
-		g.Castling &= ^CASTLE_WQS
-	case BKS_ROOK_ORIGINAL_SQUARE:
-		g.Castling &= ^CASTLE_BKS
-	case BQS_ROOK_ORIGINAL_SQUARE:
-		g.Castling &= ^CASTLE_BQS
-	}
-	// enPassant target
-	g.EnPassant = 0
-	if kind == PAWN && g.Turn == WHITE {
-		if m.From().Rank() == 6 && m.To().Rank() == 4 {
-			g.EnPassant = m.From() - 8
-		}
-	}
-	if kind == PAWN && g.Turn == BLACK {
-		if m.From().Rank() == 1 && m.To().Rank() == 3 {
-			g.EnPassant = m.From() + 8
-		}
-	}
+	g.applyMove(m)
+	g.recordPosition()
 
-	if g.Turn == WHITE {
-		g.Turn = BLACK
+	// Replaying the next move taken back keeps the others for Redo
+	if n := len(g.redo); n > 0 && g.redo[n-1] == m {
+		g.redo = g.redo[:n-1]
 	} else {
-		g.Turn = WHITE
+		g.redo = g.redo[:0]
 	}
 
-	g.recordPosition()
+	g.refreshStatus()
 
-	g.GenerateLegalMoves()
+	if g.hasObservers() {
+		g.emitMoveEvents(EVENT_MOVE_PLAYED, m, san, capturedPiece, flags, before)
+	}
 
-	g.IsCheck = g.ComputeIsCheck()
-	g.IsCheckmate = g.IsCheck && !g.hasMoves()
-	g.IsStalement = !g.IsCheckmate && !g.hasMoves()
-	g.IsMaterialDraw = g.hasInsufficientMaterial()
-	g.IsThreefoldRepetition = g.checkThreefoldRepetition()
-	g.IsFiftyMoveRule = g.checkFiftyMoveRule()
-	g.IsSeventyFiveMoveRule = g.checkSeventyFiveMoveRule()
-	g.IsFinished = (g.IsCheckmate || g.IsStalement || g.IsMaterialDraw || g.IsFivefoldRepetition() || g.IsSeventyFiveMoveRule)
+	g.runPremove()
 }
 
-func (g *Game) justMove(m Move) {
+func (p *Position) justMove(m Move) {
 	from := m.From()
 	to := m.To()
 
 	//capturedPiece := m.captured()
-	capturedPiece := g.Squares[to]
-	if m.IsEnPassant() && g.Turn == WHITE {
-		capturedPiece = g.Squares[to+8]
-	} else if m.IsEnPassant() && g.Turn == BLACK {
-		capturedPiece = g.Squares[to-8]
+	capturedPiece := p.Squares[to]
+	if m.IsEnPassant() && p.Turn == WHITE {
+		capturedPiece = p.Squares[to+8]
+	} else if m.IsEnPassant() && p.Turn == BLACK {
+		capturedPiece = p.Squares[to-8]
 	}
 	fromBBNeg := ^Bitboard(0x1 << from)
 	toBB := Bitboard(0x1 << to)
-	movingPiece := g.Squares[from]
+	movingPiece := p.Squares[from]
 	movingPieceKind := movingPiece.Kind()
 	switch movingPiece.Color() {
 	case WHITE:
 		// update bitmap of moving piece kind, unset bit of source square
-		g.Whites[movingPieceKind] &= fromBBNeg
+		p.Whites[movingPieceKind] &= fromBBNeg
 		// update bitmap of moving piece kind, set bit of source square
-		g.Whites[movingPieceKind] |= toBB
+		p.Whites[movingPieceKind] |= toBB
 		// update white pieces bitboard - unset old square
-		g.WhitePieces &= fromBBNeg
+		p.WhitePieces &= fromBBNeg
 		// update white pieces bitboard - set new square
-		g.WhitePieces |= toBB
+		p.WhitePieces |= toBB
 	case BLACK:
-		g.Blacks[movingPieceKind] &= fromBBNeg
-		g.Blacks[movingPieceKind] |= toBB
-		g.BlackPieces &= fromBBNeg
-		g.BlackPieces |= toBB
+		p.Blacks[movingPieceKind] &= fromBBNeg
+		p.Blacks[movingPieceKind] |= toBB
+		p.BlackPieces &= fromBBNeg
+		p.BlackPieces |= toBB
 	}
 
-	g.Occupied &= fromBBNeg
-	g.Occupied |= toBB
+	p.Occupied &= fromBBNeg
+	p.Occupied |= toBB
 
-	g.Squares[m.To()] = g.Squares[m.From()]
-	g.Squares[m.From()] = EMPTY
+	p.Squares[m.To()] = p.Squares[m.From()]
+	p.Squares[m.From()] = EMPTY
 	if capturedPiece != EMPTY {
 		if !m.IsEnPassant() {
-			g.capturePiece(to, capturedPiece)
+			p.capturePiece(to, capturedPiece)
 		} else {
-			if g.Turn == WHITE {
+			if p.Turn == WHITE {
 				capSq := to + 8
-				g.capturePiece(capSq, g.Squares[capSq])
-				g.Occupied &= ^Bitboard(0x1 << capSq)
-				g.Squares[to+8] = EMPTY
+				p.capturePiece(capSq, p.Squares[capSq])
+				p.Occupied &= ^Bitboard(0x1 << capSq)
+				p.Squares[to+8] = EMPTY
 			} else {
 				capSq := to - 8
-				g.capturePiece(capSq, g.Squares[capSq])
-				g.Occupied &= ^Bitboard(0x1 << capSq)
-				g.Squares[to-8] = EMPTY
+				p.capturePiece(capSq, p.Squares[capSq])
+				p.Occupied &= ^Bitboard(0x1 << capSq)
+				p.Squares[to-8] = EMPTY
 			}
 		}
 	}
@@ -473,30 +453,30 @@ func (g *Game) justMove(m Move) {
 		} else if m.To() == WQS_KING_TO_SQUARE || m.To() == BQS_KING_TO_SQUARE {
 			rookMove = NewMove(m.To()-2, m.To()+1, 0)
 		}
-		g.justMove(rookMove)
+		p.justMove(rookMove)
 	}
 	var promoteTo Piece = m.GetPromotionTo()
 	if promoteTo > 0 {
-		switch g.Squares[to].Color() {
+		switch p.Squares[to].Color() {
 		case WHITE:
 			// remove advanced pawn from boards
-			g.Whites[PAWN] &= ^toBB
+			p.Whites[PAWN] &= ^toBB
 			// add promotePiece to board
-			g.Whites[promoteTo] |= toBB
-			g.WhitePieces |= toBB
+			p.Whites[promoteTo] |= toBB
+			p.WhitePieces |= toBB
 		case BLACK:
 			// remove advanced pawn from boards
-			g.Blacks[PAWN] &= ^toBB
+			p.Blacks[PAWN] &= ^toBB
 			// add promotePiece to board
-			g.Blacks[promoteTo] |= toBB
-			g.BlackPieces |= toBB
+			p.Blacks[promoteTo] |= toBB
+			p.BlackPieces |= toBB
 		}
-		g.Squares[m.To()] = Piece(uint(promoteTo) | uint(g.Turn))
+		p.Squares[m.To()] = Piece(uint(promoteTo) | uint(p.Turn))
 	}
 }
 
 // Remove captured piece from opponent's pieces
-func (g *Game) capturePiece(sq Square, captured Piece) {
+func (p *Position) capturePiece(sq Square, captured Piece) {
 	if captured == EMPTY {
 		return
 	}
@@ -504,41 +484,41 @@ func (g *Game) capturePiece(sq Square, captured Piece) {
 	kind := captured.Kind()
 	switch captured.Color() {
 	case WHITE:
-		g.Whites[kind] &= ^sqBB
-		g.WhitePieces &= ^sqBB
+		p.Whites[kind] &= ^sqBB
+		p.WhitePieces &= ^sqBB
 	case BLACK:
-		g.Blacks[kind] &= ^sqBB
-		g.BlackPieces &= ^sqBB
+		p.Blacks[kind] &= ^sqBB
+		p.BlackPieces &= ^sqBB
 	}
 }
 
-func (g *Game) hasInsufficientMaterial() bool {
-	if g.Whites[QUEEN] > 0 || g.Whites[ROOK] > 0 || g.Whites[PAWN] > 0 {
+func (p *Position) hasInsufficientMaterial() bool {
+	if p.Whites[QUEEN] > 0 || p.Whites[ROOK] > 0 || p.Whites[PAWN] > 0 {
 		return false
 	}
-	if g.Blacks[QUEEN] > 0 || g.Blacks[ROOK] > 0 || g.Blacks[PAWN] > 0 {
+	if p.Blacks[QUEEN] > 0 || p.Blacks[ROOK] > 0 || p.Blacks[PAWN] > 0 {
 		return false
 	}
-	if g.Whites[KNIGHT] > 0 && g.Whites[BISHOP] > 0 {
+	if p.Whites[KNIGHT] > 0 && p.Whites[BISHOP] > 0 {
 		return false
 	}
-	if g.Blacks[KNIGHT] > 0 && g.Blacks[BISHOP] > 0 {
+	if p.Blacks[KNIGHT] > 0 && p.Blacks[BISHOP] > 0 {
 		return false
 	}
 
-	if g.Whites[BISHOP].NumberOfSetBits() > 1 {
+	if p.Whites[BISHOP].NumberOfSetBits() > 1 {
 		return false
 	}
 
-	if g.Blacks[BISHOP].NumberOfSetBits() > 1 {
+	if p.Blacks[BISHOP].NumberOfSetBits() > 1 {
 		return false
 	}
 
-	if g.Whites[KNIGHT].NumberOfSetBits() > 1 {
+	if p.Whites[KNIGHT].NumberOfSetBits() > 1 {
 		return false
 	}
 
-	if g.Blacks[KNIGHT].NumberOfSetBits() > 1 {
+	if p.Blacks[KNIGHT].NumberOfSetBits() > 1 {
 		return false
 	}
 
@@ -558,13 +538,15 @@ func (g *Game) hasInsufficientMaterial() bool {
 func (g *Game) GetMoveSan(m Move) string {
 	pgn := g.GetMoveSanWithoutSuffix(m)
 
-	g.MakeMove(m)
-	if g.IsCheckmate {
-		pgn += "#"
-	} else if g.IsCheck {
-		pgn += "+"
+	// Look ahead on a copy of the position so the game itself is not touched
+	next := g.Play(m)
+	if next.InCheck() {
+		if len(next.LegalMoves()) == 0 {
+			pgn += "#"
+		} else {
+			pgn += "+"
+		}
 	}
-	g.UndoMove(m)
 
 	return pgn
 }
@@ -591,11 +573,11 @@ func (g *Game) GetMoveSanWithoutSuffix(m Move) string {
 
 		// Disambiguation:
 		othersOfSameKind, onSameFileCount, onSameRankCount := g.GetOthersOfSameKindMovingToSameTargetCounts(m)
-		if othersOfSameKind > 0 {
+		if othersOfSameKind > 0 && movingKind != PAWN {
 			if onSameFileCount == 0 {
 				sb.WriteString(m.From().FileLetter()) // -------> 1.1
 			} else if onSameRankCount == 0 {
-				sb.WriteString(m.From().FileLetter()) // -------> 1.2
+				sb.WriteString(m.From().Coords()[1:]) // -------> 1.2
 			} else {
 				sb.WriteString(m.From().Coords()) // -------> 1.2
 			}
@@ -613,24 +595,26 @@ func (g *Game) GetMoveSanWithoutSuffix(m Move) string {
 
 		if m.IsPromotionMove() {
 			sb.WriteString("=")
-			sb.WriteString(strings.ToUpper(string(m.GetPromotionTo()))) // -------> 5.
+			sb.WriteRune(Piece(uint(m.GetPromotionTo()) | WHITE).ToRune()) // -------> 5.
 		}
 	}
 	return sb.String()
 }
 
 func (g *Game) GetOthersOfSameKindMovingToSameTargetCounts(themove Move) (otherOfSameKind int, onSameFileCount int, onSameRankCount int) {
-	movingPiece := g.Squares[themove.From()]
+	from := themove.From()
+	movingPiece := g.Squares[from]
 	to := themove.To()
 	for _, m := range g.LegalMoves {
-		if m == themove || m.To() != to || g.Squares[m.From()].Kind() != movingPiece.Kind() {
+		// promotions of the same pawn are told apart by the promotion piece
+		if m.From() == from || m.To() != to || g.Squares[m.From()].Kind() != movingPiece.Kind() {
 			continue
 		}
 		otherOfSameKind += 1
-		if m.From().File() == to.File() {
+		if m.From().File() == from.File() {
 			onSameFileCount += 1
 		}
-		if m.From().Rank() == to.Rank() {
+		if m.From().Rank() == from.Rank() {
 			onSameRankCount += 1
 		}
 	}
@@ -641,6 +625,11 @@ func (g *Game) UndoMove(m Move) {
 	if len(g.History) == 0 {
 		return
 	}
+	var before eventState
+	if g.hasObservers() {
+		before = g.eventStateBefore()
+	}
+
 	// Decrement history count for current position
 	if g.PositionHistory != nil {
 		g.PositionHistory[g.ZobristHash]--
@@ -668,17 +657,16 @@ func (g *Game) UndoMove(m Move) {
 	}
 
 	g.unmakeMove(m, state.CapturedPiece)
+	g.redo = append(g.redo, state.Move)
+	// Premoves were queued against the position taken back
+	g.premoves = g.premoves[:0]
 
 	// Re-calculate derived state
-	g.GenerateLegalMoves()
-	g.IsCheck = g.ComputeIsCheck()
-	g.IsCheckmate = g.IsCheck && !g.hasMoves()
-	g.IsStalement = !g.IsCheckmate && !g.hasMoves()
-	g.IsMaterialDraw = g.hasInsufficientMaterial()
-	g.IsThreefoldRepetition = g.checkThreefoldRepetition()
-	g.IsFiftyMoveRule = g.checkFiftyMoveRule()
-	g.IsSeventyFiveMoveRule = g.checkSeventyFiveMoveRule()
-	g.IsFinished = (g.IsCheckmate || g.IsStalement || g.IsMaterialDraw || g.IsFivefoldRepetition() || g.IsSeventyFiveMoveRule)
+	g.refreshStatus()
+
+	if g.hasObservers() {
+		g.emitMoveEvents(EVENT_MOVE_UNDONE, m, g.GetMoveSan(m), state.CapturedPiece, 0, before)
+	}
 }
 
 func (g *Game) unmakeMove(m Move, captured Piece) {
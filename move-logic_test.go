@@ -133,3 +133,49 @@ rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1
 	Nf3 rnbqkbnr/pppppppp/8/8/8/5N2/PPPPPPPP/RNBQKB1R b KQkq - 1 1
 	Nh3 rnbqkbnr/pppppppp/8/8/8/7N/PPPPPPPP/RNBQKB1R b KQkq - 1 1
 */
+
+func Test_GetMoveSanDoesNotChangeGame(t *testing.T) {
+	g := NewGame()
+	g.LoadFen("rnbqkbnr/ppppp2p/5p2/6p1/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 3")
+	g.GenerateLegalMoves()
+	before := CloneGame(g)
+
+	mate := NewMove(COORDS_TO_SQUARE["d1"], COORDS_TO_SQUARE["h5"], EMPTY)
+	assert.Equal(t, "Qh5#", g.GetMoveSan(mate))
+	assert.Equal(t, "e5", g.GetMoveSan(NewMove(COORDS_TO_SQUARE["e4"], COORDS_TO_SQUARE["e5"], EMPTY)))
+	assert.Equal(t, before, *g)
+
+	g.LoadFen("4k3/8/8/8/8/8/8/R3K3 w - - 0 1")
+	g.GenerateLegalMoves()
+	assert.Equal(t, "Ra8+", g.GetMoveSan(NewMove(COORDS_TO_SQUARE["a1"], COORDS_TO_SQUARE["a8"], EMPTY)))
+}
+
+func Test_GetMoveSanDisambiguation(t *testing.T) {
+	tests := []struct {
+		fen      string
+		from, to string
+		san      string
+	}{
+		{"4k3/8/8/1N6/8/8/8/1N2K3 w - - 0 1", "b1", "c3", "N1c3"},
+		{"4k3/8/8/8/8/8/4K3/R6R w - - 0 1", "a1", "d1", "Rad1"},
+		{"4k3/8/8/3p4/2P1P3/8/8/4K3 w - - 0 1", "e4", "d5", "exd5"},
+		{"7k/8/8/8/8/2Q1Q3/8/2Q1K3 w - - 0 1", "c3", "d2", "Qc3d2"},
+		{"8/4P3/8/8/8/8/k7/4K3 w - - 0 1", "e7", "e8", "e8=Q"},
+	}
+	for _, tt := range tests {
+		g := NewGame()
+		g.LoadFen(tt.fen)
+		g.GenerateLegalMoves()
+		var m Move
+		for _, legal := range g.LegalMoves {
+			if legal.From() == COORDS_TO_SQUARE[tt.from] && legal.To() == COORDS_TO_SQUARE[tt.to] {
+				m = legal
+				break
+			}
+		}
+		assert.Equal(t, tt.san, g.GetMoveSan(m), tt.fen)
+	}
+
+	g := NewGame()
+	assert.NoError(t, g.LoadPGN("[FEN \"8/4P3/8/8/8/8/k7/4K3 w - - 0 1\"]\n\n1. e8=N Kb3 2. Nd6"))
+}
@@ -0,0 +1,78 @@
+package chessongo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	E_INVALID_SAN = "e:invalid:san"
+	E_INVALID_UCI = "e:invalid:uci"
+)
+
+// Uci returns the move in UCI long algebraic notation, e.g. "e2e4" or "e7e8q"
+func (m Move) Uci() string {
+	uci := m.From().Coords() + m.To().Coords()
+	if promoteTo := m.GetPromotionTo(); promoteTo > 0 {
+		uci += string(Piece(uint(promoteTo) | BLACK).ToRune())
+	}
+	return uci
+}
+
+// ParseSan returns the legal move written as san in the current position.
+// Check, mate and annotation suffixes are ignored. g.LegalMoves must be
+// generated.
+func (g *Game) ParseSan(san string) (Move, error) {
+	san = trimSANAnnotations(strings.TrimSpace(san))
+	if san == "" {
+		return 0, fmt.Errorf(E_INVALID_SAN)
+	}
+	target := getTargetSquare(san)
+	for _, mv := range g.LegalMoves {
+		if target != -1 && int(mv.To()) != target {
+			continue
+		}
+		// Optimization: GetMoveSanWithoutSuffix avoids looking ahead for
+		// check/mate, which is expensive; the suffixes are stripped anyway.
+		if g.GetMoveSanWithoutSuffix(mv) == san {
+			return mv, nil
+		}
+	}
+	return 0, fmt.Errorf(E_INVALID_SAN)
+}
+
+// ParseUci returns the legal move written as uci in the current position.
+// g.LegalMoves must be generated.
+func (g *Game) ParseUci(uci string) (Move, error) {
+	uci = strings.ToLower(strings.TrimSpace(uci))
+	if len(uci) != 4 && len(uci) != 5 {
+		return 0, fmt.Errorf(E_INVALID_UCI)
+	}
+	from, okFrom := COORDS_TO_SQUARE[uci[0:2]]
+	to, okTo := COORDS_TO_SQUARE[uci[2:4]]
+	if !okFrom || !okTo {
+		return 0, fmt.Errorf(E_INVALID_UCI)
+	}
+	var promoteTo Piece
+	if len(uci) == 5 {
+		kind, ok := STRING_TO_KIND[uci[4:]]
+		if !ok {
+			return 0, fmt.Errorf(E_INVALID_UCI)
+		}
+		promoteTo = Piece(kind)
+	}
+	for _, mv := range g.LegalMoves {
+		if mv.From() == from && mv.To() == to && mv.GetPromotionTo() == promoteTo {
+			return mv, nil
+		}
+	}
+	return 0, fmt.Errorf(E_INVALID_UCI)
+}
+
+// ParseMove accepts a move in either UCI or SAN notation
+func (g *Game) ParseMove(move string) (Move, error) {
+	if m, err := g.ParseUci(move); err == nil {
+		return m, nil
+	}
+	return g.ParseSan(move)
+}
@@ -0,0 +1,40 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMove(t *testing.T) {
+	g := &Game{}
+	require.NoError(t, g.LoadFen("r3k3/1P6/8/8/8/8/8/R3K2R w KQq - 0 1"))
+	g.GenerateLegalMoves()
+
+	tests := []struct {
+		in, uci string
+	}{
+		{"e1g1", "e1g1"},
+		{"O-O", "e1g1"},
+		{"O-O-O+", "e1c1"},
+		{"b7a8n", "b7a8n"},
+		{"B7A8N", "b7a8n"},
+		{"bxa8=Q+!", "b7a8q"},
+		{"Rxa8+", "a1a8"},
+		{"Kf2", "e1f2"},
+	}
+	for _, tt := range tests {
+		m, err := g.ParseMove(tt.in)
+		require.NoError(t, err, tt.in)
+		require.Equal(t, tt.uci, m.Uci(), tt.in)
+	}
+
+	for _, bad := range []string{"", "e1e3", "b7b8", "b7a8k", "z9a1", "Kf3", "Nf3", "O-O-O-O"} {
+		_, err := g.ParseMove(bad)
+		require.Error(t, err, bad)
+	}
+	_, err := g.ParseUci("Kf2")
+	require.EqualError(t, err, E_INVALID_UCI)
+	_, err = g.ParseSan("e1f2")
+	require.EqualError(t, err, E_INVALID_SAN)
+}
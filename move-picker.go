@@ -0,0 +1,202 @@
+package chessongo
+
+// Stages of the MovePicker, in the order they are visited
+const (
+	PICK_HASH = iota
+	PICK_GEN_CAPTURES
+	PICK_GOOD_CAPTURES
+	PICK_KILLERS
+	PICK_GEN_QUIETS
+	PICK_QUIETS
+	PICK_BAD_CAPTURES
+	PICK_GEN_EVASIONS
+	PICK_EVASIONS
+	PICK_DONE
+)
+
+// HistoryTable scores quiet moves by from and to square, typically raised
+// whenever a quiet move causes a beta cutoff
+type HistoryTable [64][64]int
+
+func (h *HistoryTable) Add(m Move, bonus int) {
+	h[m.From()][m.To()] += bonus
+}
+
+func (h *HistoryTable) Score(m Move) int {
+	if h == nil {
+		return 0
+	}
+	return h[m.From()][m.To()]
+}
+
+// MovePicker hands out the legal moves of a position one at a time, best
+// guesses first, generating each class of moves only when it is reached:
+// the hash move, captures that do not lose material ordered by MVV-LVA, the
+// killer moves, quiet moves ordered by history, and finally the losing
+// captures. In check, the hash move is followed by the check evasions.
+//
+// The game must not be changed while the picker is in use, except for moves
+// that are undone before the next call to Next.
+type MovePicker struct {
+	g           *Game
+	hashMove    Move
+	killers     [2]Move
+	history     *HistoryTable
+	stage       int
+	killerIdx   int
+	moves       []Move
+	scores      []int
+	badCaptures []Move
+}
+
+// NewMovePicker returns a picker for the current position of g. hashMove and
+// the killers may be 0; history may be nil.
+func NewMovePicker(g *Game, hashMove Move, killers [2]Move, history *HistoryTable) *MovePicker {
+	g.IsCheck = g.ComputeIsCheck()
+	return &MovePicker{
+		g:        g,
+		hashMove: hashMove,
+		killers:  killers,
+		history:  history,
+		stage:    PICK_HASH,
+		moves:    make([]Move, 0, maxGeneratedMoves),
+		scores:   make([]int, 0, maxGeneratedMoves),
+	}
+}
+
+// Stage returns the stage the picker is currently in
+func (mp *MovePicker) Stage() int {
+	return mp.stage
+}
+
+// Orders captures by most valuable victim, then least valuable attacker
+func (mp *MovePicker) captureScore(m Move) int {
+	victim := m.GetCapturedPiece().Value()
+	if m.IsEnPassant() {
+		victim = PIECE_VALUES[PAWN]
+	}
+	if promoteTo := m.GetPromotionTo(); promoteTo > 0 {
+		victim += PIECE_VALUES[promoteTo]
+	}
+	return victim*16 - mp.g.Squares[m.From()].Value()/100
+}
+
+// Removes and returns the best scored of the remaining moves
+func (mp *MovePicker) pickBest() Move {
+	best := 0
+	for i := 1; i < len(mp.moves); i++ {
+		if mp.scores[i] > mp.scores[best] {
+			best = i
+		}
+	}
+	m := mp.moves[best]
+	last := len(mp.moves) - 1
+	mp.moves[best], mp.scores[best] = mp.moves[last], mp.scores[last]
+	mp.moves, mp.scores = mp.moves[:last], mp.scores[:last]
+	return m
+}
+
+// Generates the pseudo-legal moves of the given classes and scores them
+func (mp *MovePicker) fill(classes int, score func(Move) int) {
+	mp.moves = mp.g.generateMoves(mp.moves[:0], classes)
+	mp.scores = mp.scores[:0]
+	for _, m := range mp.moves {
+		mp.scores = append(mp.scores, score(m))
+	}
+}
+
+func (mp *MovePicker) isKiller(m Move) bool {
+	return m != 0 && (m == mp.killers[0] || m == mp.killers[1])
+}
+
+// Next returns the next legal move, or false when all moves have been handed out
+func (mp *MovePicker) Next() (Move, bool) {
+	g := mp.g
+	for {
+		switch mp.stage {
+		case PICK_HASH:
+			if g.IsCheck {
+				mp.stage = PICK_GEN_EVASIONS
+			} else {
+				mp.stage = PICK_GEN_CAPTURES
+			}
+			if g.IsLegalMove(mp.hashMove) {
+				return mp.hashMove, true
+			}
+		case PICK_GEN_CAPTURES:
+			mp.fill(GEN_CAPTURES, mp.captureScore)
+			mp.stage = PICK_GOOD_CAPTURES
+		case PICK_GOOD_CAPTURES:
+			if len(mp.moves) == 0 {
+				mp.stage = PICK_KILLERS
+				continue
+			}
+			m := mp.pickBest()
+			if m == mp.hashMove || !g.CanMove(m) {
+				continue
+			}
+			if !g.SEEGreaterOrEqual(m, 0) {
+				mp.badCaptures = append(mp.badCaptures, m)
+				continue
+			}
+			return m, true
+		case PICK_KILLERS:
+			if mp.killerIdx == len(mp.killers) {
+				mp.stage = PICK_GEN_QUIETS
+				continue
+			}
+			m := mp.killers[mp.killerIdx]
+			mp.killerIdx++
+			if m == 0 || m == mp.hashMove || isCaptureClass(m) || (mp.killerIdx == 2 && m == mp.killers[0]) {
+				continue
+			}
+			if g.IsLegalMove(m) {
+				return m, true
+			}
+		case PICK_GEN_QUIETS:
+			mp.fill(GEN_QUIETS, mp.history.Score)
+			mp.stage = PICK_QUIETS
+		case PICK_QUIETS:
+			if len(mp.moves) == 0 {
+				mp.stage = PICK_BAD_CAPTURES
+				continue
+			}
+			m := mp.pickBest()
+			if m == mp.hashMove || mp.isKiller(m) || !g.CanMove(m) {
+				continue
+			}
+			return m, true
+		case PICK_BAD_CAPTURES:
+			if len(mp.badCaptures) == 0 {
+				mp.stage = PICK_DONE
+				continue
+			}
+			m := mp.badCaptures[0]
+			mp.badCaptures = mp.badCaptures[1:]
+			return m, true
+		case PICK_GEN_EVASIONS:
+			mp.moves = g.GenerateEvasions(mp.moves[:0])
+			mp.scores = mp.scores[:0]
+			for _, m := range mp.moves {
+				if isCaptureClass(m) {
+					mp.scores = append(mp.scores, 1<<20+mp.captureScore(m))
+				} else {
+					mp.scores = append(mp.scores, mp.history.Score(m))
+				}
+			}
+			mp.stage = PICK_EVASIONS
+		case PICK_EVASIONS:
+			if len(mp.moves) == 0 {
+				mp.stage = PICK_DONE
+				continue
+			}
+			m := mp.pickBest()
+			if m == mp.hashMove {
+				continue
+			}
+			return m, true
+		default:
+			return 0, false
+		}
+	}
+}
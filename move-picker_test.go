@@ -0,0 +1,56 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func pickAll(mp *MovePicker) []Move {
+	var moves []Move
+	for m, ok := mp.Next(); ok; m, ok = mp.Next() {
+		moves = append(moves, m)
+	}
+	return moves
+}
+
+func TestMovePickerYieldsEveryLegalMoveOnce(t *testing.T) {
+	fens := append([]string{
+		"4k3/8/8/8/1b6/8/8/R3K2R w KQ - 0 1",
+		"8/8/8/2k5/3Pp3/8/8/4K3 b - d3 0 1",
+	}, stagedTestFens...)
+	for _, fen := range fens {
+		g := &Game{}
+		require.NoError(t, g.LoadFen(fen))
+		g.GenerateLegalMoves()
+		require.Equal(t, sortedMoves(g.LegalMoves), sortedMoves(pickAll(NewMovePicker(g, 0, [2]Move{}, nil))), fen)
+		mp := NewMovePicker(g, 0, [2]Move{}, nil)
+		pickAll(mp)
+		require.Equal(t, PICK_DONE, mp.Stage())
+
+		// hash move and killers must not be handed out twice
+		history := &HistoryTable{}
+		hash := g.LegalMoves[len(g.LegalMoves)-1]
+		killers := [2]Move{g.LegalMoves[0], g.LegalMoves[len(g.LegalMoves)/2]}
+		picked := pickAll(NewMovePicker(g, hash, killers, history))
+		require.Equal(t, hash, picked[0], fen)
+		require.Equal(t, sortedMoves(g.LegalMoves), sortedMoves(picked), fen)
+	}
+}
+
+func TestMovePickerOrdering(t *testing.T) {
+	g := &Game{}
+	// White can win the queen with the pawn, take a defended pawn with the
+	// queen (losing), or play quiet moves.
+	require.NoError(t, g.LoadFen("4k3/8/3p4/4p3/3q4/2P5/8/4QK2 w - - 0 1"))
+	killer := NewMove(COORDS_TO_SQUARE["f1"], COORDS_TO_SQUARE["g2"], EMPTY)
+	history := &HistoryTable{}
+	quiet := NewMove(COORDS_TO_SQUARE["e1"], COORDS_TO_SQUARE["e2"], EMPTY)
+	history.Add(quiet, 100)
+
+	picked := pickAll(NewMovePicker(g, 0, [2]Move{killer}, history))
+	require.Equal(t, NewMove(COORDS_TO_SQUARE["c3"], COORDS_TO_SQUARE["d4"], B_QUEEN), picked[0])
+	require.Equal(t, killer, picked[1])
+	require.Equal(t, quiet, picked[2])
+	require.Equal(t, NewMove(COORDS_TO_SQUARE["e1"], COORDS_TO_SQUARE["e5"], B_PAWN), picked[len(picked)-1])
+}
@@ -0,0 +1,121 @@
+package chessongo
+
+// Tells whether m is generated by GEN_CAPTURES: a capture or a promotion to a queen
+func isCaptureClass(m Move) bool {
+	return m.GetCapturedPiece() != EMPTY || m.IsEnPassant() || m.GetPromotionTo() == QUEEN
+}
+
+// Appends the legal moves among pseudo to dst
+func (g *Game) appendLegal(dst []Move, pseudo []Move) []Move {
+	for _, m := range pseudo {
+		if g.CanMove(m) {
+			dst = append(dst, m)
+		}
+	}
+	return dst
+}
+
+// GenerateCaptures appends every legal capture, en passant and capturing
+// promotions included, and every legal promotion to a queen to dst
+func (g *Game) GenerateCaptures(dst []Move) []Move {
+	var buf [maxGeneratedMoves]Move
+	g.IsCheck = g.ComputeIsCheck()
+	return g.appendLegal(dst, g.generateMoves(buf[:0], GEN_CAPTURES))
+}
+
+// GenerateQuiets appends every legal move not produced by GenerateCaptures to dst
+func (g *Game) GenerateQuiets(dst []Move) []Move {
+	var buf [maxGeneratedMoves]Move
+	g.IsCheck = g.ComputeIsCheck()
+	return g.appendLegal(dst, g.generateMoves(buf[:0], GEN_QUIETS))
+}
+
+// GenerateQuietChecks appends the legal quiet moves that give check to dst
+func (g *Game) GenerateQuietChecks(dst []Move) []Move {
+	var buf [maxGeneratedMoves]Move
+	g.IsCheck = g.ComputeIsCheck()
+	for _, m := range g.generateMoves(buf[:0], GEN_QUIETS) {
+		if g.CanMove(m) && g.GivesCheck(m) {
+			dst = append(dst, m)
+		}
+	}
+	return dst
+}
+
+// GenerateEvasions appends the legal moves that get the side to move out of
+// check to dst: king moves, and for a single checker, capturing it or
+// blocking the line. Nothing is appended when the side to move is not in
+// check.
+func (g *Game) GenerateEvasions(dst []Move) []Move {
+	us := g.Turn
+	king := Square(g.piecesOf(us)[KING].lsbIndex())
+	checkers := g.AttackersOf(king, opponentColor(us))
+	if checkers == 0 {
+		return dst
+	}
+	g.IsCheck = true
+	var block Bitboard
+	var checker Square
+	if checkers.NumberOfSetBits() == 1 {
+		checker = Square(checkers.lsbIndex())
+		block = checkers | squaresBetween(king, checker)
+	}
+	var buf [maxGeneratedMoves]Move
+	for _, m := range g.generateMoves(buf[:0], GEN_ALL) {
+		if m.IsCastlingMove() {
+			continue
+		}
+		if m.From() != king && block&(Bitboard(1)<<m.To()) == 0 {
+			// en passant may remove a checking pawn without landing on it
+			if !m.IsEnPassant() || block == 0 || (m.To()+8 != checker && m.To()-8 != checker) {
+				continue
+			}
+		}
+		if g.CanMove(m) {
+			dst = append(dst, m)
+		}
+	}
+	return dst
+}
+
+// HasLegalCapture tells whether the side to move can capture anything,
+// without generating quiet moves
+func (g *Game) HasLegalCapture() bool {
+	var buf [maxGeneratedMoves]Move
+	g.IsCheck = g.ComputeIsCheck()
+	for _, m := range g.generateMoves(buf[:0], GEN_CAPTURES) {
+		if (m.GetCapturedPiece() != EMPTY || m.IsEnPassant()) && g.CanMove(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// GivesCheck tells whether playing m puts the opponent in check
+func (g *Game) GivesCheck(m Move) bool {
+	clone := *g
+	clone.justMove(m)
+	clone.Turn = opponentColor(clone.Turn)
+	return clone.ComputeIsCheck()
+}
+
+// IsLegalMove tells whether m can be played in the current position. Unlike
+// looking m up in LegalMoves it works on positions whose legal moves have not
+// been generated.
+func (g *Game) IsLegalMove(m Move) bool {
+	if m == 0 || g.Squares[m.From()].Color() != g.Turn {
+		return false
+	}
+	classes := GEN_QUIETS
+	if isCaptureClass(m) {
+		classes = GEN_CAPTURES
+	}
+	var buf [maxGeneratedMoves]Move
+	for _, candidate := range g.generateMoves(buf[:0], classes) {
+		if candidate == m {
+			g.IsCheck = g.ComputeIsCheck()
+			return g.CanMove(m)
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package chessongo
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var stagedTestFens = []string{
+	STARTING_POSITION_FEN,
+	"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+	"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+	"rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 3",
+}
+
+func sortedMoves(moves []Move) []Move {
+	sorted := append([]Move{}, moves...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func TestStagedGeneratorsPartitionLegalMoves(t *testing.T) {
+	for _, fen := range stagedTestFens {
+		g := &Game{}
+		require.NoError(t, g.LoadFen(fen))
+		g.GenerateLegalMoves()
+
+		captures := g.GenerateCaptures(nil)
+		quiets := g.GenerateQuiets(nil)
+		for _, m := range captures {
+			require.True(t, isCaptureClass(m), fen)
+		}
+		for _, m := range quiets {
+			require.False(t, isCaptureClass(m), fen)
+		}
+		require.Equal(t, sortedMoves(g.LegalMoves), sortedMoves(append(captures, quiets...)), fen)
+
+		for _, m := range g.GenerateQuietChecks(nil) {
+			require.False(t, isCaptureClass(m), fen)
+			require.True(t, g.GivesCheck(m), fen)
+		}
+		require.Equal(t, len(captures) > 0 && hasRealCapture(captures), g.HasLegalCapture(), fen)
+	}
+}
+
+func hasRealCapture(moves []Move) bool {
+	for _, m := range moves {
+		if m.GetCapturedPiece() != EMPTY || m.IsEnPassant() {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGenerateQuietChecks(t *testing.T) {
+	g := &Game{}
+	require.NoError(t, g.LoadFen("4k3/8/8/8/8/8/8/R3K3 w - - 0 1"))
+	checks := g.GenerateQuietChecks(nil)
+	// of all rook moves only Ra8 gives check
+	require.Equal(t, []Move{NewMove(COORDS_TO_SQUARE["a1"], COORDS_TO_SQUARE["a8"], EMPTY)}, checks)
+}
+
+func TestGenerateEvasions(t *testing.T) {
+	tests := []struct {
+		fen string
+	}{
+		{"4k3/8/8/8/8/8/3q4/4K3 w - - 0 1"},
+		{"4k3/8/8/8/1b6/8/8/R3K2R w KQ - 0 1"},
+		{"4k3/8/8/8/4r3/8/5n2/4K3 w - - 0 1"},
+		{"8/8/8/2k5/3Pp3/8/8/4K3 b - d3 0 1"},
+	}
+	for _, tt := range tests {
+		g := &Game{}
+		require.NoError(t, g.LoadFen(tt.fen))
+		g.GenerateLegalMoves()
+		require.True(t, g.IsCheck, tt.fen)
+		require.Equal(t, sortedMoves(g.LegalMoves), sortedMoves(g.GenerateEvasions(nil)), tt.fen)
+	}
+
+	g := NewGame()
+	require.Empty(t, g.GenerateEvasions(nil))
+}
+
+func TestIsLegalMove(t *testing.T) {
+	g := NewGame()
+	require.True(t, g.IsLegalMove(NewMove(COORDS_TO_SQUARE["e2"], COORDS_TO_SQUARE["e4"], EMPTY)))
+	require.False(t, g.IsLegalMove(NewMove(COORDS_TO_SQUARE["e2"], COORDS_TO_SQUARE["e5"], EMPTY)))
+	require.False(t, g.IsLegalMove(NewMove(COORDS_TO_SQUARE["e7"], COORDS_TO_SQUARE["e5"], EMPTY)))
+	require.False(t, g.IsLegalMove(0))
+}
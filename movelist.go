@@ -0,0 +1,81 @@
+package chessongo
+
+// MoveList is a fixed-capacity list of moves. It is a plain value, so it can
+// live on the stack of a recursive search and be filled without allocating.
+// 256 moves is more than any legal chess position has.
+type MoveList struct {
+	moves [maxGeneratedMoves]Move
+	n     int
+}
+
+func (l *MoveList) Len() int {
+	return l.n
+}
+
+// At returns the i-th move of the list
+func (l *MoveList) At(i int) Move {
+	return l.moves[:l.n][i]
+}
+
+// Moves returns the moves of the list. The slice shares the list's storage
+// and is only valid until the list is changed.
+func (l *MoveList) Moves() []Move {
+	return l.moves[:l.n]
+}
+
+// Adds m to the end of the list
+func (l *MoveList) Push(m Move) {
+	l.moves[l.n] = m
+	l.n++
+}
+
+// Removes every move from the list
+func (l *MoveList) Clear() {
+	l.n = 0
+}
+
+func (l *MoveList) Contains(m Move) bool {
+	for _, move := range l.moves[:l.n] {
+		if move == m {
+			return true
+		}
+	}
+	return false
+}
+
+// GeneratePseudo fills list with the pseudo-legal moves of the current
+// position, replacing its previous content. g.PseudoMoves is left untouched.
+func (g *Game) GeneratePseudo(list *MoveList) {
+	list.n = len(g.generateMoves(list.moves[:0], GEN_ALL))
+}
+
+// GenerateLegal fills list with the legal moves of the current position,
+// replacing its previous content. Unlike GenerateLegalMoves it leaves
+// g.LegalMoves untouched, so the list stays valid across MakeMove and
+// UndoMove.
+func (g *Game) GenerateLegal(list *MoveList) {
+	g.IsCheck = g.ComputeIsCheck()
+	pseudo := g.generateMoves(list.moves[:0], GEN_ALL)
+	n := 0
+	for _, m := range pseudo {
+		if g.CanMove(m) {
+			list.moves[n] = m
+			n++
+		}
+	}
+	list.n = n
+}
+
+// CountLegalMoves returns the number of legal moves in the current position
+// without keeping them
+func (g *Game) CountLegalMoves() int {
+	var buf [maxGeneratedMoves]Move
+	g.IsCheck = g.ComputeIsCheck()
+	count := 0
+	for _, m := range g.generateMoves(buf[:0], GEN_ALL) {
+		if g.CanMove(m) {
+			count++
+		}
+	}
+	return count
+}
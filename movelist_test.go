@@ -0,0 +1,52 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateLegalMatchesLegalMoves(t *testing.T) {
+	for _, fen := range stagedTestFens {
+		g := &Game{}
+		require.NoError(t, g.LoadFen(fen))
+		g.GenerateLegalMoves()
+
+		var list MoveList
+		list.Push(0)
+		g.GenerateLegal(&list)
+		require.Equal(t, g.LegalMoves, list.Moves(), fen)
+		require.Equal(t, len(g.LegalMoves), list.Len(), fen)
+		require.Equal(t, len(g.LegalMoves), g.CountLegalMoves(), fen)
+		for i, m := range g.LegalMoves {
+			require.Equal(t, m, list.At(i))
+			require.True(t, list.Contains(m))
+		}
+
+		var pseudo MoveList
+		g.GeneratePseudo(&pseudo)
+		g.GeneratePseudoMoves()
+		require.Equal(t, g.PseudoMoves, pseudo.Moves(), fen)
+	}
+}
+
+func TestMoveListSurvivesMakeMove(t *testing.T) {
+	g := NewGame()
+	var list MoveList
+	g.GenerateLegal(&list)
+	before := append([]Move{}, list.Moves()...)
+	g.MakeMove(list.At(0))
+	require.Equal(t, before, list.Moves())
+
+	list.Clear()
+	require.Zero(t, list.Len())
+	require.Empty(t, list.Moves())
+}
+
+func TestGenerateLegalDoesNotAllocate(t *testing.T) {
+	g := &Game{}
+	require.NoError(t, g.LoadFen("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"))
+	var list MoveList
+	require.Zero(t, testing.AllocsPerRun(100, func() { g.GenerateLegal(&list) }))
+	require.Zero(t, testing.AllocsPerRun(100, func() { g.CountLegalMoves() }))
+}
@@ -0,0 +1,118 @@
+package chessongo
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	E_NOTHING_TO_REDO = "e:nothing-to-redo"
+	E_INVALID_PLY     = "e:invalid:ply"
+)
+
+var ErrNothingToRedo = errors.New(E_NOTHING_TO_REDO)
+
+// Moves returns the moves played since the position the game was loaded
+// from, g.Fen, in order
+func (g *Game) Moves() []Move {
+	moves := make([]Move, len(g.History))
+	for i, state := range g.History {
+		moves[i] = state.Move
+	}
+	return moves
+}
+
+// SANHistory returns the moves played since the position the game was
+// loaded from in SAN, with their check and mate suffixes. It returns no moves
+// if g.Fen cannot be loaded, which only happens to a game whose position was
+// never loaded.
+func (g *Game) SANHistory() []string {
+	san := make([]string, 0, len(g.History))
+	g.replayHistory(func(_ Move, moveSan string, _ *Game) {
+		san = append(san, moveSan)
+	})
+	return san
+}
+
+// Replays the history from g.Fen, calling visit with each move, its SAN,
+// which depends on the position the move was played in, and the replayed
+// game after the move
+func (g *Game) replayHistory(visit func(m Move, san string, after *Game)) error {
+	if len(g.History) == 0 {
+		return nil
+	}
+	replay := &Game{}
+	if err := replay.LoadFen(g.Fen); err != nil {
+		return err
+	}
+	replay.GenerateLegalMoves()
+	for _, state := range g.History {
+		san := replay.GetMoveSan(state.Move)
+		replay.MakeMove(state.Move)
+		visit(state.Move, san, replay)
+	}
+	return nil
+}
+
+// Ply returns the number of moves played since the position the game was
+// loaded from
+func (g *Game) Ply() int {
+	return len(g.History)
+}
+
+// Undo takes back the last move, which Redo can play again until a different
+// move is played
+func (g *Game) Undo() (Move, error) {
+	if len(g.History) == 0 {
+		return 0, ErrNothingToUndo
+	}
+	m := g.History[len(g.History)-1].Move
+	g.UndoMove(m)
+	return m, nil
+}
+
+// Redo plays again the last move taken back
+func (g *Game) Redo() (Move, error) {
+	if len(g.redo) == 0 {
+		return 0, ErrNothingToRedo
+	}
+	m := g.redo[len(g.redo)-1]
+	g.MakeMove(m)
+	return m, nil
+}
+
+// RedoMoves returns the moves Redo would play again, the next one first
+func (g *Game) RedoMoves() []Move {
+	moves := make([]Move, len(g.redo))
+	for i, m := range g.redo {
+		moves[len(g.redo)-1-i] = m
+	}
+	return moves
+}
+
+// GoToPly takes back or plays again moves until ply moves have been played
+// since the position the game was loaded from. ply ranges from 0 to the
+// number of moves played plus the number of moves that can be redone.
+func (g *Game) GoToPly(ply int) error {
+	if ply < 0 || ply > len(g.History)+len(g.redo) {
+		return fmt.Errorf(E_INVALID_PLY)
+	}
+	for len(g.History) > ply {
+		g.Undo()
+	}
+	for len(g.History) < ply {
+		g.Redo()
+	}
+	return nil
+}
+
+// GoToStart takes back every move, back to the position the game was loaded
+// from
+func (g *Game) GoToStart() {
+	g.GoToPly(0)
+}
+
+// GoToEnd plays again every move taken back
+func (g *Game) GoToEnd() {
+	g.GoToPly(len(g.History) + len(g.redo))
+}
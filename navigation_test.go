@@ -0,0 +1,139 @@
+package chessongo
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMovesAndSANHistory(t *testing.T) {
+	g, err := LoadPGNGame("1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7#")
+	require.NoError(t, err)
+	require.Equal(t, []string{"e4", "e5", "Qh5", "Nc6", "Bc4", "Nf6", "Qxf7#"}, g.SANHistory())
+	moves := g.Moves()
+	require.Len(t, moves, 7)
+	require.Equal(t, "d1h5", moves[2].Uci())
+	require.Equal(t, 7, g.Ply())
+
+	g = NewGame()
+	require.Empty(t, g.Moves())
+	require.Empty(t, g.SANHistory())
+}
+
+func TestUndoRedo(t *testing.T) {
+	g, err := LoadPGNGame("1. e4 e5 2. Nf3 Nc6")
+	require.NoError(t, err)
+	fens := []string{}
+	replay := &Game{}
+	require.NoError(t, replay.LoadFen(STARTING_POSITION_FEN))
+	fens = append(fens, replay.ToFen())
+	for _, m := range g.Moves() {
+		replay.MakeMove(m)
+		fens = append(fens, replay.ToFen())
+	}
+
+	m, err := g.Undo()
+	require.NoError(t, err)
+	require.Equal(t, "b8c6", m.Uci())
+	m, err = g.Undo()
+	require.NoError(t, err)
+	require.Equal(t, "g1f3", m.Uci())
+	require.Equal(t, fens[2], g.ToFen())
+	require.Equal(t, []string{"g1f3", "b8c6"}, uciMoves(g.RedoMoves()))
+
+	m, err = g.Redo()
+	require.NoError(t, err)
+	require.Equal(t, "g1f3", m.Uci())
+	require.Equal(t, fens[3], g.ToFen())
+	require.Len(t, g.LegalMoves, 29)
+
+	// playing the move that would be redone keeps the rest of the stack
+	nc6, err := g.ParseSan("Nc6")
+	require.NoError(t, err)
+	g.MakeMove(nc6)
+	require.Empty(t, g.RedoMoves())
+	g.Undo()
+	g.Undo()
+	require.Len(t, g.RedoMoves(), 2)
+	nc3, err := g.ParseSan("Nc3")
+	require.NoError(t, err)
+	g.MakeMove(nc3)
+	// a different move drops it
+	require.Empty(t, g.RedoMoves())
+	_, err = g.Redo()
+	require.ErrorIs(t, err, ErrNothingToRedo)
+
+	g = NewGame()
+	_, err = g.Undo()
+	require.ErrorIs(t, err, ErrNothingToUndo)
+}
+
+func uciMoves(moves []Move) []string {
+	uci := make([]string, len(moves))
+	for i, m := range moves {
+		uci[i] = m.Uci()
+	}
+	return uci
+}
+
+func TestGoToPly(t *testing.T) {
+	// the knights dance back to the start twice: a threefold repetition at
+	// the end only
+	g, err := LoadPGNGame("1. Nf3 Nf6 2. Ng1 Ng8 3. Nf3 Nf6 4. Ng1 Ng8")
+	require.NoError(t, err)
+	require.True(t, g.IsThreefoldRepetition)
+	end := g.ToFen()
+	endHistory := maps.Clone(g.PositionHistory)
+
+	require.NoError(t, g.GoToPly(3))
+	require.Equal(t, 3, g.Ply())
+	require.False(t, g.IsThreefoldRepetition)
+	require.Equal(t, "rnbqkb1r/pppppppp/5n2/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 3 2", g.ToFen())
+
+	g.GoToStart()
+	require.Equal(t, 0, g.Ply())
+	require.Equal(t, STARTING_POSITION_FEN, g.ToFen())
+	require.Equal(t, map[uint64]int{g.ZobristHash: 1}, g.PositionHistory)
+	require.Len(t, g.LegalMoves, 20)
+
+	g.GoToEnd()
+	require.Equal(t, end, g.ToFen())
+	require.Equal(t, endHistory, g.PositionHistory)
+	require.True(t, g.IsThreefoldRepetition)
+
+	require.EqualError(t, g.GoToPly(9), E_INVALID_PLY)
+	require.EqualError(t, g.GoToPly(-1), E_INVALID_PLY)
+
+	// navigating never loses the moves
+	require.NoError(t, g.GoToPly(5))
+	require.Len(t, g.RedoMoves(), 3)
+	require.Equal(t, []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3"}, g.SANHistory())
+}
+
+func TestNavigationEvents(t *testing.T) {
+	g, err := LoadPGNGame("1. e4 e5")
+	require.NoError(t, err)
+	var kinds []EventKind
+	g.AddObserver(ObserverFunc(func(_ *Game, e GameEvent) {
+		if e.Kind == EVENT_MOVE_PLAYED || e.Kind == EVENT_MOVE_UNDONE {
+			kinds = append(kinds, e.Kind)
+		}
+	}))
+	g.GoToStart()
+	g.Redo()
+	require.Equal(t, []EventKind{EVENT_MOVE_UNDONE, EVENT_MOVE_UNDONE, EVENT_MOVE_PLAYED}, kinds)
+}
+
+func TestSafeGameUndoesLoadedMoves(t *testing.T) {
+	g, err := LoadPGNGame("1. d4 d5")
+	require.NoError(t, err)
+	sg := NewSafeGame("g", g)
+	snapshot := sg.Snapshot()
+	require.Equal(t, []string{"d4", "d5"}, snapshot.San)
+	require.Equal(t, []string{"d2d4", "d7d5"}, uciMoves(snapshot.Moves))
+	event, err := sg.Undo(2)
+	require.NoError(t, err)
+	require.Equal(t, "d5", event.San)
+	require.Equal(t, 1, event.Ply)
+}
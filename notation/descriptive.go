@@ -0,0 +1,156 @@
+package notation
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"chessongo"
+)
+
+// Descriptive is English descriptive notation, e.g. "P-K4", "N-KB3", "PxP",
+// "BxN", "P-K8=Q", "O-O". Squares are named after the pieces starting on
+// their file and counted from the side of the player moving. Moves are
+// written in their shortest unambiguous form, qualifying the pieces and
+// squares only when needed, e.g. "N-QB3" when a knight could also go to KB3,
+// and as a last resort with the square of the piece in parentheses, e.g.
+// "R(QR1)-Q1". Check is written "ch" and mate " mate".
+//
+// Parse reads any unambiguous form, with or without the qualifiers, in
+// either case.
+var Descriptive Notation = descriptive{}
+
+type descriptive struct{}
+
+var descriptiveFiles = [8]string{"QR", "QN", "QB", "Q", "K", "KB", "KN", "KR"}
+
+// Returns the full name of sq for color, e.g. "KB3"
+func descriptiveSquare(sq chessongo.Square, color chessongo.Color) string {
+	rank := 8 - sq.Rank()
+	if color == chessongo.BLACK {
+		rank = sq.Rank() + 1
+	}
+	return descriptiveFiles[sq.File()] + strconv.Itoa(rank)
+}
+
+// Returns the names of sq, the shortest first, e.g. "B3" and "KB3"
+func squareNames(sq chessongo.Square, color chessongo.Color) []string {
+	full := descriptiveSquare(sq, color)
+	if len(descriptiveFiles[sq.File()]) == 2 {
+		return []string{full[1:], full}
+	}
+	return []string{full}
+}
+
+// Returns the names of a piece of kind on sq, the shortest first, e.g. "P",
+// "KBP" and "P(KB2)"
+func pieceNames(kind chessongo.Piece, sq chessongo.Square, color chessongo.Color) []string {
+	letter := "P"
+	if kind != chessongo.PAWN {
+		letter = pieceLetter(kind)
+	}
+	file := descriptiveFiles[sq.File()]
+	var names []string
+	switch kind {
+	case chessongo.PAWN:
+		names = []string{letter, file + letter}
+	case chessongo.KING, chessongo.QUEEN:
+		names = []string{letter}
+	default:
+		names = []string{letter, file[:1] + letter}
+	}
+	return append(names, letter+"("+descriptiveSquare(sq, color)+")")
+}
+
+// Returns every way of writing m without check suffix, the shortest and
+// least qualified first
+func descriptiveForms(g *chessongo.Game, m chessongo.Move) []string {
+	if m.IsCastlingMove() {
+		return []string{castlingSan(m)}
+	}
+	color := g.Turn
+	movers := pieceNames(g.Squares[m.From()].Kind(), m.From(), color)
+	var targets []string
+	separator := "-"
+	if captured := m.GetCapturedPiece(); captured != chessongo.EMPTY {
+		separator = "x"
+		capturedSq := m.To()
+		if m.IsEnPassant() {
+			// the pawn taken is beside the pawn taking it
+			capturedSq = chessongo.Square(m.From().Rank()*8 + m.To().File())
+		}
+		targets = pieceNames(captured.Kind(), capturedSq, color)
+	} else {
+		targets = squareNames(m.To(), color)
+	}
+	promotion := ""
+	if promoteTo := m.GetPromotionTo(); promoteTo != chessongo.EMPTY {
+		promotion = "=" + pieceLetter(promoteTo)
+	}
+	forms := make([]string, 0, len(movers)*len(targets))
+	for _, mover := range movers {
+		for _, target := range targets {
+			forms = append(forms, mover+separator+target+promotion)
+		}
+	}
+	slices.SortStableFunc(forms, func(a, b string) int {
+		return len(a) - len(b)
+	})
+	return forms
+}
+
+func (descriptive) Format(g *chessongo.Game, m chessongo.Move) string {
+	forms := descriptiveForms(g, m)
+	form := forms[len(forms)-1]
+	for _, candidate := range forms {
+		if !formOfOtherMove(g, m, candidate) {
+			form = candidate
+			break
+		}
+	}
+	switch checkSuffix(g, m) {
+	case "+":
+		form += "ch"
+	case "#":
+		form += " mate"
+	}
+	return form
+}
+
+// Tells whether form is a way of writing a legal move other than m
+func formOfOtherMove(g *chessongo.Game, m chessongo.Move, form string) bool {
+	for _, other := range g.LegalMoves {
+		if other != m && slices.Contains(descriptiveForms(g, other), form) {
+			return true
+		}
+	}
+	return false
+}
+
+// Brings a descriptive move to the form of descriptiveForms, in upper case
+func normalizeDescriptive(s string) string {
+	s = strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	s = strings.TrimRight(s, "+#!?")
+	for _, suffix := range []string{"MATE", "CH", "E.P.", "EP"} {
+		s = strings.TrimSuffix(s, suffix)
+	}
+	s = strings.ReplaceAll(s, "0", "O")
+	// promotions written "P-K8(Q)" or "P-K8Q"
+	if n := len(s); n >= 3 && s[n-3] == '(' && s[n-1] == ')' && strings.Contains("QRBN", s[n-2:n-1]) {
+		s = s[:n-3] + "=" + s[n-2:n-1]
+	} else if n >= 2 && s[n-2] >= '1' && s[n-2] <= '8' && strings.Contains("QRBN", s[n-1:]) {
+		s = s[:n-1] + "=" + s[n-1:]
+	}
+	return s
+}
+
+func (descriptive) Parse(g *chessongo.Game, s string) (chessongo.Move, error) {
+	s = normalizeDescriptive(s)
+	return matchMove(g, s, func(m chessongo.Move) []string {
+		forms := descriptiveForms(g, m)
+		for i := range forms {
+			forms[i] = strings.ToUpper(forms[i])
+		}
+		return forms
+	})
+}
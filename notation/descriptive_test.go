@@ -0,0 +1,69 @@
+package notation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescriptiveGame(t *testing.T) {
+	g := mustGame(t, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	// a Ruy Lopez exchange line
+	line := []struct{ san, descriptive string }{
+		{"e4", "P-K4"}, {"e5", "P-K4"},
+		{"Nf3", "N-KB3"}, {"Nc6", "N-QB3"},
+		{"Bb5", "B-N5"}, {"a6", "P-QR3"},
+		{"Bxc6", "BxN"}, {"dxc6", "QPxB"},
+		{"O-O", "O-O"}, {"f6", "P-B3"},
+		{"d4", "P-Q4"}, {"exd4", "PxP"},
+		{"Nxd4", "NxP"}, {"c5", "P-QB4"},
+		{"Nb3", "N-N3"}, {"Qxd1", "QxQ"},
+		{"Rxd1", "RxQ"}, {"Bg4", "B-N5"},
+	}
+	for _, ply := range line {
+		m, err := g.ParseSan(ply.san)
+		require.NoError(t, err, ply.san)
+		require.Equal(t, ply.descriptive, Descriptive.Format(g, m), ply.san)
+		parsed, err := Descriptive.Parse(g, ply.descriptive)
+		require.NoError(t, err, ply.descriptive)
+		require.Equal(t, m, parsed, ply.descriptive)
+		g.MakeMove(m)
+		g.GenerateLegalMoves()
+	}
+}
+
+func TestDescriptiveForms(t *testing.T) {
+	for _, c := range []struct{ fen, uci, descriptive string }{
+		{"4k3/P7/8/8/8/8/8/R3K2R w K - 0 1", "a7a8q", "P-R8=Qch"},
+		{"4k3/P7/8/8/8/8/8/R3K2R w K - 0 1", "e1g1", "O-O"},
+		{"7k/8/8/8/8/8/8/R4RK1 w - - 0 1", "a1d1", "QR-Q1"},
+		{"7k/8/8/8/8/8/8/R4RK1 w - - 0 1", "f1d1", "KR-Q1"},
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1a8", "R-R8 mate"},
+		// the pawn taken en passant is named, not the square moved to
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2", "e5d6", "PxP"},
+	} {
+		g := mustGame(t, c.fen)
+		m, err := g.ParseUci(c.uci)
+		require.NoError(t, err, c.uci)
+		require.Equal(t, c.descriptive, Descriptive.Format(g, m), c.uci)
+	}
+
+	g := mustGame(t, "4k3/P7/8/8/8/8/8/R3K2R w K - 0 1")
+	for s, uci := range map[string]string{
+		"p-r8(q)ch": "a7a8q",
+		"P-QR8Q":    "a7a8q",
+		"P-R8=N":    "a7a8n",
+		"KR-KR8":    "h1h8",
+		"R(QR1)-Q1": "a1d1",
+		"0-0":       "e1g1",
+	} {
+		m, err := Descriptive.Parse(g, s)
+		require.NoError(t, err, s)
+		require.Equal(t, uci, m.Uci(), s)
+	}
+	g = mustGame(t, "7k/8/8/8/8/8/8/R4RK1 w - - 0 1")
+	_, err := Descriptive.Parse(g, "R-Q1")
+	require.EqualError(t, err, E_AMBIGUOUS_MOVE)
+	_, err = Descriptive.Parse(g, "PxP e.p.")
+	require.EqualError(t, err, E_INVALID_MOVE)
+}
@@ -0,0 +1,43 @@
+package notation
+
+import (
+	"fmt"
+	"strings"
+
+	"chessongo"
+)
+
+// ICCF is the numeric notation of correspondence chess: the file and rank
+// digits of both squares, e.g. "5254" for e2-e4 and "5171" for white
+// castling short, followed by 1 to 4 for a promotion to a queen, rook,
+// bishop or knight.
+var ICCF Notation = iccf{}
+
+type iccf struct{}
+
+var iccfPromotionDigits = map[chessongo.Piece]byte{
+	chessongo.QUEEN: '1', chessongo.ROOK: '2', chessongo.BISHOP: '3', chessongo.KNIGHT: '4',
+}
+
+// Returns the two digits of sq
+func iccfSquare(sq chessongo.Square) string {
+	return fmt.Sprintf("%d%d", sq.File()+1, 8-sq.Rank())
+}
+
+func (iccf) Format(g *chessongo.Game, m chessongo.Move) string {
+	s := iccfSquare(m.From()) + iccfSquare(m.To())
+	if promoteTo := m.GetPromotionTo(); promoteTo != chessongo.EMPTY {
+		s += string(iccfPromotionDigits[promoteTo])
+	}
+	return s
+}
+
+func (n iccf) Parse(g *chessongo.Game, s string) (chessongo.Move, error) {
+	s = strings.TrimSpace(s)
+	if len(s) != 4 && len(s) != 5 {
+		return 0, fmt.Errorf(E_INVALID_MOVE)
+	}
+	return matchMove(g, s, func(m chessongo.Move) []string {
+		return []string{n.Format(g, m)}
+	})
+}
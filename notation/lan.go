@@ -0,0 +1,57 @@
+package notation
+
+import (
+	"strings"
+
+	"chessongo"
+)
+
+// LAN is long algebraic notation, giving both squares of every move:
+// "Ng1-f3", "e4xd5", "e7-e8=Q", "O-O". Parse also takes the moves without
+// separators, like "Ng1f3" or "e2e4".
+var LAN Notation = lan{}
+
+type lan struct{}
+
+func (lan) Format(g *chessongo.Game, m chessongo.Move) string {
+	return formatLAN(g, m) + checkSuffix(g, m)
+}
+
+// Returns the LAN of m without check suffix
+func formatLAN(g *chessongo.Game, m chessongo.Move) string {
+	if m.IsCastlingMove() {
+		return castlingSan(m)
+	}
+	var b strings.Builder
+	if kind := g.Squares[m.From()].Kind(); kind != chessongo.PAWN {
+		b.WriteString(pieceLetter(kind))
+	}
+	b.WriteString(m.From().Coords())
+	if m.GetCapturedPiece() != chessongo.EMPTY {
+		b.WriteByte('x')
+	} else {
+		b.WriteByte('-')
+	}
+	b.WriteString(m.To().Coords())
+	if promoteTo := m.GetPromotionTo(); promoteTo != chessongo.EMPTY {
+		b.WriteString("=" + pieceLetter(promoteTo))
+	}
+	return b.String()
+}
+
+var lanSeparators = strings.NewReplacer("-", "", "x", "", ":", "", "=", "")
+
+func normalizeLAN(s string) string {
+	s = strings.ReplaceAll(s, "0", "O")
+	if strings.HasPrefix(s, "O") {
+		return s
+	}
+	return lanSeparators.Replace(s)
+}
+
+func (lan) Parse(g *chessongo.Game, s string) (chessongo.Move, error) {
+	s = normalizeLAN(trimSuffixes(s))
+	return matchMove(g, s, func(m chessongo.Move) []string {
+		return []string{normalizeLAN(formatLAN(g, m))}
+	})
+}
@@ -0,0 +1,51 @@
+package notation
+
+import (
+	"testing"
+
+	"chessongo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLAN(t *testing.T) {
+	g := mustGame(t, "r3k2r/p1pp1pb1/bn2Pnp1/2qP4/1p2P3/2N5/PPPBBPpP/R3K2R b KQkq - 0 1")
+	for uci, lan := range map[string]string{
+		"e8g8":  "O-O",
+		"b6d5":  "Nb6xd5",
+		"a8b8":  "Ra8-b8",
+		"g2h1q": "g2xh1=Q+",
+		"g2g1q": "g2-g1=Q+",
+		"f7e6":  "f7xe6",
+		"d7e6":  "d7xe6",
+	} {
+		m, err := g.ParseUci(uci)
+		require.NoError(t, err)
+		require.Equal(t, lan, LAN.Format(g, m), uci)
+	}
+
+	g = mustGame(t, chessongo.STARTING_POSITION_FEN)
+	for _, s := range []string{"Ng1-f3", "Ng1f3", "Ng1-f3!?"} {
+		m, err := LAN.Parse(g, s)
+		require.NoError(t, err, s)
+		require.Equal(t, "g1f3", m.Uci(), s)
+	}
+	m, err := LAN.Parse(g, "e2e4")
+	require.NoError(t, err)
+	require.Equal(t, "e2e4", m.Uci())
+}
+
+func TestICCF(t *testing.T) {
+	g := mustGame(t, "r3k2r/8/8/8/8/8/6p1/R3K2R b KQkq - 0 1")
+	for uci, iccf := range map[string]string{"e8c8": "5838", "a8a1": "1811", "g2g1n": "72714", "g2h1r": "72812"} {
+		m, err := g.ParseUci(uci)
+		require.NoError(t, err)
+		require.Equal(t, iccf, ICCF.Format(g, m), uci)
+		parsed, err := ICCF.Parse(g, iccf)
+		require.NoError(t, err)
+		require.Equal(t, m, parsed)
+	}
+	g = mustGame(t, chessongo.STARTING_POSITION_FEN)
+	m, err := ICCF.Parse(g, "5254")
+	require.NoError(t, err)
+	require.Equal(t, chessongo.NewMove(chessongo.COORDS_TO_SQUARE["e2"], chessongo.COORDS_TO_SQUARE["e4"], chessongo.EMPTY), m)
+}
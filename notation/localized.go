@@ -0,0 +1,101 @@
+package notation
+
+import (
+	"fmt"
+	"strings"
+
+	"chessongo"
+)
+
+// PieceLetters are the names of the pieces in algebraic notation, indexed by
+// kind; the pawn has none
+type PieceLetters [7]string
+
+// Piece letters by language code. More languages can be added before calling
+// Localized.
+var PIECE_LETTERS = map[string]PieceLetters{
+	"en": {chessongo.KNIGHT: "N", chessongo.BISHOP: "B", chessongo.ROOK: "R", chessongo.QUEEN: "Q", chessongo.KING: "K"},
+	"de": {chessongo.KNIGHT: "S", chessongo.BISHOP: "L", chessongo.ROOK: "T", chessongo.QUEEN: "D", chessongo.KING: "K"},
+	"fr": {chessongo.KNIGHT: "C", chessongo.BISHOP: "F", chessongo.ROOK: "T", chessongo.QUEEN: "D", chessongo.KING: "R"},
+	"es": {chessongo.KNIGHT: "C", chessongo.BISHOP: "A", chessongo.ROOK: "T", chessongo.QUEEN: "D", chessongo.KING: "R"},
+	"nl": {chessongo.KNIGHT: "P", chessongo.BISHOP: "L", chessongo.ROOK: "T", chessongo.QUEEN: "D", chessongo.KING: "K"},
+	"ru": {chessongo.KNIGHT: "К", chessongo.BISHOP: "С", chessongo.ROOK: "Л", chessongo.QUEEN: "Ф", chessongo.KING: "Кр"},
+}
+
+// Figurine is algebraic notation with figurines for the pieces, e.g. "♘f3".
+// It writes the white figurines for both sides and reads either set.
+var Figurine Notation = localized{
+	letters: PieceLetters{chessongo.KNIGHT: "♘", chessongo.BISHOP: "♗", chessongo.ROOK: "♖", chessongo.QUEEN: "♕", chessongo.KING: "♔"},
+	aliases: PieceLetters{chessongo.KNIGHT: "♞", chessongo.BISHOP: "♝", chessongo.ROOK: "♜", chessongo.QUEEN: "♛", chessongo.KING: "♚"},
+}
+
+// Localized returns standard algebraic notation with the piece letters of
+// lang, one of the codes of PIECE_LETTERS, e.g. "Sf3" in German
+func Localized(lang string) (Notation, error) {
+	letters, ok := PIECE_LETTERS[strings.ToLower(lang)]
+	if !ok {
+		return nil, fmt.Errorf(E_UNKNOWN_LANGUAGE)
+	}
+	return NewLocalized(letters), nil
+}
+
+// NewLocalized returns standard algebraic notation with the given piece
+// letters, which must all differ
+func NewLocalized(letters PieceLetters) Notation {
+	return localized{letters: letters}
+}
+
+type localized struct {
+	letters PieceLetters
+	// Other names read for the pieces, if any
+	aliases PieceLetters
+}
+
+// Replaces the piece letters of san, at its start and after "=", using
+// replace
+func translate(san string, replace func(s string) (name string, rest string)) string {
+	var b strings.Builder
+	name, rest := replace(san)
+	b.WriteString(name)
+	if before, promotion, ok := strings.Cut(rest, "="); ok {
+		b.WriteString(before + "=")
+		name, rest = replace(promotion)
+		b.WriteString(name)
+	}
+	b.WriteString(rest)
+	return b.String()
+}
+
+func (n localized) Format(g *chessongo.Game, m chessongo.Move) string {
+	return translate(g.GetMoveSan(m), func(s string) (string, string) {
+		for kind := chessongo.Piece(chessongo.KNIGHT); kind <= chessongo.KING; kind++ {
+			if letter := pieceLetter(kind); strings.HasPrefix(s, letter) {
+				return n.letters[kind], s[len(letter):]
+			}
+		}
+		return "", s
+	})
+}
+
+func (n localized) Parse(g *chessongo.Game, s string) (chessongo.Move, error) {
+	san := translate(strings.TrimSpace(s), func(s string) (string, string) {
+		// the longest name first, e.g. "Кр" before "К"
+		best, bestName := chessongo.Piece(chessongo.EMPTY), ""
+		for kind := chessongo.Piece(chessongo.KNIGHT); kind <= chessongo.KING; kind++ {
+			for _, name := range []string{n.letters[kind], n.aliases[kind]} {
+				if name != "" && strings.HasPrefix(s, name) && len(name) > len(bestName) {
+					best, bestName = kind, name
+				}
+			}
+		}
+		if best == chessongo.EMPTY {
+			return "", s
+		}
+		return pieceLetter(best), s[len(bestName):]
+	})
+	m, err := g.ParseSan(san)
+	if err != nil {
+		return 0, fmt.Errorf(E_INVALID_MOVE)
+	}
+	return m, nil
+}
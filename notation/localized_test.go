@@ -0,0 +1,57 @@
+package notation
+
+import (
+	"testing"
+
+	"chessongo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalized(t *testing.T) {
+	g := mustGame(t, "4k3/1P6/8/8/8/8/8/R3K1N1 w Q - 0 1")
+	moves := map[string]chessongo.Move{}
+	for _, uci := range []string{"g1f3", "e1c1", "b7b8q", "a1a8"} {
+		m, err := g.ParseUci(uci)
+		require.NoError(t, err)
+		moves[uci] = m
+	}
+	for lang, want := range map[string][]string{
+		"en": {"Nf3", "O-O-O", "b8=Q+", "Ra8+"},
+		"de": {"Sf3", "O-O-O", "b8=D+", "Ta8+"},
+		"fr": {"Cf3", "O-O-O", "b8=D+", "Ta8+"},
+		"es": {"Cf3", "O-O-O", "b8=D+", "Ta8+"},
+		"nl": {"Pf3", "O-O-O", "b8=D+", "Ta8+"},
+		"ru": {"Кf3", "O-O-O", "b8=Ф+", "Лa8+"},
+	} {
+		n, err := Localized(lang)
+		require.NoError(t, err)
+		for i, uci := range []string{"g1f3", "e1c1", "b7b8q", "a1a8"} {
+			require.Equal(t, want[i], n.Format(g, moves[uci]), lang)
+			parsed, err := n.Parse(g, want[i])
+			require.NoError(t, err, lang)
+			require.Equal(t, moves[uci], parsed, lang)
+		}
+	}
+
+	// the Russian king and knight start alike
+	g = mustGame(t, "4k3/8/8/8/8/8/8/4K1N1 w - - 0 1")
+	ru, _ := Localized("ru")
+	m, err := ru.Parse(g, "Крf2")
+	require.NoError(t, err)
+	require.Equal(t, "e1f2", m.Uci())
+	m, err = ru.Parse(g, "Кh3")
+	require.NoError(t, err)
+	require.Equal(t, "g1h3", m.Uci())
+}
+
+func TestFigurine(t *testing.T) {
+	g := mustGame(t, chessongo.STARTING_POSITION_FEN)
+	m, err := g.ParseSan("Nf3")
+	require.NoError(t, err)
+	require.Equal(t, "♘f3", Figurine.Format(g, m))
+	for _, s := range []string{"♘f3", "♞f3"} {
+		parsed, err := Figurine.Parse(g, s)
+		require.NoError(t, err, s)
+		require.Equal(t, m, parsed)
+	}
+}
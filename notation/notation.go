@@ -0,0 +1,84 @@
+// Package notation writes and reads moves in notations other than SAN: long
+// algebraic, figurine algebraic, algebraic with the piece letters of other
+// languages, ICCF numeric and English descriptive.
+//
+// Like Game.GetMoveSan and Game.ParseSan, the notations work on the current
+// position of a game, whose legal moves must be generated.
+package notation
+
+import (
+	"fmt"
+	"strings"
+
+	"chessongo"
+)
+
+const (
+	E_INVALID_MOVE     = "e:invalid:move"
+	E_AMBIGUOUS_MOVE   = "e:ambiguous:move"
+	E_UNKNOWN_LANGUAGE = "e:unknown:language"
+)
+
+// Notation writes and reads the moves of a position in one notation
+type Notation interface {
+	// Format returns legal move m of the current position of g
+	Format(g *chessongo.Game, m chessongo.Move) string
+	// Parse returns the legal move of the current position of g written s
+	Parse(g *chessongo.Game, s string) (chessongo.Move, error)
+}
+
+// Returns "+" if m gives check, "#" if it mates and "" otherwise
+func checkSuffix(g *chessongo.Game, m chessongo.Move) string {
+	san := g.GetMoveSan(m)
+	switch {
+	case strings.HasSuffix(san, "#"):
+		return "#"
+	case strings.HasSuffix(san, "+"):
+		return "+"
+	}
+	return ""
+}
+
+// Removes the check, mate and annotation suffixes of a move
+func trimSuffixes(s string) string {
+	return strings.TrimRight(strings.TrimSpace(s), "+#!?")
+}
+
+// Returns the only legal move of g whose forms include s, forms returning the
+// ways a move may be written once normalized like s
+func matchMove(g *chessongo.Game, s string, forms func(m chessongo.Move) []string) (chessongo.Move, error) {
+	if s == "" {
+		return 0, fmt.Errorf(E_INVALID_MOVE)
+	}
+	var found chessongo.Move
+	matches := 0
+	for _, m := range g.LegalMoves {
+		for _, form := range forms(m) {
+			if form == s {
+				found = m
+				matches++
+				break
+			}
+		}
+	}
+	switch matches {
+	case 0:
+		return 0, fmt.Errorf(E_INVALID_MOVE)
+	case 1:
+		return found, nil
+	}
+	return 0, fmt.Errorf(E_AMBIGUOUS_MOVE)
+}
+
+// Returns the SAN letter of kind, e.g. "N"
+func pieceLetter(kind chessongo.Piece) string {
+	return strings.ToUpper(string(chessongo.Piece(uint(kind) | chessongo.WHITE).ToRune()))
+}
+
+// Returns the O-O or O-O-O of castling move m
+func castlingSan(m chessongo.Move) string {
+	if m.To().File() == 6 {
+		return "O-O"
+	}
+	return "O-O-O"
+}
@@ -0,0 +1,65 @@
+package notation
+
+import (
+	"testing"
+
+	"chessongo"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns the game at fen with its legal moves
+func mustGame(t *testing.T, fen string) *chessongo.Game {
+	g := &chessongo.Game{}
+	require.NoError(t, g.LoadFen(fen))
+	g.GenerateLegalMoves()
+	return g
+}
+
+// Checks that every legal move of fen is written in a form n reads back
+func requireRoundTrips(t *testing.T, n Notation, fen string) {
+	g := mustGame(t, fen)
+	for _, m := range g.LegalMoves {
+		s := n.Format(g, m)
+		parsed, err := n.Parse(g, s)
+		require.NoError(t, err, s)
+		require.Equal(t, m, parsed, s)
+	}
+}
+
+// Positions with castling, en passant, promotions and ambiguous moves
+var roundTripFens = []string{
+	chessongo.STARTING_POSITION_FEN,
+	"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+	"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+	"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+	"R6R/8/8/1Q3Q2/8/1Q3Q2/8/k1K5 w - - 0 1",
+}
+
+func TestRoundTrips(t *testing.T) {
+	notations := map[string]Notation{"lan": LAN, "figurine": Figurine, "iccf": ICCF, "descriptive": Descriptive}
+	for lang := range PIECE_LETTERS {
+		n, err := Localized(lang)
+		require.NoError(t, err)
+		notations[lang] = n
+	}
+	for name, n := range notations {
+		t.Run(name, func(t *testing.T) {
+			for _, fen := range roundTripFens {
+				requireRoundTrips(t, n, fen)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	g := mustGame(t, chessongo.STARTING_POSITION_FEN)
+	for _, n := range []Notation{LAN, Figurine, ICCF, Descriptive} {
+		for _, s := range []string{"", "e2-e5", "5255", "P-K5", "Ke2"} {
+			_, err := n.Parse(g, s)
+			require.EqualError(t, err, E_INVALID_MOVE, s)
+		}
+	}
+	_, err := Localized("xx")
+	require.EqualError(t, err, E_UNKNOWN_LANGUAGE)
+}
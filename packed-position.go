@@ -0,0 +1,261 @@
+package chessongo
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// The position has more than 32 pieces, or castling or en passant rights
+	// that do not match the pieces
+	E_PACK_UNREPRESENTABLE = "e:pack:unrepresentable"
+	E_INVALID_PACKED       = "e:invalid:packed"
+)
+
+/*
+* Packed position layout, PACKED_POSITION_SIZE bytes:
+*
+* occupancy   8 bytes  big endian bitboard of the occupied squares
+* pieces      16 bytes a 4-bit code per occupied square, in square order, high
+*                      nibble first, unused nibbles zero
+* half moves  2 bytes  big endian
+* full moves  2 bytes  big endian
+*
+* The first PACKED_KEY_SIZE bytes hold the placement, the side to move and the
+* castling and en passant rights, so they can serve as a key for positions
+* regardless of the move counters.
+ */
+const (
+	PACKED_POSITION_SIZE = 28
+	PACKED_KEY_SIZE      = 24
+)
+
+// Piece codes of the packed encoding. Codes 0 to 11 are the plain pieces;
+// the others fold the side to move and the castling and en passant rights
+// into the pieces they concern.
+const (
+	PACKED_W_PAWN = iota
+	PACKED_W_KNIGHT
+	PACKED_W_BISHOP
+	PACKED_W_ROOK
+	PACKED_W_QUEEN
+	PACKED_W_KING
+	PACKED_B_PAWN
+	PACKED_B_KNIGHT
+	PACKED_B_BISHOP
+	PACKED_B_ROOK
+	PACKED_B_QUEEN
+	PACKED_B_KING
+	// Pawn that can be taken en passant; its rank tells its color
+	PACKED_EN_PASSANT_PAWN
+	// Rook that can still castle
+	PACKED_W_CASTLING_ROOK
+	PACKED_B_CASTLING_ROOK
+	// Black king, black to move
+	PACKED_B_KING_TO_MOVE
+)
+
+var PACKED_CODE_TO_PIECE = [12]Piece{
+	W_PAWN, W_KNIGHT, W_BISHOP, W_ROOK, W_QUEEN, W_KING,
+	B_PAWN, B_KNIGHT, B_BISHOP, B_ROOK, B_QUEEN, B_KING,
+}
+
+// Packed codes of the plain pieces, indexed by Piece
+var pieceToPackedCode = func() (codes [B_KING + 1]byte) {
+	for code, piece := range PACKED_CODE_TO_PIECE {
+		codes[piece] = byte(code)
+	}
+	return
+}()
+
+// Castling right held by a rook on its original square
+var castlingRookRight = map[Square]int{
+	WKS_ROOK_ORIGINAL_SQUARE: CASTLE_WKS,
+	WQS_ROOK_ORIGINAL_SQUARE: CASTLE_WQS,
+	BKS_ROOK_ORIGINAL_SQUARE: CASTLE_BKS,
+	BQS_ROOK_ORIGINAL_SQUARE: CASTLE_BQS,
+}
+
+// PackedPosition is a fixed-size encoding of a Position. Equal positions
+// always have equal bytes, so packed positions can be compared, hashed or
+// used as keys of sorted stores.
+type PackedPosition [PACKED_POSITION_SIZE]byte
+
+// Key returns the part of the packed position that ignores the move counters
+func (pp PackedPosition) Key() [PACKED_KEY_SIZE]byte {
+	return [PACKED_KEY_SIZE]byte(pp[:PACKED_KEY_SIZE])
+}
+
+// Pack encodes the position. Move counters above 65535 are capped. Black to
+// move is recorded on the black king, so a position with black to move and
+// no black king cannot be packed.
+func (p *Position) Pack() (PackedPosition, error) {
+	var pp PackedPosition
+	if p.Occupied.NumberOfSetBits() > 32 {
+		return pp, fmt.Errorf(E_PACK_UNREPRESENTABLE)
+	}
+	binary.BigEndian.PutUint64(pp[0:8], uint64(p.Occupied))
+
+	epPawn := -1
+	if p.EnPassant != 0 {
+		epPawn = int(p.EnPassant) + 8
+		if p.Turn == BLACK {
+			epPawn = int(p.EnPassant) - 8
+		}
+		if epPawn < 0 || epPawn > 63 || p.Squares[epPawn] != Piece(opponentColor(p.Turn)|PAWN) {
+			return pp, fmt.Errorf(E_PACK_UNREPRESENTABLE)
+		}
+	}
+	castling := 0
+	nibble := 0
+	blackToMove := false
+	for occupied := p.Occupied; occupied > 0; nibble++ {
+		sq := occupied.popLSB()
+		piece := p.Squares[sq]
+		code := pieceToPackedCode[piece]
+		switch {
+		case int(sq) == epPawn:
+			code = PACKED_EN_PASSANT_PAWN
+		case piece.Kind() == ROOK && p.Castling&castlingRookRight[Square(sq)] > 0 &&
+			piece.Color() == castlingColor(castlingRookRight[Square(sq)]):
+			castling |= castlingRookRight[Square(sq)]
+			if piece.IsWhite() {
+				code = PACKED_W_CASTLING_ROOK
+			} else {
+				code = PACKED_B_CASTLING_ROOK
+			}
+		case piece == B_KING && p.Turn == BLACK:
+			code = PACKED_B_KING_TO_MOVE
+			blackToMove = true
+		}
+		pp[8+nibble/2] |= code << (4 * (1 - nibble%2))
+	}
+	if castling != p.Castling&0xF || p.Turn == BLACK && !blackToMove {
+		return pp, fmt.Errorf(E_PACK_UNREPRESENTABLE)
+	}
+	binary.BigEndian.PutUint16(pp[24:26], uint16(min(p.HalfMoves, 0xFFFF)))
+	binary.BigEndian.PutUint16(pp[26:28], uint16(min(p.FullMoves, 0xFFFF)))
+	return pp, nil
+}
+
+// Returns the color whose castling right is right
+func castlingColor(right int) Color {
+	if right == CASTLE_WKS || right == CASTLE_WQS {
+		return WHITE
+	}
+	return BLACK
+}
+
+// Unpack decodes the position
+func (pp PackedPosition) Unpack() (Position, error) {
+	var p Position
+	err := pp.unpackInto(&p)
+	return p, err
+}
+
+func (pp *PackedPosition) unpackInto(p *Position) error {
+	*p = Position{Turn: WHITE}
+	occupied := Bitboard(binary.BigEndian.Uint64(pp[0:8]))
+	if occupied.NumberOfSetBits() > 32 {
+		return fmt.Errorf(E_INVALID_PACKED)
+	}
+	epPawn := -1
+	nibble := 0
+	for ; occupied > 0; nibble++ {
+		sq := int(occupied.popLSB())
+		code := pp[8+nibble/2] >> (4 * (1 - nibble%2)) & 0xF
+		var piece Piece
+		switch code {
+		case PACKED_EN_PASSANT_PAWN:
+			switch Square(sq).Rank() {
+			case 4:
+				piece = W_PAWN
+			case 3:
+				piece = B_PAWN
+			default:
+				return fmt.Errorf(E_INVALID_PACKED)
+			}
+			if epPawn != -1 {
+				return fmt.Errorf(E_INVALID_PACKED)
+			}
+			epPawn = sq
+		case PACKED_W_CASTLING_ROOK, PACKED_B_CASTLING_ROOK:
+			piece = W_ROOK
+			if code == PACKED_B_CASTLING_ROOK {
+				piece = B_ROOK
+			}
+			right, ok := castlingRookRight[Square(sq)]
+			if !ok || castlingColor(right) != piece.Color() {
+				return fmt.Errorf(E_INVALID_PACKED)
+			}
+			p.Castling |= right
+		case PACKED_B_KING_TO_MOVE:
+			piece = B_KING
+			p.Turn = BLACK
+		default:
+			piece = PACKED_CODE_TO_PIECE[code]
+		}
+		p.addPiece(piece, sq)
+	}
+	// Unused nibbles must be zero, so every position has a single encoding
+	for ; nibble < 32; nibble++ {
+		if pp[8+nibble/2]>>(4*(1-nibble%2))&0xF != 0 {
+			return fmt.Errorf(E_INVALID_PACKED)
+		}
+	}
+	if epPawn != -1 {
+		if p.Squares[epPawn] == W_PAWN {
+			if p.Turn != BLACK {
+				return fmt.Errorf(E_INVALID_PACKED)
+			}
+			p.EnPassant = Square(epPawn + 8)
+		} else {
+			if p.Turn != WHITE {
+				return fmt.Errorf(E_INVALID_PACKED)
+			}
+			p.EnPassant = Square(epPawn - 8)
+		}
+	}
+	p.HalfMoves = int(binary.BigEndian.Uint16(pp[24:26]))
+	p.FullMoves = int(binary.BigEndian.Uint16(pp[26:28]))
+	p.ZobristHash = p.computeZobrist()
+	return nil
+}
+
+// Pack encodes the current position of the game
+func (g *Game) Pack() (PackedPosition, error) {
+	return g.Position.Pack()
+}
+
+// NewGameFromPacked starts a new game, without history, from a packed position
+func NewGameFromPacked(pp PackedPosition) (*Game, error) {
+	p, err := pp.Unpack()
+	if err != nil {
+		return nil, err
+	}
+	return NewGameFromPosition(p), nil
+}
+
+// PackPositions encodes src into dst, which must be at least as long, and
+// returns the number of positions encoded before the first error
+func PackPositions(dst []PackedPosition, src []Position) (int, error) {
+	for i := range src {
+		pp, err := src[i].Pack()
+		if err != nil {
+			return i, err
+		}
+		dst[i] = pp
+	}
+	return len(src), nil
+}
+
+// UnpackPositions decodes src into dst, which must be at least as long, and
+// returns the number of positions decoded before the first error
+func UnpackPositions(dst []Position, src []PackedPosition) (int, error) {
+	for i := range src {
+		if err := src[i].unpackInto(&dst[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(src), nil
+}
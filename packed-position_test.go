@@ -0,0 +1,131 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackedPositionRoundTrip(t *testing.T) {
+	fens := append([]string{
+		"rnbqkbnr/pppp1ppp/8/8/3Pp3/8/PPP1PPPP/RNBQKBNR b Kq d3 0 3",
+		"4k3/8/8/8/8/8/8/4K3 b - - 99 300",
+		"8/8/8/8/8/8/8/8 w - - 0 1",
+	}, stagedTestFens...)
+	for _, fen := range fens {
+		p, err := NewPosition(fen)
+		require.NoError(t, err)
+		pp, err := p.Pack()
+		require.NoError(t, err, fen)
+		unpacked, err := pp.Unpack()
+		require.NoError(t, err, fen)
+		require.Equal(t, p, unpacked, fen)
+		require.Equal(t, fen, unpacked.ToFen())
+	}
+}
+
+func TestPackedPositionIsCanonical(t *testing.T) {
+	a, err := LoadPGNGame("1. Nf3 Nf6 2. Nc3 Nc6")
+	require.NoError(t, err)
+	b, err := LoadPGNGame("1. Nc3 Nc6 2. Nf3 Nf6")
+	require.NoError(t, err)
+	pa, err := a.Pack()
+	require.NoError(t, err)
+	pb, err := b.Pack()
+	require.NoError(t, err)
+	require.Equal(t, pa, pb)
+
+	// Only the counters differ after the knights went back and forth
+	c, err := LoadPGNGame("1. Nf3 Nf6 2. Ng1 Ng8 3. Nf3 Nf6")
+	require.NoError(t, err)
+	pc, err := c.Pack()
+	require.NoError(t, err)
+	start, err := LoadPGNGame("1. Nf3 Nf6")
+	require.NoError(t, err)
+	ps, err := start.Pack()
+	require.NoError(t, err)
+	require.NotEqual(t, ps, pc)
+	require.Equal(t, ps.Key(), pc.Key())
+}
+
+func TestPackedPositionSpecialCodes(t *testing.T) {
+	p, err := NewPosition("r3k3/8/8/8/4pP2/8/8/4K2R b Kq f3 0 1")
+	require.NoError(t, err)
+	pp, err := p.Pack()
+	require.NoError(t, err)
+	// a8, e8, e4, f4, e1, h1
+	require.Equal(t, []byte{
+		PACKED_B_CASTLING_ROOK<<4 | PACKED_B_KING_TO_MOVE,
+		PACKED_B_PAWN<<4 | PACKED_EN_PASSANT_PAWN,
+		PACKED_W_KING<<4 | PACKED_W_CASTLING_ROOK,
+	}, pp[8:11])
+	require.Zero(t, pp[11])
+
+	game, err := NewGameFromPacked(pp)
+	require.NoError(t, err)
+	require.Equal(t, "r3k3/8/8/8/4pP2/8/8/4K2R b Kq f3 0 1", game.ToFen())
+}
+
+func TestPackedPositionErrors(t *testing.T) {
+	// castling right without its rook
+	p, err := NewPosition("4k3/8/8/8/8/8/8/4K3 w K - 0 1")
+	require.NoError(t, err)
+	_, err = p.Pack()
+	require.EqualError(t, err, E_PACK_UNREPRESENTABLE)
+
+	// black to move has no black king to be recorded on
+	p, err = NewPosition("8/8/8/8/8/8/8/4K3 b - - 0 1")
+	require.NoError(t, err)
+	_, err = p.Pack()
+	require.EqualError(t, err, E_PACK_UNREPRESENTABLE)
+
+	// more than 32 pieces
+	p, err = NewPosition("pppppppp/pppppppp/pppppppp/pppppppp/PPPPPPPP/8/8/k6K w - - 0 1")
+	require.NoError(t, err)
+	_, err = p.Pack()
+	require.EqualError(t, err, E_PACK_UNREPRESENTABLE)
+
+	start, err := NewPosition(STARTING_POSITION_FEN)
+	require.NoError(t, err)
+	pp, err := start.Pack()
+	require.NoError(t, err)
+
+	kings, err := NewPosition("4k3/8/8/8/8/8/8/4K3 w - - 0 1")
+	require.NoError(t, err)
+	trailing, err := kings.Pack()
+	require.NoError(t, err)
+	trailing[23] = 1
+	_, err = trailing.Unpack()
+	require.EqualError(t, err, E_INVALID_PACKED)
+
+	// en passant pawn on its original square
+	misplaced := pp
+	misplaced[8+4] = PACKED_EN_PASSANT_PAWN<<4 | PACKED_B_PAWN
+	_, err = misplaced.Unpack()
+	require.EqualError(t, err, E_INVALID_PACKED)
+}
+
+func TestPackPositions(t *testing.T) {
+	var positions []Position
+	for _, fen := range stagedTestFens {
+		p, err := NewPosition(fen)
+		require.NoError(t, err)
+		positions = append(positions, p)
+	}
+	packed := make([]PackedPosition, len(positions))
+	n, err := PackPositions(packed, positions)
+	require.NoError(t, err)
+	require.Equal(t, len(positions), n)
+
+	unpacked := make([]Position, len(packed))
+	n, err = UnpackPositions(unpacked, packed)
+	require.NoError(t, err)
+	require.Equal(t, len(packed), n)
+	require.Equal(t, positions, unpacked)
+
+	// the third position has 9 pieces
+	packed[2][23] = 0xFF
+	n, err = UnpackPositions(unpacked, packed)
+	require.Error(t, err)
+	require.Equal(t, 2, n)
+}
@@ -0,0 +1,106 @@
+package chessongo
+
+import "io"
+
+// PatternMatch is a ply of a game whose position matched a pattern
+type PatternMatch struct {
+	// Position of the game in the stream, from 0
+	Game int
+	// Number of plies played when the position was reached, 0 for the start
+	// position
+	Ply  int
+	Tags []Tag
+	// The matching position
+	Position Position
+}
+
+// PatternScanner streams a PGN collection, reporting every ply of every
+// game where the position matches a pattern. It is used like bufio.Scanner:
+//
+//	s := NewPatternScanner(r, pattern)
+//	for s.Scan() {
+//		m := s.Match()
+//		...
+//	}
+//	if err := s.Err(); err != nil {
+//		...
+//	}
+//
+// Games whose moves cannot be played are skipped and counted by Skipped.
+type PatternScanner struct {
+	games   *PGNScanner
+	pattern Pattern
+	// Set for the first match of each game only
+	FirstPerGame bool
+
+	game      int
+	tags      []Tag
+	positions []Position
+	next      int
+	match     PatternMatch
+	skipped   int
+}
+
+func NewPatternScanner(r io.Reader, pattern Pattern) *PatternScanner {
+	return &PatternScanner{games: NewPGNScanner(r), pattern: pattern, game: -1}
+}
+
+// Scan advances to the next match, reporting whether there is one
+func (s *PatternScanner) Scan() bool {
+	for {
+		for s.next < len(s.positions) {
+			ply := s.next
+			s.next++
+			if !s.pattern.Match(&s.positions[ply]) {
+				continue
+			}
+			s.match = PatternMatch{Game: s.game, Ply: ply, Tags: s.tags, Position: s.positions[ply]}
+			if s.FirstPerGame {
+				s.next = len(s.positions)
+			}
+			return true
+		}
+		if !s.games.Scan() {
+			return false
+		}
+		s.loadGame(s.games.Text())
+	}
+}
+
+// Replays the moves of pgn, keeping the position reached at every ply
+func (s *PatternScanner) loadGame(pgn string) {
+	s.game++
+	s.positions, s.next = s.positions[:0], 0
+	g, err := LoadPGNGame(pgn)
+	if err != nil {
+		s.skipped++
+		return
+	}
+	p, err := NewPosition(g.Fen)
+	if err != nil {
+		s.skipped++
+		return
+	}
+	s.tags = ParsePGNTags(pgn)
+	s.positions = append(s.positions, p)
+	for _, state := range g.History {
+		p = p.Play(state.Move)
+		s.positions = append(s.positions, p)
+	}
+}
+
+// Match returns the match found by the last call to Scan
+func (s *PatternScanner) Match() PatternMatch {
+	return s.match
+}
+
+// Skipped returns the number of games skipped so far because their moves
+// could not be played
+func (s *PatternScanner) Skipped() int {
+	return s.skipped
+}
+
+// Err returns the first error reading the stream, nil at the end of it
+func (s *PatternScanner) Err() error {
+	return s.games.Err()
+}
@@ -0,0 +1,58 @@
+package chessongo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatternScanner(t *testing.T) {
+	pgn := `[Event "One"]
+
+1. e4 d5 2. exd5 Qxd5 3. Nc3 Qa5 *
+
+[Event "Broken"]
+
+1. e4 e5 2. Ke3 *
+
+[Event "Two"]
+
+1. d4 d5 2. c4 dxc4 3. e3 e6 4. Bxc4 c5 5. Nf3 cxd4 6. exd4 *
+`
+	// White has an isolated d-pawn
+	iqp := AllOf(IsolatedPawn(WHITE, 3), PieceOn(W_PAWN, COORDS_TO_SQUARE["d4"]))
+	s := NewPatternScanner(strings.NewReader(pgn), iqp)
+	var matches []PatternMatch
+	for s.Scan() {
+		matches = append(matches, s.Match())
+	}
+	require.NoError(t, s.Err())
+	require.Equal(t, 1, s.Skipped())
+	require.Len(t, matches, 1)
+	require.Equal(t, 2, matches[0].Game)
+	require.Equal(t, 11, matches[0].Ply)
+	require.Equal(t, []Tag{{"Event", "Two"}}, matches[0].Tags)
+	require.Equal(t, "rnbqkbnr/pp3ppp/4p3/8/2BP4/5N2/PP3PPP/RNBQK2R b KQkq - 0 6", matches[0].Position.ToFen())
+
+	// In the first game, e4 is empty with the black queen at home only at
+	// the start and right after 2. exd5
+	noE4 := Not(PieceOn(W_PAWN, COORDS_TO_SQUARE["e4"]))
+	s = NewPatternScanner(strings.NewReader(pgn), AllOf(noE4, PieceOn(B_QUEEN, COORDS_TO_SQUARE["d8"])))
+	var plies []int
+	for s.Scan() {
+		if s.Match().Game == 0 {
+			plies = append(plies, s.Match().Ply)
+		}
+	}
+	require.Equal(t, []int{0, 3}, plies)
+
+	s = NewPatternScanner(strings.NewReader(pgn), noE4)
+	s.FirstPerGame = true
+	var games []int
+	for s.Scan() {
+		games = append(games, s.Match().Game)
+		require.Zero(t, s.Match().Ply)
+	}
+	require.Equal(t, []int{0, 2}, games)
+}
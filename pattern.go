@@ -0,0 +1,281 @@
+package chessongo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	E_INVALID_SIGNATURE     = "e:invalid:signature"
+	E_INVALID_BOARD_PATTERN = "e:invalid:board-pattern"
+)
+
+// ANY_FILE makes IsolatedPawn look at every file
+const ANY_FILE = -1
+
+// Pattern is a property of positions, such as a material balance or a pawn
+// structure. Patterns look at the bitboards of the position only, so they
+// are cheap enough to be tried at every ply of large game collections.
+type Pattern interface {
+	Match(p *Position) bool
+}
+
+// PatternFunc adapts a function to the Pattern interface
+type PatternFunc func(p *Position) bool
+
+func (f PatternFunc) Match(p *Position) bool {
+	return f(p)
+}
+
+// AllOf matches the positions matching every pattern
+func AllOf(patterns ...Pattern) Pattern {
+	return PatternFunc(func(p *Position) bool {
+		for _, pattern := range patterns {
+			if !pattern.Match(p) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// AnyOf matches the positions matching at least one of the patterns
+func AnyOf(patterns ...Pattern) Pattern {
+	return PatternFunc(func(p *Position) bool {
+		for _, pattern := range patterns {
+			if pattern.Match(p) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Not matches the positions pattern does not match
+func Not(pattern Pattern) Pattern {
+	return PatternFunc(func(p *Position) bool {
+		return !pattern.Match(p)
+	})
+}
+
+// EitherSide matches the positions matching pattern as written or with the
+// colors swapped, e.g. an isolated pawn of either side
+func EitherSide(pattern Pattern) Pattern {
+	return PatternFunc(func(p *Position) bool {
+		if pattern.Match(p) {
+			return true
+		}
+		mirror := p.Mirror()
+		return pattern.Match(&mirror)
+	})
+}
+
+// Mirror returns the position with the board flipped vertically and the
+// colors swapped, which is the same position seen from the other side
+func (p Position) Mirror() Position {
+	var m Position
+	squares, castling, enPassant := flipColors(p.Squares, p.Castling, p.EnPassant)
+	for sq, piece := range squares {
+		if piece != EMPTY {
+			m.addPiece(piece, sq)
+		}
+	}
+	m.Castling = castling
+	m.EnPassant = enPassant
+	m.HalfMoves = p.HalfMoves
+	m.FullMoves = p.FullMoves
+	m.Turn = opponentColor(p.Turn)
+	m.ZobristHash = m.computeZobrist()
+	return m
+}
+
+// PieceOn matches the positions with piece on sq
+func PieceOn(piece Piece, sq Square) Pattern {
+	return PatternFunc(func(p *Position) bool {
+		return p.Squares[sq] == piece
+	})
+}
+
+// AttackedBy matches the positions where a piece like piece (a colored
+// piece, e.g. W_PAWN) attacks or defends sq
+func AttackedBy(sq Square, piece Piece) Pattern {
+	return PatternFunc(func(p *Position) bool {
+		return p.AttackersOf(sq, piece.Color())&p.piecesOf(piece.Color())[piece.Kind()] > 0
+	})
+}
+
+// Returns the squares of the files next to those of bb
+func adjacentFiles(bb Bitboard) Bitboard {
+	return (bb&^FILE_A_MASK)>>1 | (bb&^FILE_H_MASK)<<1
+}
+
+// Returns the files holding a piece of bb
+func filesOf(bb Bitboard) Bitboard {
+	var files Bitboard
+	for f := 0; f < 8; f++ {
+		if fileMask := Bitboard(FILE_A_MASK) << f; bb&fileMask > 0 {
+			files |= fileMask
+		}
+	}
+	return files
+}
+
+// IsolatedPawn matches the positions where color has an isolated pawn, a pawn
+// with no pawn of its color on the files next to it, on file (0 for the a
+// file to 7 for the h file, or ANY_FILE)
+func IsolatedPawn(color Color, file int) Pattern {
+	return PatternFunc(func(p *Position) bool {
+		pawns := p.piecesOf(color)[PAWN]
+		candidates := pawns
+		if file != ANY_FILE {
+			candidates &= Bitboard(FILE_A_MASK) << file
+		}
+		return candidates&^adjacentFiles(filesOf(pawns)) > 0
+	})
+}
+
+// OppositeColoredBishops matches the positions where each side has a single
+// bishop, on squares of different colors
+func OppositeColoredBishops() Pattern {
+	return PatternFunc(func(p *Position) bool {
+		white, black := p.Whites[BISHOP], p.Blacks[BISHOP]
+		if white.NumberOfSetBits() != 1 || black.NumberOfSetBits() != 1 {
+			return false
+		}
+		return (white&LIGHT_SQUARES_MASK > 0) != (black&LIGHT_SQUARES_MASK > 0)
+	})
+}
+
+// Material is the number of pieces of each kind of one side, indexed by kind
+type Material [7]int
+
+func (p *Position) material(color Color) Material {
+	var m Material
+	for kind := PAWN; kind <= KING; kind++ {
+		m[kind] = p.piecesOf(color)[kind].NumberOfSetBits()
+	}
+	return m
+}
+
+var signatureLetters = map[byte]int{'K': KING, 'Q': QUEEN, 'R': ROOK, 'B': BISHOP, 'N': KNIGHT, 'P': PAWN}
+
+// Parses one side of a material signature; the king may be left out
+func parseMaterial(side string) (Material, bool) {
+	var m Material
+	for i := 0; i < len(side); i++ {
+		kind, ok := signatureLetters[side[i]]
+		if !ok {
+			return m, false
+		}
+		m[kind]++
+	}
+	if m[KING] > 1 {
+		return m, false
+	}
+	m[KING] = 1
+	return m, true
+}
+
+// MaterialSignature matches the positions with exactly the material of sig,
+// white's pieces then black's separated by "v", e.g. "KRPvKR" for a rook and
+// pawn against a rook. Wrap it in EitherSide to match either way round.
+func MaterialSignature(sig string) (Pattern, error) {
+	whiteSide, blackSide, ok := strings.Cut(strings.ToUpper(strings.TrimSpace(sig)), "V")
+	if !ok {
+		return nil, fmt.Errorf(E_INVALID_SIGNATURE)
+	}
+	white, okWhite := parseMaterial(whiteSide)
+	black, okBlack := parseMaterial(blackSide)
+	if !okWhite || !okBlack {
+		return nil, fmt.Errorf(E_INVALID_SIGNATURE)
+	}
+	return PatternFunc(func(p *Position) bool {
+		return p.material(WHITE) == white && p.material(BLACK) == black
+	}), nil
+}
+
+// MaterialCount matches the positions where piece (a colored piece, e.g.
+// W_ROOK) occurs between min and max times
+func MaterialCount(piece Piece, min, max int) Pattern {
+	return PatternFunc(func(p *Position) bool {
+		n := p.piecesOf(piece.Color())[piece.Kind()].NumberOfSetBits()
+		return n >= min && n <= max
+	})
+}
+
+// BoardPattern is a partial board: some squares must hold given pieces, some
+// must be empty or occupied, and the others do not matter
+type BoardPattern struct {
+	// Squares that must hold each piece, indexed by kind
+	whites, blacks [7]Bitboard
+	empty          Bitboard
+	occupied       Bitboard
+}
+
+// ParseBoardPattern reads a partial board written like the piece placement
+// of a FEN, from the 8th rank down, where besides the FEN piece letters and
+// digits for empty squares
+//
+//	?  is any square, empty or not
+//	*  is any piece
+//
+// e.g. "????k???/8/8/3P4/8/8/8/????K???" for a white pawn on d5 with the
+// kings on their original squares and nothing else on the 3rd to 7th ranks.
+func ParseBoardPattern(s string) (*BoardPattern, error) {
+	ranks := strings.Split(strings.TrimSpace(s), "/")
+	if len(ranks) != 8 {
+		return nil, fmt.Errorf(E_INVALID_BOARD_PATTERN)
+	}
+	b := &BoardPattern{}
+	for r, rank := range ranks {
+		file := 0
+		for _, c := range rank {
+			if file > 7 {
+				return nil, fmt.Errorf(E_INVALID_BOARD_PATTERN)
+			}
+			bit := Bitboard(1) << (r*8 + file)
+			switch {
+			case c >= '1' && c <= '8':
+				n := int(c - '0')
+				if file+n > 8 {
+					return nil, fmt.Errorf(E_INVALID_BOARD_PATTERN)
+				}
+				for i := 0; i < n; i++ {
+					b.empty |= bit << i
+				}
+				file += n
+				continue
+			case c == '?':
+			case c == '*':
+				b.occupied |= bit
+			default:
+				piece, ok := RUNE_TO_PIECE[c]
+				if !ok {
+					return nil, fmt.Errorf(E_INVALID_BOARD_PATTERN)
+				}
+				if piece.IsWhite() {
+					b.whites[piece.Kind()] |= bit
+				} else {
+					b.blacks[piece.Kind()] |= bit
+				}
+			}
+			file++
+		}
+		if file != 8 {
+			return nil, fmt.Errorf(E_INVALID_BOARD_PATTERN)
+		}
+	}
+	return b, nil
+}
+
+func (b *BoardPattern) Match(p *Position) bool {
+	if p.Occupied&b.empty > 0 || p.Occupied&b.occupied != b.occupied {
+		return false
+	}
+	for kind := PAWN; kind <= KING; kind++ {
+		if p.Whites[kind]&b.whites[kind] != b.whites[kind] || p.Blacks[kind]&b.blacks[kind] != b.blacks[kind] {
+			return false
+		}
+	}
+	return true
+}
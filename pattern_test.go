@@ -0,0 +1,88 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mustPosition(t *testing.T, fen string) *Position {
+	p, err := NewPosition(fen)
+	require.NoError(t, err)
+	return &p
+}
+
+func TestMaterialSignature(t *testing.T) {
+	krpkr, err := MaterialSignature("KRPvKR")
+	require.NoError(t, err)
+	// the king may be left out
+	rpr, err := MaterialSignature("rpvr")
+	require.NoError(t, err)
+
+	p := mustPosition(t, "8/8/3k4/8/1r6/4P3/4K3/7R w - - 0 1")
+	require.True(t, krpkr.Match(p))
+	require.True(t, rpr.Match(p))
+	mirrored := p.Mirror()
+	require.False(t, krpkr.Match(&mirrored))
+	require.True(t, EitherSide(krpkr).Match(&mirrored))
+	require.False(t, krpkr.Match(mustPosition(t, STARTING_POSITION_FEN)))
+
+	for _, bad := range []string{"KRP", "KRXvKR", "KKRvK"} {
+		_, err := MaterialSignature(bad)
+		require.EqualError(t, err, E_INVALID_SIGNATURE, bad)
+	}
+}
+
+func TestMirror(t *testing.T) {
+	p := mustPosition(t, "r3k2r/8/8/8/4pP2/8/8/R3K3 b Qkq f3 0 12")
+	mirrored := p.Mirror()
+	require.Equal(t, "r3k3/8/8/4Pp2/8/8/8/R3K2R w KQq f6 0 12", mirrored.ToFen())
+	require.Equal(t, *p, mirrored.Mirror())
+}
+
+func TestPiecePatterns(t *testing.T) {
+	// white knight on d5 supported by the e4 pawn, isolated pawns on d6 and h2
+	p := mustPosition(t, "4k3/pp3ppp/3p4/3N4/4P3/8/P4P1P/4K3 w - - 0 1")
+	d5 := COORDS_TO_SQUARE["d5"]
+	outpost := AllOf(PieceOn(W_KNIGHT, d5), AttackedBy(d5, W_PAWN))
+	require.True(t, outpost.Match(p))
+	require.False(t, AllOf(PieceOn(W_KNIGHT, d5), AttackedBy(d5, B_PAWN)).Match(p))
+	require.False(t, AllOf(PieceOn(W_BISHOP, d5), AttackedBy(d5, W_PAWN)).Match(p))
+
+	require.True(t, IsolatedPawn(BLACK, 3).Match(p))
+	require.False(t, IsolatedPawn(BLACK, 0).Match(p))
+	require.True(t, IsolatedPawn(WHITE, 7).Match(p))
+	require.True(t, IsolatedPawn(WHITE, ANY_FILE).Match(p))
+	require.False(t, IsolatedPawn(WHITE, 4).Match(p))
+	require.True(t, EitherSide(IsolatedPawn(WHITE, 3)).Match(p))
+
+	require.True(t, AnyOf(Not(outpost), MaterialCount(W_PAWN, 4, 4)).Match(p))
+	require.False(t, MaterialCount(B_PAWN, 0, 5).Match(p))
+}
+
+func TestOppositeColoredBishops(t *testing.T) {
+	withRooks := AllOf(OppositeColoredBishops(), MaterialCount(W_ROOK, 1, 2), MaterialCount(B_ROOK, 1, 2))
+	// c1 is dark, c8 is light
+	require.True(t, withRooks.Match(mustPosition(t, "r1b1k3/8/8/8/8/8/8/R1B1K3 w - - 0 1")))
+	require.False(t, withRooks.Match(mustPosition(t, "r1b1k3/8/8/8/8/8/8/2B1K3 w - - 0 1")))
+	require.False(t, OppositeColoredBishops().Match(mustPosition(t, "r1b1k3/8/8/8/8/8/8/R2BK3 w - - 0 1")))
+	require.False(t, OppositeColoredBishops().Match(mustPosition(t, STARTING_POSITION_FEN)))
+}
+
+func TestBoardPattern(t *testing.T) {
+	b, err := ParseBoardPattern("????k???/8/8/3P4/8/8/8/????K???")
+	require.NoError(t, err)
+	require.True(t, b.Match(mustPosition(t, "r3k3/8/8/3P4/8/8/8/4K2R w - - 0 1")))
+	require.False(t, b.Match(mustPosition(t, "r3k3/8/8/3P4/8/8/8/3K3R w - - 0 1")))
+	require.False(t, b.Match(mustPosition(t, "r3k3/8/2n5/3P4/8/8/8/4K2R w - - 0 1")))
+
+	occupied, err := ParseBoardPattern("????????/????????/????????/???*????/????????/????????/????????/????????")
+	require.NoError(t, err)
+	require.True(t, occupied.Match(mustPosition(t, "4k3/8/8/3n4/8/8/8/4K3 w - - 0 1")))
+	require.False(t, occupied.Match(mustPosition(t, STARTING_POSITION_FEN)))
+
+	for _, bad := range []string{"8/8/8/8/8/8/8", "9/8/8/8/8/8/8/8", "???????/8/8/8/8/8/8/8", "x7/8/8/8/8/8/8/8", "44?/8/8/8/8/8/8/8"} {
+		_, err := ParseBoardPattern(bad)
+		require.EqualError(t, err, E_INVALID_BOARD_PATTERN, bad)
+	}
+}
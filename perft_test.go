@@ -12,12 +12,12 @@ func perft(g *Game, depth int) uint64 {
 	}
 
 	var nodes uint64
-	// Make a copy of moves to avoid issues with shared slices if any,
-	// though Clone() should handle it.
-	moves := make([]Move, len(g.LegalMoves))
-	copy(moves, g.LegalMoves)
+	// The list lives on this frame, so MakeMove regenerating g.LegalMoves
+	// deeper down cannot disturb the iteration.
+	var moves MoveList
+	g.GenerateLegal(&moves)
 
-	for _, m := range moves {
+	for _, m := range moves.Moves() {
 		g.MakeMove(m)
 		nodes += perft(g, depth-1)
 		g.UndoMove(m)
@@ -0,0 +1,158 @@
+package chessongo
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Longest line a PGNScanner accepts
+const maxPGNLine = 1 << 20
+
+// PGNScanner splits a stream of PGN games, such as a PGN file, into games
+// without reading it whole. It is used like bufio.Scanner:
+//
+//	s := NewPGNScanner(r)
+//	for s.Scan() {
+//		g, err := LoadPGNGame(s.Text())
+//		...
+//	}
+//	if err := s.Err(); err != nil {
+//		...
+//	}
+//
+// A game ends with its result token, or where the tags of the next game
+// start.
+type PGNScanner struct {
+	lines *bufio.Scanner
+	game  strings.Builder
+	// Whether the current game has movetext yet
+	inMoves bool
+	// Open { comments carried over from previous lines
+	braceDepth int
+	text       string
+	// Line read but belonging to the next game
+	pending    string
+	hasPending bool
+}
+
+func NewPGNScanner(r io.Reader) *PGNScanner {
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 0, 64*1024), maxPGNLine)
+	return &PGNScanner{lines: lines}
+}
+
+// Scan advances to the next game, reporting whether there is one
+func (s *PGNScanner) Scan() bool {
+	s.game.Reset()
+	s.inMoves = false
+	s.braceDepth = 0
+	for {
+		var line string
+		if s.hasPending {
+			line, s.hasPending = s.pending, false
+		} else if s.lines.Scan() {
+			line = s.lines.Text()
+		} else {
+			return s.finish()
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "%") {
+			// escaped line
+			continue
+		}
+		if s.braceDepth == 0 && strings.HasPrefix(trimmed, "[") && s.inMoves {
+			s.pending, s.hasPending = line, true
+			return s.finish()
+		}
+		if trimmed == "" && s.game.Len() == 0 {
+			continue
+		}
+		s.game.WriteString(line)
+		s.game.WriteByte('\n')
+		if trimmed == "" || (s.braceDepth == 0 && strings.HasPrefix(trimmed, "[")) {
+			continue
+		}
+		s.inMoves = true
+		if s.endsWithResult(trimmed) {
+			return s.finish()
+		}
+	}
+}
+
+// Emits the current game, if any
+func (s *PGNScanner) finish() bool {
+	s.text = s.game.String()
+	return strings.TrimSpace(s.text) != ""
+}
+
+// Tracks the comments of a movetext line and tells whether its last token,
+// outside comments and variations, is a result
+func (s *PGNScanner) endsWithResult(line string) bool {
+	last := ""
+	parenDepth := 0
+	for _, tok := range strings.Fields(line) {
+		for _, c := range tok {
+			switch c {
+			case '{':
+				s.braceDepth++
+			case '}':
+				s.braceDepth = max(s.braceDepth-1, 0)
+			case '(':
+				if s.braceDepth == 0 {
+					parenDepth++
+				}
+			case ')':
+				if s.braceDepth == 0 {
+					parenDepth = max(parenDepth-1, 0)
+				}
+			}
+		}
+		if s.braceDepth == 0 && parenDepth == 0 {
+			last = tok
+		}
+	}
+	return s.braceDepth == 0 && parenDepth == 0 && isPGNResult(last)
+}
+
+// Text returns the game found by the last call to Scan
+func (s *PGNScanner) Text() string {
+	return s.text
+}
+
+// Err returns the first error reading the stream, nil at the end of it
+func (s *PGNScanner) Err() error {
+	return s.lines.Err()
+}
+
+// ParsePGNTags returns the tags of the header of pgn, in order
+func ParsePGNTags(pgn string) []Tag {
+	var tags []Tag
+	for line := range strings.Lines(pgn) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+			break
+		}
+		if tag, ok := parsePGNTag(line[1 : len(line)-1]); ok {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Parses the inside of a tag line, e.g. `White "Tal, Mikhail"`
+func parsePGNTag(s string) (Tag, bool) {
+	name, value, ok := strings.Cut(strings.TrimSpace(s), " ")
+	value = strings.TrimSpace(value)
+	if !ok || len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return Tag{}, false
+	}
+	value = value[1 : len(value)-1]
+	if strings.Contains(value, `\`) {
+		value = strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(value)
+	}
+	return Tag{Name: name, Value: value}, true
+}
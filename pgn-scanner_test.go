@@ -0,0 +1,60 @@
+package chessongo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const scannerTestPGN = `[Event "First"]
+[White "Tal, Mikhail"]
+[Black "Say \"hi\""]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+
+% escaped line
+[Event "Second"]
+[Result "*"]
+
+1. d4 {a comment
+[that looks like a tag]} d5 (1... Nf6 2. c4 1-0) 2. c4
+[Event "Third"]
+
+1. c4 *
+1. Nf3 Nf6 1/2-1/2
+`
+
+func TestPGNScanner(t *testing.T) {
+	s := NewPGNScanner(strings.NewReader(scannerTestPGN))
+	var games []string
+	for s.Scan() {
+		games = append(games, s.Text())
+	}
+	require.NoError(t, s.Err())
+	require.Len(t, games, 4)
+
+	require.Equal(t, []Tag{{"Event", "First"}, {"White", "Tal, Mikhail"}, {"Black", `Say "hi"`}, {"Result", "1-0"}}, ParsePGNTags(games[0]))
+	require.Equal(t, []Tag{{"Event", "Second"}, {"Result", "*"}}, ParsePGNTags(games[1]))
+	require.Contains(t, games[1], "[that looks like a tag]")
+	require.Equal(t, []Tag{{"Event", "Third"}}, ParsePGNTags(games[2]))
+	require.Empty(t, ParsePGNTags(games[3]))
+
+	for i, fen := range []string{
+		"r1bqkb1r/pppp1Qpp/2n2n2/4p3/2B1P3/8/PPPP1PPP/RNB1K1NR b KQkq - 0 4",
+		"rnbqkbnr/ppp1pppp/8/3p4/2PP4/8/PP2PPPP/RNBQKBNR b KQkq c3 0 2",
+		"rnbqkbnr/pppppppp/8/8/2P5/8/PP1PPPPP/RNBQKBNR b KQkq c3 0 1",
+		"rnbqkb1r/pppppppp/5n2/8/8/5N2/PPPPPPPP/RNBQKB1R w KQkq - 2 2",
+	} {
+		g, err := LoadPGNGame(games[i])
+		require.NoError(t, err, games[i])
+		require.Equal(t, fen, g.ToFen())
+	}
+}
+
+func TestPGNScannerEmpty(t *testing.T) {
+	s := NewPGNScanner(strings.NewReader("\n\n  \n"))
+	require.False(t, s.Scan())
+	require.NoError(t, s.Err())
+}
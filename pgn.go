@@ -10,6 +10,19 @@ import (
 // recording position history via Zobrist hashing. Variations and comments are
 // ignored; only the main line is applied.
 func (g *Game) LoadPGN(pgn string) error {
+	// Observers hear about the loaded game as a whole, not about every move
+	observers := g.observers
+	g.observers = nil
+	err := g.loadPGN(pgn)
+	g.observers = observers
+	if err != nil {
+		return err
+	}
+	g.emitLoaded(SOURCE_PGN)
+	return nil
+}
+
+func (g *Game) loadPGN(pgn string) error {
 	fastPath := !strings.ContainsAny(pgn, "[{(")
 	startFEN := STARTING_POSITION_FEN
 	if !fastPath {
@@ -46,27 +59,12 @@ func (g *Game) LoadPGN(pgn string) error {
 			continue
 		}
 
-		target := getTargetSquare(tok)
-
-		// b.GenerateLegalMoves() is already done by LoadFen (initially) and MakeMove (subsequently).
-		matched := false
-		for _, mv := range g.LegalMoves {
-			if target != -1 && int(mv.To()) != target {
-				continue
-			}
-
-			// Optimization: GetMoveSanWithoutSuffix avoids cloning the board (to check for check/mate)
-			// which is very expensive. We strip annotations from the token anyway.
-			san := trimSANAnnotations(g.GetMoveSanWithoutSuffix(mv))
-			if san == tok {
-				g.MakeMove(mv)
-				matched = true
-				break
-			}
-		}
-		if !matched {
+		// g.LegalMoves is already generated above (initially) and by MakeMove (subsequently).
+		mv, err := g.ParseSan(tok)
+		if err != nil {
 			return fmt.Errorf("pgn move not found: %s", tok)
 		}
+		g.MakeMove(mv)
 	}
 
 	return nil
@@ -155,7 +153,7 @@ func tokenizePGNMoves(pgn string) []string {
 	for i := 0; i < len(pgn); i++ {
 		c := pgn[i]
 		if lineStart {
-			inTag = c == '['
+			inTag = c == '[' && braceDepth == 0
 		}
 		if c == '\n' {
 			lineStart = true
@@ -16,6 +16,17 @@ const (
 	KING          // 110
 )
 
+// Conventional material values in centipawns, indexed by kind
+var PIECE_VALUES = [7]int{
+	EMPTY:  0,
+	PAWN:   100,
+	KNIGHT: 320,
+	BISHOP: 330,
+	ROOK:   500,
+	QUEEN:  900,
+	KING:   20000,
+}
+
 const WHITE_MASK = 0x8
 const BLACK_MASK = 0x10
 
@@ -39,10 +50,23 @@ type Color uint8
 
 type Piece uint8
 
+// Returns the other color
+func opponentColor(c Color) Color {
+	if c == WHITE {
+		return BLACK
+	}
+	return WHITE
+}
+
 func (p Piece) Kind() Piece {
 	return Piece(p & (0x7))
 }
 
+// Returns the material value of the piece, see PIECE_VALUES
+func (p Piece) Value() int {
+	return PIECE_VALUES[p.Kind()]
+}
+
 func (p Piece) IsWhite() bool {
 	return p&WHITE_MASK > 0
 }
@@ -0,0 +1,154 @@
+package chessongo
+
+// Pin describes a piece that cannot leave the line between an enemy slider
+// and a piece of its own without exposing the latter
+type Pin struct {
+	// The pinned piece
+	Pinned Square
+	// The enemy slider pinning it
+	Pinner Square
+	// The piece behind the pinned one
+	Target Square
+	// The squares from the pinner up to, but not including, the target. The
+	// pinned piece may still move within the ray.
+	Ray Bitboard
+	// Set when the target is the king: the pinned piece may not leave the
+	// ray at all. Otherwise the target is worth more than the pinned piece.
+	Absolute bool
+}
+
+// DiscoveredAttack describes a piece that unmasks an attack by a slider of
+// its own color when it moves away
+type DiscoveredAttack struct {
+	// The piece that has to move
+	Mover Square
+	// The slider whose line opens
+	Slider Square
+	// The enemy piece that will be attacked
+	Target Square
+}
+
+// Tells whether a slider of the given kind moves along direction
+func slidesAlong(kind Piece, direction Direction) bool {
+	switch kind {
+	case QUEEN:
+		return true
+	case ROOK:
+		return direction <= DIRECTION_W
+	case BISHOP:
+		return direction >= DIRECTION_NE
+	}
+	return false
+}
+
+// Returns the closest occupied square in occupied seen from sq towards direction, -1 if none
+func nextBlocker(sq Square, direction Direction, occupied Bitboard) int {
+	blockers := RAY_MASKS[direction][sq] & occupied
+	if blockers == 0 {
+		return -1
+	}
+	if DIRECTION_LSB_MSP[direction] == LSB {
+		return int(blockers.lsbIndex())
+	}
+	return blockers.msbIndex()
+}
+
+// Pins returns every piece of the given color that is pinned by an enemy
+// slider, either to its king (absolute) or to a more valuable piece
+// (relative)
+func (g *Game) Pins(color Color) []Pin {
+	var pins []Pin
+	enemy := g.piecesOf(opponentColor(color))
+	for sliders := enemy[BISHOP] | enemy[ROOK] | enemy[QUEEN]; sliders > 0; {
+		pinner := Square(sliders.popLSB())
+		for _, direction := range ALL_DIRECTIONS {
+			if !slidesAlong(g.Squares[pinner].Kind(), direction) {
+				continue
+			}
+			first := nextBlocker(pinner, direction, g.Occupied)
+			if first < 0 || g.Squares[first].Color() != color {
+				continue
+			}
+			second := nextBlocker(Square(first), direction, g.Occupied)
+			if second < 0 || g.Squares[second].Color() != color {
+				continue
+			}
+			pinned, target := g.Squares[first], g.Squares[second]
+			absolute := target.Kind() == KING
+			if !absolute && target.Value() <= pinned.Value() {
+				continue
+			}
+			ray := (RAY_MASKS[direction][pinner] &^ RAY_MASKS[direction][second]) &^ (Bitboard(1) << uint(second))
+			pins = append(pins, Pin{
+				Pinned:   Square(first),
+				Pinner:   pinner,
+				Target:   Square(second),
+				Ray:      ray | Bitboard(1)<<pinner,
+				Absolute: absolute,
+			})
+		}
+	}
+	return pins
+}
+
+// PinnedPieces returns the pieces of the given color pinned to their king
+func (g *Game) PinnedPieces(color Color) Bitboard {
+	var pinned Bitboard
+	for _, pin := range g.Pins(color) {
+		if pin.Absolute {
+			pinned |= Bitboard(1) << pin.Pinned
+		}
+	}
+	return pinned
+}
+
+// XRayAttackers returns the sliders of the given color that attack sq
+// through exactly one piece of either color standing in between
+func (g *Game) XRayAttackers(sq Square, by Color) Bitboard {
+	var xrays Bitboard
+	for _, direction := range ALL_DIRECTIONS {
+		first := nextBlocker(sq, direction, g.Occupied)
+		if first < 0 {
+			continue
+		}
+		second := nextBlocker(Square(first), direction, g.Occupied)
+		if second < 0 {
+			continue
+		}
+		piece := g.Squares[second]
+		if piece.Color() == by && slidesAlong(piece.Kind(), direction) {
+			xrays |= Bitboard(1) << uint(second)
+		}
+	}
+	return xrays
+}
+
+// DiscoveredAttacks returns every piece of the given color that would
+// unmask an attack on an enemy piece by one of its own sliders when moving
+// off the line. A target on the enemy king means a discovered check.
+func (g *Game) DiscoveredAttacks(color Color) []DiscoveredAttack {
+	var discovered []DiscoveredAttack
+	ours := g.piecesOf(color)
+	for sliders := ours[BISHOP] | ours[ROOK] | ours[QUEEN]; sliders > 0; {
+		slider := Square(sliders.popLSB())
+		for _, direction := range ALL_DIRECTIONS {
+			if !slidesAlong(g.Squares[slider].Kind(), direction) {
+				continue
+			}
+			first := nextBlocker(slider, direction, g.Occupied)
+			if first < 0 || g.Squares[first].Color() != color {
+				continue
+			}
+			second := nextBlocker(Square(first), direction, g.Occupied)
+			if second < 0 || g.Squares[second].Color() != opponentColor(color) {
+				continue
+			}
+			discovered = append(discovered, DiscoveredAttack{
+				Mover:  Square(first),
+				Slider: slider,
+				Target: Square(second),
+			})
+		}
+	}
+	return discovered
+}
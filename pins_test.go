@@ -0,0 +1,66 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPins(t *testing.T) {
+	g := &Game{}
+	// The knight is pinned to the king by the rook, the d7 pawn is pinned to
+	// the queen on c8 by the bishop, and the bishop on g7 is not pinned at all.
+	require.NoError(t, g.LoadFen("2q5/3p2b1/4B3/8/R3n2k/8/8/K7 b - - 0 1"))
+	pins := g.Pins(BLACK)
+	require.Len(t, pins, 2)
+
+	byPinned := map[Square]Pin{}
+	for _, p := range pins {
+		byPinned[p.Pinned] = p
+	}
+	knight := byPinned[COORDS_TO_SQUARE["e4"]]
+	require.True(t, knight.Absolute)
+	require.Equal(t, COORDS_TO_SQUARE["a4"], knight.Pinner)
+	require.Equal(t, COORDS_TO_SQUARE["h4"], knight.Target)
+	require.Equal(t, squaresBB("a4", "b4", "c4", "d4", "e4", "f4", "g4"), knight.Ray)
+
+	pawn := byPinned[COORDS_TO_SQUARE["d7"]]
+	require.False(t, pawn.Absolute)
+	require.Equal(t, COORDS_TO_SQUARE["e6"], pawn.Pinner)
+	require.Equal(t, COORDS_TO_SQUARE["c8"], pawn.Target)
+	require.Equal(t, squaresBB("e6", "d7"), pawn.Ray)
+
+	require.Equal(t, squaresBB("e4"), g.PinnedPieces(BLACK))
+	require.Empty(t, g.Pins(WHITE))
+}
+
+func TestPinsIgnoreLessValuableTarget(t *testing.T) {
+	g := &Game{}
+	// The queen stands in front of a pawn: not a pin.
+	require.NoError(t, g.LoadFen("4k3/8/2p5/3q4/8/8/6B1/4K3 b - - 0 1"))
+	require.Empty(t, g.Pins(BLACK))
+}
+
+func TestXRayAttackers(t *testing.T) {
+	g := &Game{}
+	// The rooks are doubled on the d-file, the queen stands behind the bishop.
+	require.NoError(t, g.LoadFen("3r2k1/3r4/8/8/3P4/8/1B6/Q5K1 w - - 0 1"))
+	require.Equal(t, squaresBB("d7"), g.AttackersOf(COORDS_TO_SQUARE["d4"], BLACK))
+	require.Equal(t, squaresBB("d8"), g.XRayAttackers(COORDS_TO_SQUARE["d4"], BLACK))
+	require.Equal(t, squaresBB("a1"), g.XRayAttackers(COORDS_TO_SQUARE["c3"], WHITE)&squaresBB("a1"))
+	// the queen sees d4 through the bishop on b2
+	require.Equal(t, squaresBB("a1"), g.XRayAttackers(COORDS_TO_SQUARE["d4"], WHITE))
+}
+
+func TestDiscoveredAttacks(t *testing.T) {
+	g := &Game{}
+	// Moving the knight off the e-file gives a discovered check.
+	require.NoError(t, g.LoadFen("4k3/8/8/8/4N3/8/8/4R1K1 w - - 0 1"))
+	discovered := g.DiscoveredAttacks(WHITE)
+	require.Equal(t, []DiscoveredAttack{{
+		Mover:  COORDS_TO_SQUARE["e4"],
+		Slider: COORDS_TO_SQUARE["e1"],
+		Target: COORDS_TO_SQUARE["e8"],
+	}}, discovered)
+	require.Empty(t, g.DiscoveredAttacks(BLACK))
+}
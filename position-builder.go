@@ -0,0 +1,242 @@
+package chessongo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const E_INVALID_POSITION = "e:invalid:position"
+
+// PositionError is returned when a built position is not playable
+type PositionError struct {
+	Problems []FenProblem
+}
+
+func (e *PositionError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		messages[i] = p.Message
+	}
+	return E_INVALID_POSITION + ": " + strings.Join(messages, "; ")
+}
+
+// PositionBuilder sets up a position piece by piece. Setters return the
+// builder so calls can be chained; nothing is checked until Commit, which
+// also reports the invalid squares, pieces and colors given to the setters.
+type PositionBuilder struct {
+	squares   [64]Piece
+	problems  []FenProblem
+	turn      Color
+	castling  int
+	enPassant Square
+	halfMoves int
+	fullMoves int
+}
+
+// NewPositionBuilder returns a builder for an empty board with white to move
+func NewPositionBuilder() *PositionBuilder {
+	return &PositionBuilder{turn: WHITE, fullMoves: 1}
+}
+
+// Builder returns a builder initialised with the current position
+func (g *Game) Builder() *PositionBuilder {
+	return &PositionBuilder{
+		squares:   g.Squares,
+		turn:      g.Turn,
+		castling:  g.Castling,
+		enPassant: g.EnPassant,
+		halfMoves: g.HalfMoves,
+		fullMoves: g.FullMoves,
+	}
+}
+
+// Puts piece on sq, replacing whatever was there
+func (b *PositionBuilder) SetPiece(sq Square, piece Piece) *PositionBuilder {
+	if _, ok := PIECE_TO_RUNE[piece]; piece != EMPTY && !ok {
+		b.problem(E_INVALID_PIECE, FEN_FIELD_PLACEMENT, "piece %d does not exist", piece)
+		return b
+	}
+	if b.onBoard(sq) {
+		b.squares[sq] = piece
+	}
+	return b
+}
+
+// Removes the piece on sq
+func (b *PositionBuilder) Clear(sq Square) *PositionBuilder {
+	if b.onBoard(sq) {
+		b.squares[sq] = EMPTY
+	}
+	return b
+}
+
+// Tells whether sq is on the board, recording a problem for Commit if not
+func (b *PositionBuilder) onBoard(sq Square) bool {
+	if int(sq) < len(b.squares) {
+		return true
+	}
+	b.problem(E_INVALID_SQUARE, FEN_FIELD_PLACEMENT, "square %d is off the board", sq)
+	return false
+}
+
+// Records a problem for Commit
+func (b *PositionBuilder) problem(code string, field int, format string, args ...any) {
+	b.problems = append(b.problems, FenProblem{
+		Code:    code,
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Removes every piece from the board
+func (b *PositionBuilder) ClearAll() *PositionBuilder {
+	b.squares = [64]Piece{}
+	return b
+}
+
+// Sets the side to move, WHITE or BLACK
+func (b *PositionBuilder) SetTurn(turn Color) *PositionBuilder {
+	if turn != WHITE && turn != BLACK {
+		b.problem(E_FEN_TURN, FEN_FIELD_TURN, "side to move must be white or black, got %d", turn)
+		return b
+	}
+	b.turn = turn
+	return b
+}
+
+// Sets the castling rights (CASTLE_* flags). Rights that the board does not
+// support (king or rook not on its original square) are dropped, here and
+// again on Commit.
+func (b *PositionBuilder) SetCastling(castling int) *PositionBuilder {
+	b.castling = castling & (CASTLE_WKS | CASTLE_WQS | CASTLE_BKS | CASTLE_BQS)
+	b.pruneCastling()
+	return b
+}
+
+// Sets the en-passant target square, 0 for none
+func (b *PositionBuilder) SetEnPassant(sq Square) *PositionBuilder {
+	if sq > 63 {
+		b.problem(E_INVALID_SQUARE, FEN_FIELD_EN_PASSANT, "en-passant square %d is off the board", sq)
+		return b
+	}
+	b.enPassant = sq
+	return b
+}
+
+func (b *PositionBuilder) SetClocks(halfMoves, fullMoves int) *PositionBuilder {
+	b.halfMoves = halfMoves
+	b.fullMoves = fullMoves
+	return b
+}
+
+// Mirrors the board top to bottom, keeping piece colors
+func (b *PositionBuilder) MirrorVertical() *PositionBuilder {
+	var squares [64]Piece
+	for sq, piece := range b.squares {
+		squares[sq^56] = piece
+	}
+	b.squares = squares
+	if b.enPassant != 0 {
+		b.enPassant ^= 56
+	}
+	b.pruneCastling()
+	return b
+}
+
+// Mirrors the board left to right
+func (b *PositionBuilder) MirrorHorizontal() *PositionBuilder {
+	var squares [64]Piece
+	for sq, piece := range b.squares {
+		squares[sq^7] = piece
+	}
+	b.squares = squares
+	if b.enPassant != 0 {
+		b.enPassant ^= 7
+	}
+	b.pruneCastling()
+	return b
+}
+
+// Mirrors the board top to bottom and swaps the colors of all pieces, the
+// side to move and the castling rights. The result is the same position seen
+// from the other side.
+func (b *PositionBuilder) FlipColors() *PositionBuilder {
+	b.squares, b.castling, b.enPassant = flipColors(b.squares, b.castling, b.enPassant)
+	b.turn = opponentColor(b.turn)
+	b.pruneCastling()
+	return b
+}
+
+// Returns the board mirrored top to bottom with the colors of the pieces
+// swapped, and the castling rights and en-passant square that go with it
+func flipColors(squares [64]Piece, castling int, enPassant Square) ([64]Piece, int, Square) {
+	var flipped [64]Piece
+	for sq, piece := range squares {
+		if piece != EMPTY {
+			piece = Piece(uint(piece.Kind()) | uint(opponentColor(piece.Color())))
+		}
+		flipped[sq^56] = piece
+	}
+	if enPassant != 0 {
+		enPassant ^= 56
+	}
+	castling = (castling&(CASTLE_WKS|CASTLE_WQS))<<2 | (castling&(CASTLE_BKS|CASTLE_BQS))>>2
+	return flipped, castling, enPassant
+}
+
+// Drops castling rights whose king or rook is not on its original square
+func (b *PositionBuilder) pruneCastling() {
+	rights := []struct {
+		right          int
+		king, rook     Piece
+		kingSq, rookSq Square
+	}{
+		{CASTLE_WKS, W_KING, W_ROOK, W_KING_INIT_SQUARE, WKS_ROOK_ORIGINAL_SQUARE},
+		{CASTLE_WQS, W_KING, W_ROOK, W_KING_INIT_SQUARE, WQS_ROOK_ORIGINAL_SQUARE},
+		{CASTLE_BKS, B_KING, B_ROOK, B_KING_INIT_SQUARE, BKS_ROOK_ORIGINAL_SQUARE},
+		{CASTLE_BQS, B_KING, B_ROOK, B_KING_INIT_SQUARE, BQS_ROOK_ORIGINAL_SQUARE},
+	}
+	for _, r := range rights {
+		if b.squares[r.kingSq] != r.king || b.squares[r.rookSq] != r.rook {
+			b.castling &= ^r.right
+		}
+	}
+}
+
+// Commit validates the position and returns a new game starting from it
+func (b *PositionBuilder) Commit() (*Game, error) {
+	g := &Game{}
+	if err := b.CommitTo(g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// CommitTo validates the position and loads it into g, recomputing the
+// hash, legal moves and status. g is left untouched if validation fails.
+func (b *PositionBuilder) CommitTo(g *Game) error {
+	if len(b.problems) > 0 {
+		return &PositionError{Problems: b.problems}
+	}
+	b.pruneCastling()
+	candidate := Game{}
+	candidate.Reset()
+	for sq, piece := range b.squares {
+		candidate.addPiece(piece, sq)
+	}
+	candidate.Turn = b.turn
+	candidate.Castling = b.castling
+	candidate.EnPassant = b.enPassant
+	candidate.HalfMoves = b.halfMoves
+	candidate.FullMoves = b.fullMoves
+	if problems := candidate.Validate(FEN_LEGAL_FOR_PLAY); len(problems) > 0 {
+		return &PositionError{Problems: problems}
+	}
+
+	g.replaceWith(&candidate)
+	g.Fen = g.ToFen()
+	g.recordPosition()
+	g.refreshStatus()
+	g.emitLoaded(SOURCE_BUILDER)
+	return nil
+}
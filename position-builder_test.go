@@ -0,0 +1,93 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPositionBuilderCommit(t *testing.T) {
+	g, err := NewPositionBuilder().
+		SetPiece(COORDS_TO_SQUARE["e1"], W_KING).
+		SetPiece(COORDS_TO_SQUARE["h1"], W_ROOK).
+		SetPiece(COORDS_TO_SQUARE["e8"], B_KING).
+		SetCastling(CASTLE_WKS|CASTLE_WQS|CASTLE_BKS).
+		SetClocks(3, 20).
+		Commit()
+	require.NoError(t, err)
+	require.Equal(t, "4k3/8/8/8/8/8/8/4K2R w K - 3 20", g.ToFen())
+	require.Equal(t, g.computeZobrist(), g.ZobristHash)
+	require.Equal(t, 1, g.RepetitionCount())
+	// 5 king moves, 9 rook moves and castling
+	require.Len(t, g.LegalMoves, 15)
+}
+
+func TestPositionBuilderEditsGame(t *testing.T) {
+	g := NewGame()
+	err := g.Builder().
+		Clear(COORDS_TO_SQUARE["e2"]).
+		SetPiece(COORDS_TO_SQUARE["e4"], W_PAWN).
+		SetTurn(BLACK).
+		SetEnPassant(COORDS_TO_SQUARE["e3"]).
+		CommitTo(g)
+	require.NoError(t, err)
+	require.Equal(t, "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", g.ToFen())
+	require.Len(t, g.LegalMoves, 20)
+
+	// Removing the h1 rook drops the white king side castling right.
+	require.NoError(t, g.Builder().Clear(COORDS_TO_SQUARE["h1"]).CommitTo(g))
+	require.Equal(t, CASTLE_WQS|CASTLE_BKS|CASTLE_BQS, g.Castling)
+}
+
+func TestPositionBuilderRejectsInvalidPosition(t *testing.T) {
+	g := NewGame()
+	fen := g.ToFen()
+	err := g.Builder().Clear(COORDS_TO_SQUARE["e8"]).CommitTo(g)
+	require.Error(t, err)
+	positionErr, ok := err.(*PositionError)
+	require.True(t, ok)
+	require.Equal(t, E_FEN_MISSING_KING, positionErr.Problems[0].Code)
+	require.Equal(t, fen, g.ToFen())
+}
+
+func TestPositionBuilderRejectsSquareOffBoard(t *testing.T) {
+	g := NewGame()
+	fen := g.ToFen()
+	err := g.Builder().SetPiece(64, W_QUEEN).Clear(100).CommitTo(g)
+	positionErr, ok := err.(*PositionError)
+	require.True(t, ok)
+	require.Len(t, positionErr.Problems, 2)
+	require.Equal(t, E_INVALID_SQUARE, positionErr.Problems[0].Code)
+	require.Equal(t, fen, g.ToFen())
+}
+
+func TestPositionBuilderRejectsInvalidValues(t *testing.T) {
+	g := NewGame()
+	fen := g.ToFen()
+	err := g.Builder().SetPiece(COORDS_TO_SQUARE["a2"], Piece(31)).SetTurn(Color(3)).SetEnPassant(70).CommitTo(g)
+	positionErr, ok := err.(*PositionError)
+	require.True(t, ok)
+	codes := []string{}
+	for _, problem := range positionErr.Problems {
+		codes = append(codes, problem.Code)
+	}
+	require.Equal(t, []string{E_INVALID_PIECE, E_FEN_TURN, E_INVALID_SQUARE}, codes)
+	require.Equal(t, FEN_FIELD_EN_PASSANT, positionErr.Problems[2].Field)
+	require.Equal(t, fen, g.ToFen())
+}
+
+func TestPositionBuilderMirrors(t *testing.T) {
+	g := &Game{}
+	require.NoError(t, g.LoadFen("r3k3/8/8/8/4P3/8/8/4K2R b Kq e3 0 1"))
+
+	flipped, err := g.Builder().FlipColors().Commit()
+	require.NoError(t, err)
+	require.Equal(t, "4k2r/8/8/4p3/8/8/8/R3K3 w Qk e6 0 1", flipped.ToFen())
+
+	mirrored, err := g.Builder().MirrorHorizontal().Commit()
+	require.NoError(t, err)
+	require.Equal(t, "3k3r/8/8/8/3P4/8/8/R2K4 b - d3 0 1", mirrored.ToFen())
+
+	_, err = g.Builder().MirrorVertical().Commit()
+	require.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package chessongo
+
+// Position is the state of the board at one moment: where the pieces are,
+// whose turn it is, castling and en-passant rights, the move clocks and the
+// Zobrist hash. It holds no slices or maps, so it is copied by plain
+// assignment and a copy can be shared with other goroutines or stored in a
+// tree without aliasing anything.
+//
+// The exported methods of Position never modify it; Play returns the
+// position after a move instead.
+type Position struct {
+	WhitePieces Bitboard
+	BlackPieces Bitboard
+	// _, pawns, knights, bishops, rooks, queens, king
+	Whites      [7]Bitboard
+	Blacks      [7]Bitboard
+	Occupied    Bitboard
+	Squares     [64]Piece
+	EnPassant   Square
+	Castling    int
+	HalfMoves   int
+	FullMoves   int
+	Turn        Color
+	ZobristHash uint64
+}
+
+// NewPosition parses fen into a Position
+func NewPosition(fen string) (Position, error) {
+	g := Game{}
+	if err := g.LoadFen(fen); err != nil {
+		return Position{}, err
+	}
+	return g.Position, nil
+}
+
+// NewGameFromPosition starts a new game, without history, from p
+func NewGameFromPosition(p Position) *Game {
+	g := &Game{}
+	g.Reset()
+	g.Position = p
+	g.Fen = g.ToFen()
+	g.recordPosition()
+	g.refreshStatus()
+	return g
+}
+
+// InCheck tells whether the side to move is in check
+func (p Position) InCheck() bool {
+	return p.ComputeIsCheck()
+}
+
+// LegalMoves returns the legal moves of the side to move
+func (p Position) LegalMoves() []Move {
+	var buf [maxGeneratedMoves]Move
+	inCheck := p.ComputeIsCheck()
+	var moves []Move
+	for _, m := range p.generateMoves(buf[:0], GEN_ALL) {
+		if p.canMove(m, inCheck) {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// Play returns the position reached by playing m, which must be legal
+func (p Position) Play(m Move) Position {
+	next := p
+	next.applyMove(m)
+	next.ZobristHash = next.computeZobrist()
+	return next
+}
+
+// Checks whether the pseudo-legal move m is legal. inCheck must tell whether
+// the side to move is currently in check.
+func (p *Position) canMove(m Move, inCheck bool) bool {
+	if m.IsCastlingMove() {
+		var inBetweenSq Square
+		if m.To() == WKS_KING_TO_SQUARE || m.To() == BKS_KING_TO_SQUARE {
+			inBetweenSq = m.From() + 1
+		} else if m.To() == WQS_KING_TO_SQUARE || m.To() == BQS_KING_TO_SQUARE {
+			inBetweenSq = m.From() - 1
+		}
+		inBetweenMove := NewMove(m.From(), inBetweenSq, EMPTY)
+		if inCheck || p.WillMoveCauseCheck(inBetweenMove) {
+			return false
+		}
+	}
+	return !p.WillMoveCauseCheck(m)
+}
+
+// Plays m on the board and updates the rights, clocks and side to move. The
+// hash is left for the caller to recompute.
+func (p *Position) applyMove(m Move) {
+	if p.ShouldResetPly(m) {
+		p.HalfMoves = 0
+	} else {
+		p.HalfMoves++
+	}
+	if p.ShouldIncFullMoves(m) {
+		p.FullMoves++
+	}
+
+	p.justMove(m)
+	kind := p.Squares[m.To()].Kind()
+	if kind == KING {
+		if p.Turn == WHITE {
+			p.Castling &= ^(CASTLE_WKS | CASTLE_WQS)
+		} else {
+			p.Castling &= ^(CASTLE_BKS | CASTLE_BQS)
+		}
+	}
+	if kind == ROOK {
+		switch m.From() {
+		case WKS_ROOK_ORIGINAL_SQUARE:
+			p.Castling &= ^CASTLE_WKS
+		case WQS_ROOK_ORIGINAL_SQUARE:
+			p.Castling &= ^CASTLE_WQS
+		case BKS_ROOK_ORIGINAL_SQUARE:
+			p.Castling &= ^CASTLE_BKS
+		case BQS_ROOK_ORIGINAL_SQUARE:
+			p.Castling &= ^CASTLE_BQS
+		}
+	}
+
+	switch m.To() {
+	case WKS_ROOK_ORIGINAL_SQUARE:
+		p.Castling &= ^CASTLE_WKS
+	case WQS_ROOK_ORIGINAL_SQUARE:
+		p.Castling &= ^CASTLE_WQS
+	case BKS_ROOK_ORIGINAL_SQUARE:
+		p.Castling &= ^CASTLE_BKS
+	case BQS_ROOK_ORIGINAL_SQUARE:
+		p.Castling &= ^CASTLE_BQS
+	}
+	// enPassant target
+	p.EnPassant = 0
+	if kind == PAWN && p.Turn == WHITE {
+		if m.From().Rank() == 6 && m.To().Rank() == 4 {
+			p.EnPassant = m.From() - 8
+		}
+	}
+	if kind == PAWN && p.Turn == BLACK {
+		if m.From().Rank() == 1 && m.To().Rank() == 3 {
+			p.EnPassant = m.From() + 8
+		}
+	}
+
+	if p.Turn == WHITE {
+		p.Turn = BLACK
+	} else {
+		p.Turn = WHITE
+	}
+}
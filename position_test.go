@@ -0,0 +1,87 @@
+package chessongo
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPositionPlayMatchesMakeMove(t *testing.T) {
+	for _, fen := range stagedTestFens {
+		g := &Game{}
+		require.NoError(t, g.LoadFen(fen))
+		g.GenerateLegalMoves()
+		pos := g.Position
+		for _, m := range g.LegalMoves {
+			next := pos.Play(m)
+			g.MakeMove(m)
+			require.Equal(t, g.Position, next, fen)
+			g.UndoMove(m)
+		}
+		require.Equal(t, g.Position, pos, "Play must not modify the position")
+	}
+}
+
+func TestPositionLegalMovesAndInCheck(t *testing.T) {
+	pos, err := NewPosition("4k3/8/8/8/1b6/8/8/R3K2R w KQ - 0 1")
+	require.NoError(t, err)
+	require.True(t, pos.InCheck())
+
+	g := NewGameFromPosition(pos)
+	require.Equal(t, g.LegalMoves, pos.LegalMoves())
+	require.True(t, g.IsCheck)
+	require.Empty(t, g.History)
+
+	// castling out of check is not allowed
+	for _, m := range pos.LegalMoves() {
+		require.False(t, m.IsCastlingMove())
+	}
+
+	_, err = NewPosition("not a fen")
+	require.Error(t, err)
+}
+
+func TestPositionIsSharedByValue(t *testing.T) {
+	g := NewGame()
+	start := g.Position
+	playCoords(g, "e2", "e4")
+	require.Equal(t, Piece(EMPTY), start.Squares[COORDS_TO_SQUARE["e4"]])
+	require.Equal(t, Piece(W_PAWN), g.Squares[COORDS_TO_SQUARE["e4"]])
+
+	var wg sync.WaitGroup
+	counts := make([]int, 8)
+	for i := range counts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for _, m := range start.LegalMoves() {
+				counts[i] += len(start.Play(m).LegalMoves())
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, c := range counts {
+		require.Equal(t, 400, c)
+	}
+}
+
+func TestCloneGameKeepsDerivedState(t *testing.T) {
+	g := NewGame()
+	for i := 0; i < 2; i++ {
+		playCoords(g, "g1", "f3")
+		playCoords(g, "g8", "f6")
+		playCoords(g, "f3", "g1")
+		playCoords(g, "f6", "g8")
+	}
+	require.True(t, g.IsThreefoldRepetition)
+
+	clone := CloneGame(g)
+	require.True(t, clone.IsThreefoldRepetition)
+	require.Equal(t, g.LegalMoves, clone.LegalMoves)
+	require.Equal(t, g.Position, clone.Position)
+
+	clone.MakeMove(clone.LegalMoves[0])
+	require.Len(t, g.History, 8)
+	require.Equal(t, 3, g.RepetitionCount())
+}
@@ -0,0 +1,201 @@
+package chessongo
+
+import (
+	"errors"
+	"time"
+)
+
+const E_INVALID_PREMOVE = "e:invalid:premove"
+
+var ErrInvalidPremove = errors.New(E_INVALID_PREMOVE)
+
+// PremoveMode tells how many premoves a game queues
+type PremoveMode uint8
+
+const (
+	// A single premove, replaced by the next one queued
+	PREMOVE_SINGLE PremoveMode = iota
+	// Any number of premoves, played one per turn in the order queued
+	PREMOVE_MULTI
+)
+
+var PREMOVE_MODE_TO_STRING = map[PremoveMode]string{
+	PREMOVE_SINGLE: "single",
+	PREMOVE_MULTI:  "multi",
+}
+
+func (m PremoveMode) String() string {
+	return PREMOVE_MODE_TO_STRING[m]
+}
+
+// PremoveMode returns how many premoves the game queues, PREMOVE_SINGLE
+// unless set otherwise
+func (g *Game) PremoveMode() PremoveMode {
+	return g.premoveMode
+}
+
+// SetPremoveMode sets how many premoves the game queues. Switching to
+// PREMOVE_SINGLE keeps only the first premove queued. The mode is kept when
+// a new position is loaded into the game.
+func (g *Game) SetPremoveMode(mode PremoveMode) {
+	g.premoveMode = mode
+	if mode == PREMOVE_SINGLE && len(g.premoves) > 1 {
+		g.premoves = g.premoves[:1]
+	}
+}
+
+// QueuePremove queues m for the side not to move, to be played as soon as
+// the opponent has moved. The position may change until then, so m is only
+// checked to be a move its piece can make: the opponent may capture a piece
+// on the way or on the target square, even one of the player's own. In
+// PREMOVE_MULTI mode, m is checked against the board as left by the
+// premoves already queued, and in PREMOVE_SINGLE mode it replaces them. A
+// pawn move to the last rank must carry its promotion piece.
+func (g *Game) QueuePremove(m Move) error {
+	if g.withStatus().IsFinished {
+		return ErrGameFinished
+	}
+	color, queued := opponentColor(g.Turn), g.premoves
+	if g.premoveMode == PREMOVE_SINGLE {
+		queued = nil
+	}
+	if !g.isPremove(m, color, queued) {
+		return ErrInvalidPremove
+	}
+	g.premoveColor = color
+	g.premoves = append(queued, m)
+	return nil
+}
+
+// Premoves returns the queued premoves, the next one to play first
+func (g *Game) Premoves() []Move {
+	premoves := make([]Move, len(g.premoves))
+	copy(premoves, g.premoves)
+	return premoves
+}
+
+// CancelPremove drops premove i along with the ones queued after it, which
+// were checked against the board it left. It reports whether there was such
+// a premove.
+func (g *Game) CancelPremove(i int) bool {
+	if i < 0 || i >= len(g.premoves) {
+		return false
+	}
+	g.premoves = g.premoves[:i]
+	return true
+}
+
+// CancelPremoves drops every queued premove
+func (g *Game) CancelPremoves() {
+	g.premoves = g.premoves[:0]
+}
+
+// MakeTimedMove plays m and presses clock at now, the first move of the game
+// starting it instead. When m triggers a premove, the clock is pressed again
+// with zero think time for it and the premove is returned, zero otherwise.
+// The clock stops when the game is over.
+func (g *Game) MakeTimedMove(m Move, clock *Clock, now time.Time) Move {
+	mover, ply := g.Turn, len(g.History)
+	g.MakeMove(m)
+	if clock.Running() == NO_COLOR {
+		// the first move of the game is not timed
+		clock.Start(opponentColor(mover), now)
+	} else {
+		clock.Press(now)
+	}
+	var premove Move
+	if len(g.History) > ply+1 {
+		premove = g.History[ply+1].Move
+		clock.PressAfter(now, 0)
+	}
+	if g.IsFinished {
+		clock.Stop(now)
+	}
+	return premove
+}
+
+// Plays the next premove once its turn came, or drops them all if it is
+// illegal; called after every move
+func (g *Game) runPremove() {
+	if len(g.premoves) == 0 || g.Turn != g.premoveColor {
+		return
+	}
+	next := g.premoves[0]
+	legal, ok := g.matchLegalMove(next)
+	if g.IsFinished || !ok {
+		g.premoves = g.premoves[:0]
+		if g.hasObservers() {
+			g.emit(GameEvent{Kind: EVENT_PREMOVES_DISCARDED, Move: next})
+		}
+		return
+	}
+	g.premoves = append(g.premoves[:0], g.premoves[1:]...)
+	g.makeMove(legal, MOVE_FLAG_PREMOVE)
+}
+
+// Tells whether color could make m on the board left by the queued
+// premoves, with its castling rights, ignoring every other piece
+func (g *Game) isPremove(m Move, color Color, queued []Move) bool {
+	board, castling := g.Squares, g.Castling
+	for _, premove := range queued {
+		premoveOn(&board, &castling, premove)
+	}
+	from, to := m.From(), m.To()
+	piece := board[from]
+	if from == to || piece.Color() != color {
+		return false
+	}
+	lastRank := 0
+	if color == BLACK {
+		lastRank = 7
+	}
+	if piece.Kind() != PAWN || to.Rank() != lastRank {
+		if m.IsPromotionMove() {
+			return false
+		}
+	} else if kind := m.GetPromotionTo(); kind < KNIGHT || kind > QUEEN {
+		return false
+	}
+
+	toBB := Bitboard(1) << to
+	switch piece.Kind() {
+	case PAWN:
+		forward, startRank := -8, 6
+		if color == BLACK {
+			forward, startRank = 8, 1
+		}
+		return int(to) == int(from)+forward ||
+			int(to) == int(from)+2*forward && from.Rank() == startRank ||
+			pawnAttacks(Bitboard(1)<<from, color)&toBB > 0
+	case KING:
+		if hop, ok := castlingRookHops[to]; ok && from.Rank() == to.Rank() && abs(from.File()-to.File()) == 2 {
+			right := castlingRookRight[hop[0]]
+			return castlingColor(right) == color && castling&right > 0 &&
+				board[hop[0]] == Piece(color)|ROOK
+		}
+	}
+	// on an empty board, so that only the shape of the move counts
+	return pieceAttacks(piece, from, 0)&toBB > 0
+}
+
+// Plays premove m on board, regardless of the opponent, and drops the
+// castling rights it loses
+func premoveOn(board *[64]Piece, castling *int, m Move) {
+	from, to := m.From(), m.To()
+	piece := board[from]
+	if piece.Kind() == KING {
+		if hop, ok := castlingRookHops[to]; ok && from.Rank() == to.Rank() && abs(from.File()-to.File()) == 2 {
+			board[hop[1]], board[hop[0]] = board[hop[0]], EMPTY
+		}
+		for _, right := range castlingRookRight {
+			if castlingColor(right) == piece.Color() {
+				*castling &^= right
+			}
+		}
+	}
+	*castling &^= castlingRookRight[from] | castlingRookRight[to]
+	if m.IsPromotionMove() {
+		piece = Piece(piece.Color()) | m.GetPromotionTo()
+	}
+	board[to], board[from] = piece, EMPTY
+}
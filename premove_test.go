@@ -0,0 +1,185 @@
+package chessongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func premove(from, to string) Move {
+	return NewMove(sq(from), sq(to), EMPTY)
+}
+
+func playUci(t *testing.T, g *Game, uci string) {
+	t.Helper()
+	g.refreshStatus()
+	m, err := g.ParseUci(uci)
+	require.NoError(t, err)
+	g.MakeMove(m)
+}
+
+func TestQueuePremoveGeometry(t *testing.T) {
+	g := NewGame()
+	for _, valid := range [][2]string{
+		{"e7", "e5"}, {"e7", "e6"}, {"g8", "f6"}, {"d7", "c6"},
+		// the opponent may clear the way or capture a piece on the target
+		{"f8", "b4"}, {"d8", "d2"}, {"a8", "a7"}, {"e8", "g8"},
+	} {
+		require.NoError(t, g.QueuePremove(premove(valid[0], valid[1])), valid)
+	}
+	for _, invalid := range [][2]string{
+		{"e2", "e4"}, {"e5", "e4"}, {"e7", "e4"}, {"g8", "g6"}, {"f8", "f6"}, {"e8", "e6"}, {"a8", "a8"},
+	} {
+		require.ErrorIs(t, g.QueuePremove(premove(invalid[0], invalid[1])), ErrInvalidPremove, invalid)
+	}
+
+	// castling needs the right and the rook, promotions their piece
+	g = &Game{}
+	require.NoError(t, g.LoadFen("r3k2r/1P6/8/8/8/8/8/R3K2R b Kq - 0 1"))
+	require.NoError(t, g.QueuePremove(premove("e1", "g1")))
+	require.ErrorIs(t, g.QueuePremove(premove("e1", "c1")), ErrInvalidPremove)
+	require.ErrorIs(t, g.QueuePremove(premove("b7", "b8")), ErrInvalidPremove)
+	require.NoError(t, g.QueuePremove(NewPromotionMove(sq("b7"), sq("b8"), EMPTY, QUEEN)))
+	require.NoError(t, g.QueuePremove(NewPromotionMove(sq("b7"), sq("a8"), EMPTY, KNIGHT)))
+	require.ErrorIs(t, g.QueuePremove(NewPromotionMove(sq("e1"), sq("e2"), EMPTY, QUEEN)), ErrInvalidPremove)
+
+	g, err := LoadPGNGame("1. f3 e5 2. g4 Qh4#")
+	require.NoError(t, err)
+	require.ErrorIs(t, g.QueuePremove(premove("e5", "e4")), ErrGameFinished)
+}
+
+func TestPremoveExecuted(t *testing.T) {
+	g := NewGame()
+	require.NoError(t, g.QueuePremove(premove("e7", "e5")))
+	require.Equal(t, []Move{premove("e7", "e5")}, g.Premoves())
+
+	playUci(t, g, "e2e4")
+	require.Equal(t, []string{"e4", "e5"}, g.SANHistory())
+	require.Equal(t, Color(WHITE), g.Turn)
+	require.Empty(t, g.Premoves())
+}
+
+func TestPremoveDiscarded(t *testing.T) {
+	g := NewGame()
+	playUci(t, g, "e2e4")
+	require.NoError(t, g.QueuePremove(premove("e4", "d5")))
+	require.ErrorIs(t, g.QueuePremove(premove("e4", "e6")), ErrInvalidPremove)
+	require.Equal(t, []Move{premove("e4", "d5")}, g.Premoves())
+	require.NoError(t, g.QueuePremove(premove("d1", "h5")))
+	require.Equal(t, []Move{premove("d1", "h5")}, g.Premoves(), "a single premove is replaced")
+	g.CancelPremoves()
+	require.Empty(t, g.Premoves())
+
+	require.NoError(t, g.QueuePremove(premove("g1", "f3")))
+	recorder := &eventRecorder{}
+	g.AddObserver(recorder)
+	playUci(t, g, "d7d5")
+	require.Equal(t, []string{"e4", "d5", "Nf3"}, g.SANHistory())
+	require.Equal(t, []EventKind{EVENT_MOVE_PLAYED, EVENT_MOVE_PLAYED}, recorder.kinds())
+	require.Zero(t, recorder.events[0].Flags&MOVE_FLAG_PREMOVE)
+	require.NotZero(t, recorder.events[1].Flags&MOVE_FLAG_PREMOVE)
+
+	// the pawn is gone before it can capture
+	recorder.reset()
+	require.NoError(t, g.QueuePremove(premove("e4", "d5")))
+	playUci(t, g, "d5e4")
+	require.Equal(t, Color(WHITE), g.Turn)
+	require.Empty(t, g.Premoves())
+	require.Equal(t, []EventKind{EVENT_MOVE_PLAYED, EVENT_PREMOVES_DISCARDED}, recorder.kinds())
+	require.Equal(t, premove("e4", "d5"), recorder.events[1].Move)
+}
+
+func TestMultiPremove(t *testing.T) {
+	g := NewGame()
+	g.SetPremoveMode(PREMOVE_MULTI)
+	playUci(t, g, "e2e4")
+	// white queues Bc4, Qf3 and Qxf7, each checked on the board left by the
+	// ones before
+	require.ErrorIs(t, g.QueuePremove(premove("c4", "f7")), ErrInvalidPremove)
+	require.NoError(t, g.QueuePremove(premove("f1", "c4")))
+	require.NoError(t, g.QueuePremove(premove("d1", "f3")))
+	require.NoError(t, g.QueuePremove(premove("f3", "f7")))
+	require.ErrorIs(t, g.QueuePremove(premove("f1", "b5")), ErrInvalidPremove)
+	require.Len(t, g.Premoves(), 3)
+
+	playUci(t, g, "e7e5")
+	require.Equal(t, []Move{premove("d1", "f3"), premove("f3", "f7")}, g.Premoves())
+	playUci(t, g, "b8c6")
+	playUci(t, g, "d7d6")
+	require.Equal(t, []string{"e4", "e5", "Bc4", "Nc6", "Qf3", "d6", "Qxf7#"}, g.SANHistory())
+	require.True(t, g.IsCheckmate)
+	require.Empty(t, g.Premoves())
+
+	// castling rights are lost once the king is premoved
+	g = NewGame()
+	g.SetPremoveMode(PREMOVE_MULTI)
+	for _, uci := range []string{"e2e4", "e7e5", "g1f3", "b8c6"} {
+		playUci(t, g, uci)
+	}
+	require.NoError(t, g.QueuePremove(premove("g8", "f6")))
+	require.NoError(t, g.QueuePremove(premove("e8", "g8")))
+	require.True(t, g.CancelPremove(1))
+	require.False(t, g.CancelPremove(1))
+	require.NoError(t, g.QueuePremove(premove("e8", "e7")))
+	require.ErrorIs(t, g.QueuePremove(premove("e7", "g7")), ErrInvalidPremove)
+	require.NoError(t, g.QueuePremove(premove("e7", "e8")))
+	require.ErrorIs(t, g.QueuePremove(premove("e8", "g8")), ErrInvalidPremove)
+
+	// back to a single premove, the first one queued is kept
+	g.SetPremoveMode(PREMOVE_SINGLE)
+	require.Equal(t, []Move{premove("g8", "f6")}, g.Premoves())
+	require.True(t, g.CancelPremove(0))
+	require.Empty(t, g.Premoves())
+}
+
+func TestPremoveClearedOnUndo(t *testing.T) {
+	g := NewGame()
+	playUci(t, g, "e2e4")
+	require.NoError(t, g.QueuePremove(premove("g1", "f3")))
+	clone := CloneGame(g)
+	_, err := g.Undo()
+	require.NoError(t, err)
+	require.Empty(t, g.Premoves())
+	require.Equal(t, []Move{premove("g1", "f3")}, clone.Premoves())
+	require.NoError(t, clone.LoadFen(STARTING_POSITION_FEN))
+	require.Empty(t, clone.Premoves())
+}
+
+func TestPremoveModeSurvivesLoads(t *testing.T) {
+	source := NewGame()
+	playUci(t, source, "e2e4")
+	data, err := source.MarshalJSON()
+	require.NoError(t, err)
+	binary, err := source.MarshalBinary()
+	require.NoError(t, err)
+
+	g := NewGame()
+	g.SetPremoveMode(PREMOVE_MULTI)
+	require.NoError(t, g.UnmarshalJSON(data))
+	require.Equal(t, PREMOVE_MULTI, g.PremoveMode())
+	require.NoError(t, g.UnmarshalBinary(binary))
+	require.Equal(t, PREMOVE_MULTI, g.PremoveMode())
+	require.NoError(t, NewPositionBuilder().SetPiece(sq("e1"), W_KING).SetPiece(sq("e8"), B_KING).CommitTo(g))
+	require.Equal(t, PREMOVE_MULTI, g.PremoveMode())
+	require.NoError(t, g.LoadFen(STARTING_POSITION_FEN))
+	require.Equal(t, PREMOVE_MULTI, g.PremoveMode())
+}
+
+func TestMakeTimedMove(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	clock := NewClock(TimeControl{Base: time.Minute, Increment: time.Second})
+	g := NewGame()
+
+	require.Zero(t, g.MakeTimedMove(NewMove(sq("e2"), sq("e4"), EMPTY), clock, at(0)))
+	require.Equal(t, Color(BLACK), clock.Running())
+	require.NoError(t, g.QueuePremove(premove("g1", "f3")))
+
+	premoved := g.MakeTimedMove(NewMove(sq("e7"), sq("e5"), EMPTY), clock, at(10))
+	require.Equal(t, "g1f3", premoved.Uci())
+	require.Equal(t, Color(BLACK), clock.Running())
+	require.Equal(t, 51*time.Second, clock.Remaining(BLACK, at(10)))
+	// the premove took no time and earned the increment
+	require.Equal(t, 61*time.Second, clock.Remaining(WHITE, at(10)))
+}
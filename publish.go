@@ -0,0 +1,362 @@
+package chessongo
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+)
+
+// PublishOptions tells how to export a game for publishing
+type PublishOptions struct {
+	// Tags printed in the heading, e.g. White, Black, Event, Site and Date
+	Tags []Tag
+	// Plies after which to print a diagram, 0 for the start position
+	Diagrams []int
+}
+
+func (o PublishOptions) tag(name string) string {
+	for _, tag := range o.Tags {
+		if tag.Name == name {
+			return tag.Value
+		}
+	}
+	return ""
+}
+
+// Returns "White - Black" and "Event, Site, Date" for the tags that are set
+func (o PublishOptions) heading() (players, details string) {
+	if white, black := o.tag("White"), o.tag("Black"); white != "" || black != "" {
+		players = white + " – " + black
+	}
+	var parts []string
+	for _, name := range []string{"Event", "Site", "Date", "Round"} {
+		if value := o.tag(name); value != "" && value != "?" {
+			parts = append(parts, value)
+		}
+	}
+	return players, strings.Join(parts, ", ")
+}
+
+// A move with the position it leads to and the lines played instead of it
+type publishedMove struct {
+	// Move number and whether white played it
+	Number     int
+	White      bool
+	San        string
+	Fen        string
+	Variations [][]publishedMove
+}
+
+func newPublishedMove(san string, after *Game) publishedMove {
+	// the side that moved is the one not to move after it; black moving
+	// completes the move number
+	m := publishedMove{Number: after.FullMoves, White: after.Turn == BLACK, San: san, Fen: after.ToFen()}
+	if !m.White {
+		m.Number--
+	}
+	return m
+}
+
+// Escapes the characters LaTeX treats specially
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`, `{`, `\{`, `}`, `\}`, `$`, `\$`, `&`, `\&`, `#`, `\#`,
+	`%`, `\%`, `_`, `\_`, `~`, `\textasciitilde{}`, `^`, `\textasciicircum{}`,
+)
+
+// WriteLaTeX writes the game as LaTeX for the xskak package, which loads
+// chessboard: the mainline in \mainline commands, interrupted by a
+// \chessboard diagram at each ply of opts.Diagrams. The output is a fragment
+// meant to be \input into a document loading xskak.
+func (g *Game) WriteLaTeX(w io.Writer, opts PublishOptions) error {
+	return (&GameTree{Game: g}).WriteLaTeX(w, opts)
+}
+
+// ToLaTeX returns the LaTeX written by WriteLaTeX
+func (g *Game) ToLaTeX(opts PublishOptions) (string, error) {
+	return (&GameTree{Game: g}).ToLaTeX(opts)
+}
+
+// WriteLaTeX writes the game like Game.WriteLaTeX, each variation following
+// the move it replaces in a \variation command, indented in a quote
+// environment.
+func (t *GameTree) WriteLaTeX(w io.Writer, opts PublishOptions) error {
+	start, moves, err := t.publishedMoves()
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	if players, details := opts.heading(); players != "" || details != "" {
+		fmt.Fprintf(&b, "\\noindent\\textbf{%s}", latexEscaper.Replace(players))
+		if details != "" {
+			fmt.Fprintf(&b, "\\\\\n%s", latexEscaper.Replace(details))
+		}
+		b.WriteString("\n\n")
+	}
+	if start == STARTING_POSITION_FEN {
+		b.WriteString("\\newchessgame\n")
+	} else {
+		turn := "w"
+		if strings.Fields(start)[1] == "b" {
+			turn = "b"
+		}
+		fmt.Fprintf(&b, "\\newchessgame[setfen=%s, moveid=%s%s]\n", start, strings.Fields(start)[5], turn)
+	}
+	if slices.Contains(opts.Diagrams, 0) {
+		writeLaTeXDiagram(&b, start)
+	}
+	writeLaTeXLine(&b, "mainline", moves, opts.Diagrams)
+	if result, _ := t.Game.withStatus().Result(); result != RESULT_ONGOING {
+		fmt.Fprintf(&b, "\\textbf{%s}\n", result)
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// ToLaTeX returns the LaTeX written by WriteLaTeX
+func (t *GameTree) ToLaTeX(opts PublishOptions) (string, error) {
+	var b strings.Builder
+	err := t.WriteLaTeX(&b, opts)
+	return b.String(), err
+}
+
+// Writes moves in \<command> commands, broken after the plies of diagrams,
+// which are followed by their diagram, and after the moves with variations,
+// which are followed by them
+func writeLaTeXLine(b *strings.Builder, command string, moves []publishedMove, diagrams []int) {
+	var line []string
+	for i, m := range moves {
+		switch {
+		case m.White:
+			line = append(line, fmt.Sprintf("%d. %s", m.Number, m.San))
+		case len(line) == 0:
+			line = append(line, fmt.Sprintf("%d... %s", m.Number, m.San))
+		default:
+			line = append(line, m.San)
+		}
+		diagram := slices.Contains(diagrams, i+1)
+		if diagram || len(m.Variations) > 0 || i == len(moves)-1 {
+			fmt.Fprintf(b, "\\%s{%s}\n", command, strings.Join(line, " "))
+			line = line[:0]
+		}
+		for _, variation := range m.Variations {
+			b.WriteString("\\begin{quote}\n")
+			writeLaTeXLine(b, "variation", variation, nil)
+			b.WriteString("\\end{quote}\n")
+		}
+		if diagram {
+			writeLaTeXDiagram(b, m.Fen)
+		}
+	}
+}
+
+func writeLaTeXDiagram(b *strings.Builder, fen string) {
+	fmt.Fprintf(b, "\n\\begin{center}\n\\chessboard[setfen=%s]\n\\end{center}\n\n", fen)
+}
+
+// A token of the HTML move list: a move number, a move, a diagram, or the
+// start or end of a variation
+type htmlToken struct {
+	Number string
+	San    string
+	// Index in the positions of the page of the one after the move
+	Position int
+	Diagram  string
+	Open     bool
+	Close    bool
+}
+
+var htmlTemplate = template.Must(template.New("game").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{if .Players}}{{.Players}}{{else}}Game{{end}}</title>
+<style>
+body { font-family: Georgia, serif; max-width: 42em; margin: 2em auto; padding: 0 1em; color: #222; }
+h1 { font-size: 1.4em; margin-bottom: 0; }
+.details { color: #666; margin-top: .2em; }
+.board { display: grid; grid-template-columns: repeat(8, 1fr); width: 100%; max-width: 24em; aspect-ratio: 1; border: 1px solid #444; margin: 1em 0; user-select: none; }
+.board div { display: flex; align-items: center; justify-content: center; font-size: 2.2em; line-height: 1; }
+.board .light { background: #f0d9b5; }
+.board .dark { background: #b58863; }
+.diagram { max-width: 14em; margin: .8em auto; }
+.diagram div { font-size: 1.3em; }
+.controls button { font-size: 1em; min-width: 3em; }
+.moves { line-height: 1.8; }
+.moves a { color: inherit; text-decoration: none; padding: 0 .15em; border-radius: .2em; cursor: pointer; }
+.moves a.current { background: #9bc700; }
+.variation { margin-left: 1.5em; color: #555; }
+.variation .moves { margin: .2em 0; }
+.result { font-weight: bold; }
+</style>
+</head>
+<body>
+{{if .Players}}<h1>{{.Players}}</h1>
+{{end}}{{if .Details}}<p class="details">{{.Details}}</p>
+{{end}}<div class="board" id="board"></div>
+<div class="controls">
+<button data-go="start" title="Start">|&lt;</button>
+<button data-go="back" title="Back">&lt;</button>
+<button data-go="forward" title="Forward">&gt;</button>
+<button data-go="end" title="End">&gt;|</button>
+</div>
+<p class="moves">
+{{range .Tokens}}{{if .Diagram}}</p>
+<div class="board diagram" data-fen="{{.Diagram}}"></div>
+<p class="moves">
+{{else if .Open}}</p>
+<div class="variation">
+<p class="moves">
+{{else if .Close}}</p>
+</div>
+<p class="moves">
+{{else if .Number}}{{.Number}} {{else}}<a data-position="{{.Position}}">{{.San}}</a>
+{{end}}{{end}}{{if .Result}}<span class="result">{{.Result}}</span>{{end}}
+</p>
+<script>
+(function () {
+  var fens = {{.Fens}}, previous = {{.Previous}}, next = {{.Next}}, last = {{.Last}};
+  var glyphs = { K: "♔", Q: "♕", R: "♖", B: "♗", N: "♘", P: "♙", k: "♚", q: "♛", r: "♜", b: "♝", n: "♞", p: "♟" };
+  function draw(el, fen) {
+    var rows = fen.split(" ")[0].split("/");
+    el.innerHTML = "";
+    rows.forEach(function (row, r) {
+      var f = 0;
+      row.split("").forEach(function (c) {
+        var empty = /[1-8]/.test(c);
+        var n = empty ? +c : 1;
+        for (var i = 0; i < n; i++, f++) {
+          var sq = document.createElement("div");
+          sq.className = (r + f) % 2 ? "dark" : "light";
+          sq.textContent = empty ? "" : glyphs[c];
+          el.appendChild(sq);
+        }
+      });
+    });
+  }
+  var board = document.getElementById("board");
+  var moves = document.querySelectorAll(".moves a");
+  var position = 0;
+  function go(n) {
+    if (n < 0) return;
+    position = n;
+    draw(board, fens[position]);
+    moves.forEach(function (a) { a.classList.toggle("current", +a.dataset.position === position); });
+  }
+  moves.forEach(function (a) { a.addEventListener("click", function () { go(+a.dataset.position); }); });
+  var steps = { start: function () { return 0; }, back: function () { return previous[position]; }, forward: function () { return next[position]; }, end: function () { return last; } };
+  document.querySelectorAll(".controls button").forEach(function (b) {
+    b.addEventListener("click", function () { go(steps[b.dataset.go]()); });
+  });
+  document.addEventListener("keydown", function (e) {
+    if (e.key === "ArrowLeft") go(previous[position]);
+    if (e.key === "ArrowRight") go(next[position]);
+    if (e.key === "Home") go(0);
+    if (e.key === "End") go(last);
+  });
+  document.querySelectorAll(".diagram").forEach(function (d) { draw(d, d.dataset.fen); });
+  go(0);
+})();
+</script>
+</body>
+</html>
+`))
+
+// WriteHTML writes the game as a self-contained HTML page, with its scripts
+// and styles inline: an interactive board replaying the moves, driven by the
+// buttons, the arrow keys or a click on a move, above the move list with a
+// static diagram at each ply of opts.Diagrams.
+func (g *Game) WriteHTML(w io.Writer, opts PublishOptions) error {
+	return (&GameTree{Game: g}).WriteHTML(w, opts)
+}
+
+// ToHTML returns the page written by WriteHTML
+func (g *Game) ToHTML(opts PublishOptions) (string, error) {
+	return (&GameTree{Game: g}).ToHTML(opts)
+}
+
+// The data of the HTML template
+type htmlPage struct {
+	Players, Details, Result string
+	Tokens                   []htmlToken
+	// Every position of the page: the mainline from the start position, then
+	// the variations. Previous and Next link each one to the positions
+	// before and after it in its line, -1 for none.
+	Fens           []string
+	Previous, Next []int
+	// End of the mainline
+	Last int
+}
+
+// WriteHTML writes the game like Game.WriteHTML, each variation following
+// the move it replaces, indented. Its moves can be clicked and stepped
+// through like those of the mainline.
+func (t *GameTree) WriteHTML(w io.Writer, opts PublishOptions) error {
+	start, moves, err := t.publishedMoves()
+	if err != nil {
+		return err
+	}
+	players, details := opts.heading()
+	page := htmlPage{Players: players, Details: details, Fens: []string{start}, Previous: []int{-1}, Next: []int{-1}}
+	if slices.Contains(opts.Diagrams, 0) {
+		page.Tokens = append(page.Tokens, htmlToken{Diagram: start})
+	}
+	page.Last = page.addPositions(moves, 0) + len(moves) - 1
+	page.addLine(moves, 0, 1, opts.Diagrams)
+	if result, _ := t.Game.withStatus().Result(); result != RESULT_ONGOING {
+		page.Result = result
+	}
+	return htmlTemplate.Execute(w, page)
+}
+
+// ToHTML returns the page written by WriteHTML
+func (t *GameTree) ToHTML(opts PublishOptions) (string, error) {
+	var b strings.Builder
+	err := t.WriteHTML(&b, opts)
+	return b.String(), err
+}
+
+// Adds the positions after moves, a line starting from position before, and
+// returns the index of the first one
+func (p *htmlPage) addPositions(moves []publishedMove, before int) int {
+	first := len(p.Fens)
+	for i, m := range moves {
+		previous := first + i - 1
+		if i == 0 {
+			previous = before
+		}
+		p.Fens = append(p.Fens, m.Fen)
+		p.Previous = append(p.Previous, previous)
+		p.Next = append(p.Next, -1)
+		// a variation leaves the move after before to the line it replaces
+		if p.Next[previous] == -1 {
+			p.Next[previous] = first + i
+		}
+	}
+	return first
+}
+
+// Adds the tokens of moves, whose positions start at first, followed by
+// their variations and by a diagram at the plies of diagrams
+func (p *htmlPage) addLine(moves []publishedMove, before, first int, diagrams []int) {
+	for i, m := range moves {
+		broken := i == 0 || slices.Contains(diagrams, i) || len(moves[i-1].Variations) > 0
+		switch {
+		case m.White:
+			p.Tokens = append(p.Tokens, htmlToken{Number: fmt.Sprintf("%d.", m.Number)})
+		case broken:
+			p.Tokens = append(p.Tokens, htmlToken{Number: fmt.Sprintf("%d...", m.Number)})
+		}
+		p.Tokens = append(p.Tokens, htmlToken{San: m.San, Position: first + i})
+		for _, variation := range m.Variations {
+			p.Tokens = append(p.Tokens, htmlToken{Open: true})
+			p.addLine(variation, before, p.addPositions(variation, before), nil)
+			p.Tokens = append(p.Tokens, htmlToken{Close: true})
+		}
+		if slices.Contains(diagrams, i+1) {
+			p.Tokens = append(p.Tokens, htmlToken{Diagram: m.Fen})
+		}
+		before = first + i
+	}
+}
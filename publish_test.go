@@ -0,0 +1,126 @@
+package chessongo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLaTeX(t *testing.T) {
+	g, err := LoadPGNGame("1. f3 e5 2. g4 Qh4# 0-1")
+	require.NoError(t, err)
+	latex, err := g.ToLaTeX(PublishOptions{
+		Tags:     []Tag{{"White", "Fool & Co"}, {"Black", "Smart"}, {"Event", "Casual"}, {"Site", "?"}},
+		Diagrams: []int{3},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `\noindent\textbf{Fool \& Co – Smart}\\
+Casual
+
+\newchessgame
+\mainline{1. f3 e5 2. g4}
+
+\begin{center}
+\chessboard[setfen=rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq g3 0 2]
+\end{center}
+
+\mainline{2... Qh4#}
+\textbf{0-1}
+`, latex)
+}
+
+func TestWriteLaTeXFromPosition(t *testing.T) {
+	g, err := LoadPGNGame(`[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 40"]
+
+40... Kd7 41. e4 *`)
+	require.NoError(t, err)
+	latex, err := g.ToLaTeX(PublishOptions{Diagrams: []int{0}})
+	require.NoError(t, err)
+	require.Equal(t, `\newchessgame[setfen=4k3/8/8/8/8/8/4P3/4K3 b - - 0 40, moveid=40b]
+
+\begin{center}
+\chessboard[setfen=4k3/8/8/8/8/8/4P3/4K3 b - - 0 40]
+\end{center}
+
+\mainline{40... Kd7 41. e4}
+`, latex)
+}
+
+func TestWriteHTML(t *testing.T) {
+	g, err := LoadPGNGame("1. f3 e5 2. g4 Qh4# 0-1")
+	require.NoError(t, err)
+	page, err := g.ToHTML(PublishOptions{Tags: []Tag{{"White", "<Fool>"}, {"Black", "Smart"}}, Diagrams: []int{2}})
+	require.NoError(t, err)
+
+	require.True(t, strings.HasPrefix(page, "<!DOCTYPE html>"))
+	// self-contained
+	require.NotContains(t, page, "src=")
+	require.NotContains(t, page, "href=")
+	require.Contains(t, page, "<h1>&lt;Fool&gt; – Smart</h1>")
+	require.Contains(t, page, `1. <a data-position="1">f3</a>`)
+	require.Contains(t, page, `<a data-position="4">Qh4#</a>`)
+	// a diagram after black's first move
+	require.Contains(t, page, `<div class="board diagram" data-fen="rnbqkbnr/pppp1ppp/8/4p3/8/5P2/PPPPP1PP/RNBQKBNR w KQkq e6 0 2"></div>`)
+	require.Contains(t, page, `2. <a data-position="3">g4</a>`)
+	require.Contains(t, page, `<span class="result">0-1</span>`)
+	require.Contains(t, page, `var fens = ["rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",`)
+}
+
+func TestGameTreeVariations(t *testing.T) {
+	g, err := LoadPGNGame("1. e4 e5 2. Nf3 Nc6 3. Bb5 *")
+	require.NoError(t, err)
+	tree := &GameTree{Game: g, Variations: []Variation{
+		{Ply: 3, Moves: []string{"f4", "exf4", "Nf3"}, Variations: []Variation{
+			{Ply: 4, Moves: []string{"Qh4"}},
+		}},
+		{Ply: 4, Moves: []string{"d6"}},
+	}}
+	latex, err := tree.ToLaTeX(PublishOptions{})
+	require.NoError(t, err)
+	require.Equal(t, `\newchessgame
+\mainline{1. e4 e5 2. Nf3}
+\begin{quote}
+\variation{2. f4 exf4}
+\begin{quote}
+\variation{2... Qh4+}
+\end{quote}
+\variation{3. Nf3}
+\end{quote}
+\mainline{2... Nc6}
+\begin{quote}
+\variation{2... d6}
+\end{quote}
+\mainline{3. Bb5}
+`, latex)
+
+	page, err := tree.ToHTML(PublishOptions{})
+	require.NoError(t, err)
+	require.Contains(t, page, `2. <a data-position="3">Nf3</a>
+</p>
+<div class="variation">
+<p class="moves">
+2. <a data-position="6">f4</a>
+<a data-position="7">exf4</a>
+</p>
+<div class="variation">
+<p class="moves">
+2... <a data-position="9">Qh4&#43;</a>
+</p>
+</div>
+<p class="moves">
+3. <a data-position="8">Nf3</a>
+</p>
+</div>
+<p class="moves">
+2... <a data-position="4">Nc6</a>`)
+	// the variations step from the position they start from
+	require.Contains(t, page, `previous = [-1,0,1,2,3,4,2,6,7,6,3], next = [1,2,3,4,5,-1,7,8,-1,-1,-1]`)
+
+	tree.Variations = []Variation{{Ply: 6, Moves: []string{"d4"}}}
+	_, err = tree.ToLaTeX(PublishOptions{})
+	require.EqualError(t, err, E_INVALID_PLY)
+	tree.Variations = []Variation{{Ply: 3, Moves: []string{"Ke3"}}}
+	_, err = tree.ToHTML(PublishOptions{})
+	require.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"chessongo"
+)
+
+// Image draws the position as an image, leaving out the coordinates
+func Image(p *chessongo.Position, opts Options) *image.RGBA {
+	size := opts.squareSize()
+	img := image.NewRGBA(image.Rect(0, 0, 8*size, 8*size))
+	highlights := opts.highlights(p)
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			sq := opts.squareAt(row, col)
+			cell := image.Rect(col*size, row*size, (col+1)*size, (row+1)*size)
+			fill := DarkSquareColor
+			if isLightSquare(sq) {
+				fill = LightSquareColor
+			}
+			draw.Draw(img, cell, image.NewUniform(fill), image.Point{}, draw.Src)
+			for _, overlay := range []color.Color{highlights[sq], opts.Squares[sq]} {
+				if overlay != nil {
+					draw.Draw(img, cell, image.NewUniform(overlay), image.Point{}, draw.Over)
+				}
+			}
+			if piece := p.Squares[sq]; piece != chessongo.EMPTY {
+				drawSprite(img, cell, piece)
+			}
+		}
+	}
+	for _, arrow := range opts.Arrows {
+		drawArrow(img, opts, arrow)
+	}
+	return img
+}
+
+// Draws the sprite of piece scaled to cell
+func drawSprite(img *image.RGBA, cell image.Rectangle, piece chessongo.Piece) {
+	outline, body := PieceOutlineColor, WhitePieceColor
+	if piece.IsBlack() {
+		outline, body = BlackOutlineColor, BlackPieceColor
+	}
+	sprite := sprites[piece.Kind()]
+	w, h := cell.Dx(), cell.Dy()
+	for y := 0; y < h; y++ {
+		row := sprite[y*spriteSize/h]
+		for x := 0; x < w; x++ {
+			switch row[x*spriteSize/w] {
+			case 'x':
+				img.Set(cell.Min.X+x, cell.Min.Y+y, outline)
+			case 'o':
+				img.Set(cell.Min.X+x, cell.Min.Y+y, body)
+			}
+		}
+	}
+}
+
+// Draws an arrow by building the mask of its shaft and head
+func drawArrow(img *image.RGBA, opts Options, arrow Arrow) {
+	from, to, width, head, ok := opts.arrowShape(arrow)
+	if !ok {
+		return
+	}
+	c := arrow.Color
+	if c == nil {
+		c = DefaultArrowColor
+	}
+	mask := image.NewAlpha(img.Bounds())
+	dx, dy := to.x-from.x, to.y-from.y
+	length := math.Hypot(dx, dy)
+	dx, dy = dx/length, dy/length
+	for y := 0; y < mask.Rect.Dy(); y++ {
+		for x := 0; x < mask.Rect.Dx(); x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			// position along the shaft and distance from its axis
+			along := (px-from.x)*dx + (py-from.y)*dy
+			across := math.Abs((px-from.x)*dy - (py-from.y)*dx)
+			inShaft := along >= 0 && along <= length && across <= width/2
+			if inShaft || inTriangle(point{px, py}, head) {
+				mask.SetAlpha(x, y, color.Alpha{0xff})
+			}
+		}
+	}
+	draw.DrawMask(img, img.Bounds(), image.NewUniform(c), image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+// Tells whether p is inside the triangle t
+func inTriangle(p point, t [3]point) bool {
+	side := func(a, b point) float64 {
+		return (b.x-a.x)*(p.y-a.y) - (b.y-a.y)*(p.x-a.x)
+	}
+	d1, d2, d3 := side(t[0], t[1]), side(t[1], t[2]), side(t[2], t[0])
+	hasNegative := d1 < 0 || d2 < 0 || d3 < 0
+	hasPositive := d1 > 0 || d2 > 0 || d3 > 0
+	return !(hasNegative && hasPositive)
+}
+
+// PNG writes the image drawn by Image in the PNG format
+func PNG(w io.Writer, p *chessongo.Position, opts Options) error {
+	return png.Encode(w, Image(p, opts))
+}
@@ -0,0 +1,51 @@
+package render
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"chessongo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPNG(t *testing.T) {
+	p := mustPosition(t, "8/8/8/8/8/8/6k1/K7 w - - 0 1")
+	var buf bytes.Buffer
+	require.NoError(t, PNG(&buf, p, Options{SquareSize: 16}))
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	require.Equal(t, 128, img.Bounds().Dx())
+	require.Equal(t, 128, img.Bounds().Dy())
+
+	rgba := func(c color.Color) color.RGBA {
+		return color.RGBAModel.Convert(c).(color.RGBA)
+	}
+	// a8 is light, b8 dark
+	require.Equal(t, rgba(LightSquareColor), rgba(img.At(0, 0)))
+	require.Equal(t, rgba(DarkSquareColor), rgba(img.At(16, 0)))
+	// the sprite cells map one to one at 16 pixels a square: the white king on
+	// a1 and the black king on g2
+	require.Equal(t, rgba(PieceOutlineColor), rgba(img.At(7, 112)))
+	require.Equal(t, rgba(WhitePieceColor), rgba(img.At(7, 113)))
+	require.Equal(t, rgba(BlackPieceColor), rgba(img.At(96+7, 96+8)))
+}
+
+func TestImageOrientationHighlightsAndArrows(t *testing.T) {
+	p := mustPosition(t, "8/8/8/8/8/8/6k1/K7 w - - 0 1")
+	a1, h8 := chessongo.COORDS_TO_SQUARE["a1"], chessongo.COORDS_TO_SQUARE["h8"]
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+
+	img := Image(p, Options{SquareSize: 16, Orientation: chessongo.BLACK, Squares: map[chessongo.Square]color.Color{h8: blue}})
+	// flipped, the white king is in the top right corner and h8 in the bottom
+	// left one
+	require.Equal(t, color.RGBAModel.Convert(PieceOutlineColor), img.At(128-16+7, 0))
+	require.Equal(t, blue, img.At(0, 127))
+
+	img = Image(p, Options{SquareSize: 16, Arrows: []Arrow{{From: a1, To: h8, Color: blue}}})
+	// on the diagonal, away from the pieces
+	require.Equal(t, blue, img.At(64, 64))
+	require.Equal(t, blue, img.At(110, 17))
+	require.NotEqual(t, blue, img.At(64, 100))
+}
@@ -0,0 +1,222 @@
+// Package render draws chess positions: plain text boards in ASCII or
+// Unicode figurines, SVG diagrams and PNG images. Every renderer writes to an
+// io.Writer and has a variant returning a string or an image.
+package render
+
+import (
+	"image/color"
+	"io"
+	"math"
+	"strings"
+
+	"chessongo"
+)
+
+// Default side of a square of SVG diagrams and PNG images, in pixels
+const DEFAULT_SQUARE_SIZE = 45
+
+// Options tells how to draw a position. The zero value draws the board from
+// white's side, with coordinates and without highlights. Text boards use
+// Orientation and NoCoordinates only, and images have no coordinates.
+type Options struct {
+	// Color at the bottom of the board, white if NO_COLOR
+	Orientation chessongo.Color
+	// Leaves out the file letters and rank numbers
+	NoCoordinates bool
+	// Move to highlight, usually the last one played; zero for none
+	LastMove chessongo.Move
+	// Highlights the king of the side to move when it is in check
+	HighlightCheck bool
+	// Squares to fill with a color, drawn over the board and the highlights
+	// and under the pieces
+	Squares map[chessongo.Square]color.Color
+	// Arrows drawn over the pieces, in order
+	Arrows []Arrow
+	// Side of a square in pixels, DEFAULT_SQUARE_SIZE if zero
+	SquareSize int
+}
+
+// Arrow points from one square to another
+type Arrow struct {
+	From, To chessongo.Square
+	// DefaultArrowColor if nil
+	Color color.Color
+}
+
+// Colors of the diagrams
+var (
+	LightSquareColor  color.Color = color.NRGBA{0xf0, 0xd9, 0xb5, 0xff}
+	DarkSquareColor   color.Color = color.NRGBA{0xb5, 0x88, 0x63, 0xff}
+	LastMoveColor     color.Color = color.NRGBA{0x9b, 0xc7, 0x00, 0x69}
+	CheckColor        color.Color = color.NRGBA{0xff, 0x00, 0x00, 0x80}
+	DefaultArrowColor color.Color = color.NRGBA{0x15, 0x78, 0x1b, 0xcc}
+	WhitePieceColor   color.Color = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	BlackPieceColor   color.Color = color.NRGBA{0x20, 0x20, 0x20, 0xff}
+	PieceOutlineColor color.Color = color.NRGBA{0x00, 0x00, 0x00, 0xff}
+	BlackOutlineColor color.Color = color.NRGBA{0xa0, 0xa0, 0xa0, 0xff}
+	CoordinateColor   color.Color = color.NRGBA{0x40, 0x40, 0x40, 0xff}
+)
+
+// GameOptions returns the options highlighting the last move of g and its
+// king in check
+func GameOptions(g *chessongo.Game) Options {
+	opts := Options{HighlightCheck: true}
+	if n := len(g.History); n > 0 {
+		opts.LastMove = g.History[n-1].Move
+	}
+	return opts
+}
+
+func (o Options) squareSize() int {
+	if o.SquareSize <= 0 {
+		return DEFAULT_SQUARE_SIZE
+	}
+	return o.SquareSize
+}
+
+func (o Options) flipped() bool {
+	return o.Orientation == chessongo.BLACK
+}
+
+// Returns the square drawn at row and column of the diagram, counted from its
+// top left corner
+func (o Options) squareAt(row, col int) chessongo.Square {
+	if o.flipped() {
+		row, col = 7-row, 7-col
+	}
+	return chessongo.Square(row*8 + col)
+}
+
+// Returns the row and column of the diagram where sq is drawn
+func (o Options) cellOf(sq chessongo.Square) (row, col int) {
+	row, col = sq.Rank(), sq.File()
+	if o.flipped() {
+		row, col = 7-row, 7-col
+	}
+	return row, col
+}
+
+// Returns the squares to highlight: the last move, then the king in check
+func (o Options) highlights(p *chessongo.Position) map[chessongo.Square]color.Color {
+	marks := map[chessongo.Square]color.Color{}
+	if o.LastMove != 0 {
+		marks[o.LastMove.From()] = LastMoveColor
+		marks[o.LastMove.To()] = LastMoveColor
+	}
+	if o.HighlightCheck && p.InCheck() {
+		king := p.Whites[chessongo.KING]
+		if p.Turn == chessongo.BLACK {
+			king = p.Blacks[chessongo.KING]
+		}
+		for sq := 0; sq < 64; sq++ {
+			if king&(1<<sq) > 0 {
+				marks[chessongo.Square(sq)] = CheckColor
+			}
+		}
+	}
+	return marks
+}
+
+func isLightSquare(sq chessongo.Square) bool {
+	return (sq.Rank()+sq.File())%2 == 0
+}
+
+// Unicode figurines, indexed by piece
+var FIGURINES = map[chessongo.Piece]rune{
+	chessongo.W_KING: '♔', chessongo.W_QUEEN: '♕', chessongo.W_ROOK: '♖',
+	chessongo.W_BISHOP: '♗', chessongo.W_KNIGHT: '♘', chessongo.W_PAWN: '♙',
+	chessongo.B_KING: '♚', chessongo.B_QUEEN: '♛', chessongo.B_ROOK: '♜',
+	chessongo.B_BISHOP: '♝', chessongo.B_KNIGHT: '♞', chessongo.B_PAWN: '♟',
+}
+
+// Writes a text board using glyph for the pieces and empty for empty squares
+func writeText(w io.Writer, p *chessongo.Position, opts Options, glyph func(chessongo.Piece) rune, empty rune) error {
+	var b strings.Builder
+	for row := 0; row < 8; row++ {
+		if !opts.NoCoordinates {
+			b.WriteString(opts.squareAt(row, 0).Coords()[1:])
+			b.WriteByte(' ')
+		}
+		for col := 0; col < 8; col++ {
+			if col > 0 {
+				b.WriteByte(' ')
+			}
+			if piece := p.Squares[opts.squareAt(row, col)]; piece != chessongo.EMPTY {
+				b.WriteRune(glyph(piece))
+			} else {
+				b.WriteRune(empty)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	if !opts.NoCoordinates {
+		b.WriteString(" ")
+		for col := 0; col < 8; col++ {
+			b.WriteByte(' ')
+			b.WriteString(opts.squareAt(7, col).FileLetter())
+		}
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ASCII writes the board with the FEN letters of the pieces and dots for the
+// empty squares:
+//
+//	8 r n b q k b n r
+//	7 p p p p p p p p
+//	...
+//	  a b c d e f g h
+func ASCII(w io.Writer, p *chessongo.Position, opts Options) error {
+	return writeText(w, p, opts, chessongo.Piece.ToRune, '.')
+}
+
+// ASCIIString returns the board drawn by ASCII
+func ASCIIString(p *chessongo.Position, opts Options) string {
+	var b strings.Builder
+	ASCII(&b, p, opts)
+	return b.String()
+}
+
+// Unicode writes the board like ASCII, with figurines for the pieces
+func Unicode(w io.Writer, p *chessongo.Position, opts Options) error {
+	return writeText(w, p, opts, func(piece chessongo.Piece) rune { return FIGURINES[piece] }, '·')
+}
+
+// UnicodeString returns the board drawn by Unicode
+func UnicodeString(p *chessongo.Position, opts Options) string {
+	var b strings.Builder
+	Unicode(&b, p, opts)
+	return b.String()
+}
+
+type point struct{ x, y float64 }
+
+// Returns the center of the cell where sq is drawn, in pixels
+func (o Options) center(sq chessongo.Square) point {
+	row, col := o.cellOf(sq)
+	size := float64(o.squareSize())
+	return point{(float64(col) + 0.5) * size, (float64(row) + 0.5) * size}
+}
+
+// Returns the shape of an arrow in pixels: its shaft, of the given width, and
+// its head, tip first. ok is false for an arrow from a square to itself.
+func (o Options) arrowShape(a Arrow) (shaftFrom, shaftTo point, width float64, head [3]point, ok bool) {
+	if a.From == a.To {
+		return
+	}
+	size := float64(o.squareSize())
+	from, tip := o.center(a.From), o.center(a.To)
+	dx, dy := tip.x-from.x, tip.y-from.y
+	length := math.Hypot(dx, dy)
+	dx, dy = dx/length, dy/length
+	headLength, headWidth := 0.45*size, 0.5*size
+	base := point{tip.x - dx*headLength, tip.y - dy*headLength}
+	head = [3]point{
+		tip,
+		{base.x - dy*headWidth/2, base.y + dx*headWidth/2},
+		{base.x + dy*headWidth/2, base.y - dx*headWidth/2},
+	}
+	return from, base, size / 6, head, true
+}
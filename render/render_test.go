@@ -0,0 +1,82 @@
+package render
+
+import (
+	"testing"
+
+	"chessongo"
+	"github.com/stretchr/testify/require"
+)
+
+func mustPosition(t *testing.T, fen string) *chessongo.Position {
+	p, err := chessongo.NewPosition(fen)
+	require.NoError(t, err)
+	return &p
+}
+
+func TestSpritesAreSquare(t *testing.T) {
+	for kind := chessongo.Piece(chessongo.PAWN); kind <= chessongo.KING; kind++ {
+		sprite, ok := sprites[kind]
+		require.True(t, ok, kind)
+		for _, row := range sprite {
+			require.Len(t, row, spriteSize, kind)
+		}
+	}
+}
+
+func TestASCII(t *testing.T) {
+	p := mustPosition(t, chessongo.STARTING_POSITION_FEN)
+	require.Equal(t, ""+
+		"8 r n b q k b n r\n"+
+		"7 p p p p p p p p\n"+
+		"6 . . . . . . . .\n"+
+		"5 . . . . . . . .\n"+
+		"4 . . . . . . . .\n"+
+		"3 . . . . . . . .\n"+
+		"2 P P P P P P P P\n"+
+		"1 R N B Q K B N R\n"+
+		"  a b c d e f g h\n", ASCIIString(p, Options{}))
+
+	p = mustPosition(t, "8/8/8/8/8/8/6k1/K7 w - - 0 1")
+	require.Equal(t, ""+
+		"1 . . . . . . . K\n"+
+		"2 . k . . . . . .\n"+
+		"3 . . . . . . . .\n"+
+		"4 . . . . . . . .\n"+
+		"5 . . . . . . . .\n"+
+		"6 . . . . . . . .\n"+
+		"7 . . . . . . . .\n"+
+		"8 . . . . . . . .\n"+
+		"  h g f e d c b a\n", ASCIIString(p, Options{Orientation: chessongo.BLACK}))
+}
+
+func TestUnicode(t *testing.T) {
+	p := mustPosition(t, "8/8/8/8/8/8/6k1/K7 w - - 0 1")
+	require.Equal(t, ""+
+		"· · · · · · · ·\n"+
+		"· · · · · · · ·\n"+
+		"· · · · · · · ·\n"+
+		"· · · · · · · ·\n"+
+		"· · · · · · · ·\n"+
+		"· · · · · · · ·\n"+
+		"· · · · · · ♚ ·\n"+
+		"♔ · · · · · · ·\n", UnicodeString(p, Options{NoCoordinates: true}))
+}
+
+func TestGameOptions(t *testing.T) {
+	g := chessongo.NewGame()
+	g.GenerateLegalMoves()
+	opts := GameOptions(g)
+	require.Zero(t, opts.LastMove)
+	require.True(t, opts.HighlightCheck)
+
+	for _, san := range []string{"f3", "e5", "g4", "Qh4#"} {
+		m, err := g.ParseSan(san)
+		require.NoError(t, err)
+		g.MakeMove(m)
+	}
+	opts = GameOptions(g)
+	require.Equal(t, "d8h4", opts.LastMove.Uci())
+	marks := opts.highlights(&g.Position)
+	require.Equal(t, LastMoveColor, marks[chessongo.COORDS_TO_SQUARE["h4"]])
+	require.Equal(t, CheckColor, marks[chessongo.COORDS_TO_SQUARE["e1"]])
+}
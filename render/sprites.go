@@ -0,0 +1,119 @@
+package render
+
+import "chessongo"
+
+// Piece sprites, 16 by 16 cells: 'x' is the outline, 'o' the body, '.' is
+// transparent. They are drawn in the colors of the piece, so one sprite
+// serves both sides.
+const spriteSize = 16
+
+var sprites = map[chessongo.Piece][spriteSize]string{
+	chessongo.PAWN: {
+		"................",
+		"................",
+		"................",
+		"......xxxx......",
+		".....xoooox.....",
+		".....xoooox.....",
+		"......xoox......",
+		".....xoooox.....",
+		"......xoox......",
+		"......xoox......",
+		".....xoooox.....",
+		"....xoooooox....",
+		"...xoooooooox...",
+		"...xxxxxxxxxx...",
+		"................",
+		"................",
+	},
+	chessongo.KNIGHT: {
+		"................",
+		"................",
+		".......xx.......",
+		"......xoox......",
+		".....xoooxx.....",
+		"....xooxoooxx...",
+		"...xoooooooox...",
+		"...xooxxxoooox..",
+		"....xx..xooooox.",
+		".......xoooooox.",
+		"......xoooooox..",
+		".....xoooooox...",
+		"....xooooooox...",
+		"...xxxxxxxxxxx..",
+		"................",
+		"................",
+	},
+	chessongo.BISHOP: {
+		"................",
+		".......xx.......",
+		"......xoox......",
+		".......xx.......",
+		"......xoox......",
+		".....xoooox.....",
+		"....xooxooox....",
+		"....xoxxxoox....",
+		"....xooxooox....",
+		".....xoooox.....",
+		"......xxxx......",
+		".....xoooox.....",
+		"....xoooooox....",
+		"...xxxxxxxxxx...",
+		"................",
+		"................",
+	},
+	chessongo.ROOK: {
+		"................",
+		"................",
+		"...xxx.xx.xxx...",
+		"...xoxxooxxox...",
+		"...xoooooooox...",
+		"...xxxxxxxxxx...",
+		"....xoooooox....",
+		"....xoooooox....",
+		"....xoooooox....",
+		"....xoooooox....",
+		"....xoooooox....",
+		"...xxxxxxxxxx...",
+		"..xoooooooooox..",
+		"..xxxxxxxxxxxx..",
+		"................",
+		"................",
+	},
+	chessongo.QUEEN: {
+		"................",
+		".x.....xx.....x.",
+		"xox...xoox...xox",
+		".xox..xoox..xox.",
+		".xoox.xoox.xoox.",
+		"..xooxxooxxoox..",
+		"..xoooooooooox..",
+		"..xoooooooooox..",
+		"...xoooooooox...",
+		"...xoooooooox...",
+		"....xxxxxxxx....",
+		"....xoooooox....",
+		"...xoooooooox...",
+		"..xxxxxxxxxxxx..",
+		"................",
+		"................",
+	},
+	chessongo.KING: {
+		".......xx.......",
+		"......xoox......",
+		".......xx.......",
+		"......xoox......",
+		"..xxx.xoox.xxx..",
+		".xooox.xx.xooox.",
+		".xoooxxooxxooox.",
+		".xoooooooooooox.",
+		"..xoooooooooox..",
+		"..xoooooooooox..",
+		"...xoooooooox...",
+		"....xxxxxxxx....",
+		"....xoooooox....",
+		"...xoooooooox...",
+		"..xxxxxxxxxxxx..",
+		"................",
+	},
+}
@@ -0,0 +1,145 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+
+	"chessongo"
+)
+
+// Returns the SVG color and opacity of c
+func svgColor(c color.Color) (string, string) {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B), fmt.Sprintf("%.3g", float64(n.A)/0xff)
+}
+
+// Returns the fill attributes of c
+func svgFill(c color.Color) string {
+	hex, opacity := svgColor(c)
+	if opacity == "1" {
+		return fmt.Sprintf(`fill="%s"`, hex)
+	}
+	return fmt.Sprintf(`fill="%s" fill-opacity="%s"`, hex, opacity)
+}
+
+// Writes the sprite of piece as an SVG symbol, one rectangle per run of cells
+func writeSVGSymbol(b *strings.Builder, piece chessongo.Piece) {
+	outline, body := PieceOutlineColor, WhitePieceColor
+	if piece.IsBlack() {
+		outline, body = BlackOutlineColor, BlackPieceColor
+	}
+	fmt.Fprintf(b, `<symbol id="%s" viewBox="0 0 %d %d">`, piece.ToString(), spriteSize, spriteSize)
+	for y, row := range sprites[piece.Kind()] {
+		for x := 0; x < spriteSize; {
+			c := row[x]
+			run := 1
+			for x+run < spriteSize && row[x+run] == c {
+				run++
+			}
+			switch c {
+			case 'x':
+				fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="1" %s/>`, x, y, run, svgFill(outline))
+			case 'o':
+				fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="1" %s/>`, x, y, run, svgFill(body))
+			}
+			x += run
+		}
+	}
+	b.WriteString("</symbol>\n")
+}
+
+// SVG writes a diagram of the position as a standalone SVG document. The
+// pieces are drawn from the same sprites as PNG images, so the diagram needs
+// no fonts or external files.
+func SVG(w io.Writer, p *chessongo.Position, opts Options) error {
+	size := opts.squareSize()
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		8*size, 8*size, 8*size, 8*size)
+
+	b.WriteString("<defs>\n")
+	used := map[chessongo.Piece]bool{}
+	for _, piece := range p.Squares {
+		if piece != chessongo.EMPTY && !used[piece] {
+			used[piece] = true
+			writeSVGSymbol(&b, piece)
+		}
+	}
+	b.WriteString("</defs>\n")
+
+	highlights := opts.highlights(p)
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			sq := opts.squareAt(row, col)
+			fill := DarkSquareColor
+			if isLightSquare(sq) {
+				fill = LightSquareColor
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" %s/>`+"\n", col*size, row*size, size, size, svgFill(fill))
+			for _, overlay := range []color.Color{highlights[sq], opts.Squares[sq]} {
+				if overlay != nil {
+					fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" %s/>`+"\n", col*size, row*size, size, size, svgFill(overlay))
+				}
+			}
+		}
+	}
+
+	if !opts.NoCoordinates {
+		fontSize := max(size/4, 1)
+		for i := 0; i < 8; i++ {
+			// rank numbers in the top left corner of the left column, file
+			// letters in the bottom right corner of the bottom row, in the
+			// color of the other squares
+			rankSq, fileSq := opts.squareAt(i, 0), opts.squareAt(7, i)
+			fmt.Fprintf(&b, `<text x="%d" y="%d" font-family="sans-serif" font-size="%d" %s>%s</text>`+"\n",
+				size/20+1, i*size+fontSize, fontSize, svgFill(otherSquareColor(rankSq)), rankSq.Coords()[1:])
+			fmt.Fprintf(&b, `<text x="%d" y="%d" font-family="sans-serif" font-size="%d" text-anchor="end" %s>%s</text>`+"\n",
+				(i+1)*size-size/20-1, 8*size-size/20-1, fontSize, svgFill(otherSquareColor(fileSq)), fileSq.FileLetter())
+		}
+	}
+
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			if piece := p.Squares[opts.squareAt(row, col)]; piece != chessongo.EMPTY {
+				fmt.Fprintf(&b, `<use xlink:href="#%s" x="%d" y="%d" width="%d" height="%d"/>`+"\n",
+					piece.ToString(), col*size, row*size, size, size)
+			}
+		}
+	}
+
+	for _, arrow := range opts.Arrows {
+		from, to, width, head, ok := opts.arrowShape(arrow)
+		if !ok {
+			continue
+		}
+		c := arrow.Color
+		if c == nil {
+			c = DefaultArrowColor
+		}
+		hex, opacity := svgColor(c)
+		fmt.Fprintf(&b, `<g opacity="%s"><line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%.1f"/>`,
+			opacity, from.x, from.y, to.x, to.y, hex, width)
+		fmt.Fprintf(&b, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s"/></g>`+"\n",
+			head[0].x, head[0].y, head[1].x, head[1].y, head[2].x, head[2].y, hex)
+	}
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// SVGString returns the diagram drawn by SVG
+func SVGString(p *chessongo.Position, opts Options) string {
+	var b strings.Builder
+	SVG(&b, p, opts)
+	return b.String()
+}
+
+// Returns the color of the squares of the other color than sq
+func otherSquareColor(sq chessongo.Square) color.Color {
+	if isLightSquare(sq) {
+		return DarkSquareColor
+	}
+	return LightSquareColor
+}
@@ -0,0 +1,66 @@
+package render
+
+import (
+	"encoding/xml"
+	"image/color"
+	"io"
+	"strings"
+	"testing"
+
+	"chessongo"
+	"github.com/stretchr/testify/require"
+)
+
+// Checks that s is well-formed XML
+func requireWellFormed(t *testing.T, s string) {
+	d := xml.NewDecoder(strings.NewReader(s))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	p := mustPosition(t, chessongo.STARTING_POSITION_FEN)
+	svg := SVGString(p, Options{})
+	requireWellFormed(t, svg)
+	require.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`))
+	require.Contains(t, svg, `width="360" height="360"`)
+	// one symbol per kind of piece present, one use per piece
+	require.Equal(t, 12, strings.Count(svg, "<symbol "))
+	require.Equal(t, 32, strings.Count(svg, "<use "))
+	require.Contains(t, svg, `<use xlink:href="#wK" x="180" y="315"`)
+	require.Equal(t, 16, strings.Count(svg, "<text "))
+	require.Contains(t, svg, ">a</text>")
+
+	flipped := SVGString(p, Options{Orientation: chessongo.BLACK, NoCoordinates: true, SquareSize: 10})
+	requireWellFormed(t, flipped)
+	require.Contains(t, flipped, `<use xlink:href="#wK" x="30" y="0"`)
+	require.NotContains(t, flipped, "<text ")
+}
+
+func TestSVGHighlightsAndArrows(t *testing.T) {
+	p := mustPosition(t, "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3")
+	m := chessongo.NewMove(chessongo.COORDS_TO_SQUARE["d8"], chessongo.COORDS_TO_SQUARE["h4"], chessongo.EMPTY)
+	svg := SVGString(p, Options{
+		LastMove:       m,
+		HighlightCheck: true,
+		Squares:        map[chessongo.Square]color.Color{chessongo.COORDS_TO_SQUARE["a1"]: color.NRGBA{0, 0, 0xff, 0xff}},
+		Arrows: []Arrow{
+			{From: chessongo.COORDS_TO_SQUARE["h4"], To: chessongo.COORDS_TO_SQUARE["e1"]},
+			{From: chessongo.COORDS_TO_SQUARE["a2"], To: chessongo.COORDS_TO_SQUARE["a2"]},
+		},
+	})
+	requireWellFormed(t, svg)
+	// the king in check on e1
+	require.Contains(t, svg, `<rect x="180" y="315" width="45" height="45" fill="#ff0000" fill-opacity="0.502"/>`)
+	// the last move
+	require.Equal(t, 2, strings.Count(svg, `fill="#9bc700"`))
+	require.Contains(t, svg, `<rect x="0" y="315" width="45" height="45" fill="#0000ff"/>`)
+	// arrows to the same square are left out
+	require.Equal(t, 1, strings.Count(svg, "<polygon "))
+	require.Contains(t, svg, `<line x1="337.5" y1="202.5"`)
+}
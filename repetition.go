@@ -0,0 +1,88 @@
+package chessongo
+
+// hasLegalEnPassant tells whether the side to move can actually capture on
+// the en-passant square. A square left behind by a double pawn push that no
+// pawn can (legally) capture on does not change the position under FIDE
+// Article 9.2, so it must not take part in repetition identity.
+func (p *Position) hasLegalEnPassant() bool {
+	ep := p.EnPassant
+	if ep == 0 || p.Squares[ep] != EMPTY {
+		return false
+	}
+	var capSq Square
+	var ourPawn, theirPawn Piece
+	if p.Turn == WHITE {
+		if ep.Rank() != 2 {
+			return false
+		}
+		capSq, ourPawn, theirPawn = ep+8, W_PAWN, B_PAWN
+	} else {
+		if ep.Rank() != 5 {
+			return false
+		}
+		capSq, ourPawn, theirPawn = ep-8, B_PAWN, W_PAWN
+	}
+	if p.Squares[capSq] != theirPawn {
+		return false
+	}
+	for _, shift := range [2]int{-1, 1} {
+		file := ep.File() + shift
+		if IsCoordsOutofBoard(capSq.Rank(), file) {
+			continue
+		}
+		from := CoordsToSquare(capSq.Rank(), file)
+		if p.Squares[from] != ourPawn {
+			continue
+		}
+		if !p.WillMoveCauseCheck(NewEnPassantMove(from, ep, theirPawn)) {
+			return true
+		}
+	}
+	return false
+}
+
+// RepetitionCount returns how many times the current position has occurred.
+func (g *Game) RepetitionCount() int {
+	if g.PositionHistory == nil {
+		return 0
+	}
+	return g.PositionHistory[g.ZobristHash]
+}
+
+// positionHashAt returns the hash of the position reached after the given
+// ply, counted from the position the game was loaded from.
+func (g *Game) positionHashAt(ply int) uint64 {
+	if ply == len(g.History) {
+		return g.ZobristHash
+	}
+	return g.History[ply].ZobristHash
+}
+
+// RepetitionPlies lists the plies at which the current position occurred,
+// oldest first. Ply 0 is the position the game was loaded from; positions
+// recorded before that (e.g. restored by UnmarshalBinary) are not listed.
+func (g *Game) RepetitionPlies() []int {
+	var plies []int
+	for ply := 0; ply <= len(g.History); ply++ {
+		if g.positionHashAt(ply) == g.ZobristHash {
+			plies = append(plies, ply)
+		}
+	}
+	return plies
+}
+
+// Repetitions returns every position that occurred more than once, keyed by
+// its Zobrist hash, together with the plies it occurred at.
+func (g *Game) Repetitions() map[uint64][]int {
+	seen := map[uint64][]int{}
+	for ply := 0; ply <= len(g.History); ply++ {
+		hash := g.positionHashAt(ply)
+		seen[hash] = append(seen[hash], ply)
+	}
+	for hash, plies := range seen {
+		if len(plies) < 2 {
+			delete(seen, hash)
+		}
+	}
+	return seen
+}
@@ -0,0 +1,74 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func playCoords(g *Game, from, to string) {
+	fromSq := COORDS_TO_SQUARE[from]
+	toSq := COORDS_TO_SQUARE[to]
+	g.MakeMove(NewMove(fromSq, toSq, g.Squares[toSq]))
+}
+
+func TestHasLegalEnPassant(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		expect bool
+	}{
+		{"noSquare", STARTING_POSITION_FEN, false},
+		{"capturable", "rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 3", true},
+		{"noAdjacentPawn", "rnbqkbnr/pppppppp/8/4p3/3P4/8/PPP1PPPP/RNBQKBNR w KQkq e6 0 2", false},
+		// Capturing would expose the white king on a5 to the rook on h5.
+		{"pinnedAlongRank", "8/8/8/KPp4r/8/8/8/7k w - c6 0 1", false},
+		{"blackCapturable", "4k3/8/8/8/3pP3/8/8/4K3 b - e3 0 1", true},
+	}
+
+	for _, tt := range tests {
+		g := &Game{}
+		require.NoError(t, g.LoadFen(tt.fen))
+		require.Equalf(t, tt.expect, g.hasLegalEnPassant(), "hasLegalEnPassant %s", tt.name)
+	}
+}
+
+func TestToFenLegalEnPassantOnly(t *testing.T) {
+	g := &Game{}
+	fen := "rnbqkbnr/pppppppp/8/4p3/3P4/8/PPP1PPPP/RNBQKBNR w KQkq e6 0 2"
+	require.NoError(t, g.LoadFen(fen))
+	require.Equal(t, fen, g.ToFen())
+	require.Equal(t, "rnbqkbnr/pppppppp/8/4p3/3P4/8/PPP1PPPP/RNBQKBNR w KQkq - 0 2",
+		g.ToFenWithOptions(FenOptions{LegalEnPassantOnly: true}))
+
+	fen = "rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 3"
+	require.NoError(t, g.LoadFen(fen))
+	require.Equal(t, fen, g.ToFenWithOptions(FenOptions{LegalEnPassantOnly: true}))
+}
+
+func TestRepetitionBrokenByCapturableEnPassant(t *testing.T) {
+	g := &Game{}
+	require.NoError(t, g.LoadFen("rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 3"))
+	for _, mv := range [][2]string{{"g1", "f3"}, {"g8", "f6"}, {"f3", "g1"}, {"f6", "g8"}} {
+		playCoords(g, mv[0], mv[1])
+	}
+	// The capture exd6 was possible only in the start position.
+	require.Equal(t, 1, g.RepetitionCount())
+}
+
+func TestRepetitionPlies(t *testing.T) {
+	g := NewGame()
+	require.Equal(t, []int{0}, g.RepetitionPlies())
+	cycle := [][2]string{{"g1", "f3"}, {"g8", "f6"}, {"f3", "g1"}, {"f6", "g8"}}
+	for i := 0; i < 2; i++ {
+		for _, mv := range cycle {
+			playCoords(g, mv[0], mv[1])
+		}
+	}
+	require.Equal(t, 3, g.RepetitionCount())
+	require.Equal(t, []int{0, 4, 8}, g.RepetitionPlies())
+
+	repetitions := g.Repetitions()
+	require.Len(t, repetitions, 4)
+	require.Equal(t, []int{0, 4, 8}, repetitions[g.ZobristHash])
+}
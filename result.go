@@ -0,0 +1,66 @@
+package chessongo
+
+// Game results, as written in PGN
+const (
+	RESULT_WHITE_WINS = "1-0"
+	RESULT_BLACK_WINS = "0-1"
+	RESULT_DRAW       = "1/2-1/2"
+	RESULT_ONGOING    = "*"
+)
+
+// Termination tells why a game ended
+type Termination uint8
+
+const (
+	TERMINATION_NONE Termination = iota
+	TERMINATION_CHECKMATE
+	TERMINATION_STALEMATE
+	TERMINATION_INSUFFICIENT_MATERIAL
+	TERMINATION_DEAD_POSITION
+	TERMINATION_FIVEFOLD_REPETITION
+	TERMINATION_SEVENTY_FIVE_MOVE_RULE
+	// Decided by the players or the clock rather than the board
+	TERMINATION_RESIGNATION
+	TERMINATION_DRAW_AGREEMENT
+	TERMINATION_TIMEOUT
+)
+
+var TERMINATION_TO_STRING = map[Termination]string{
+	TERMINATION_NONE:                   "none",
+	TERMINATION_CHECKMATE:              "checkmate",
+	TERMINATION_STALEMATE:              "stalemate",
+	TERMINATION_INSUFFICIENT_MATERIAL:  "insufficient material",
+	TERMINATION_DEAD_POSITION:          "dead position",
+	TERMINATION_FIVEFOLD_REPETITION:    "fivefold repetition",
+	TERMINATION_SEVENTY_FIVE_MOVE_RULE: "seventy-five-move rule",
+	TERMINATION_RESIGNATION:            "resignation",
+	TERMINATION_DRAW_AGREEMENT:         "draw agreement",
+	TERMINATION_TIMEOUT:                "timeout",
+}
+
+func (t Termination) String() string {
+	return TERMINATION_TO_STRING[t]
+}
+
+// Result returns the PGN result of the game together with the reason it
+// ended. Games that are still running report RESULT_ONGOING.
+func (g *Game) Result() (string, Termination) {
+	switch {
+	case g.IsCheckmate:
+		if g.Turn == WHITE {
+			return RESULT_BLACK_WINS, TERMINATION_CHECKMATE
+		}
+		return RESULT_WHITE_WINS, TERMINATION_CHECKMATE
+	case g.IsStalement:
+		return RESULT_DRAW, TERMINATION_STALEMATE
+	case g.IsMaterialDraw:
+		return RESULT_DRAW, TERMINATION_INSUFFICIENT_MATERIAL
+	case g.IsDeadDraw:
+		return RESULT_DRAW, TERMINATION_DEAD_POSITION
+	case g.IsFivefoldRepetition():
+		return RESULT_DRAW, TERMINATION_FIVEFOLD_REPETITION
+	case g.IsSeventyFiveMoveRule:
+		return RESULT_DRAW, TERMINATION_SEVENTY_FIVE_MOVE_RULE
+	}
+	return RESULT_ONGOING, TERMINATION_NONE
+}
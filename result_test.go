@@ -0,0 +1,32 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResult(t *testing.T) {
+	g := NewGame()
+	result, termination := g.Result()
+	require.Equal(t, RESULT_ONGOING, result)
+	require.Equal(t, TERMINATION_NONE, termination)
+
+	require.NoError(t, g.LoadPGN("1. f3 e5 2. g4 Qh4#"))
+	result, termination = g.Result()
+	require.Equal(t, RESULT_BLACK_WINS, result)
+	require.Equal(t, TERMINATION_CHECKMATE, termination)
+	require.Equal(t, "checkmate", termination.String())
+
+	require.NoError(t, g.LoadFen("7k/5Q2/6K1/8/8/8/8/8 w - - 0 1"))
+	playCoords(g, "f7", "g7")
+	require.True(t, g.IsCheckmate)
+	result, _ = g.Result()
+	require.Equal(t, RESULT_WHITE_WINS, result)
+
+	require.NoError(t, g.LoadFen("7k/8/5QK1/8/8/8/8/8 w - - 0 1"))
+	playCoords(g, "f6", "f7")
+	result, termination = g.Result()
+	require.Equal(t, RESULT_DRAW, result)
+	require.Equal(t, TERMINATION_STALEMATE, termination)
+}
@@ -0,0 +1,330 @@
+package chessongo
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+const (
+	E_STALE_GAME      = "e:stale:game"
+	E_ILLEGAL_MOVE    = "e:illegal:move"
+	E_GAME_FINISHED   = "e:game:finished"
+	E_NOTHING_TO_UNDO = "e:nothing-to-undo"
+	E_GAME_NOT_FOUND  = "e:game:not-found"
+	E_GAME_CLOSED     = "e:game:closed"
+)
+
+var (
+	// ErrStaleGame is returned when the game moved on since the caller looked at it
+	ErrStaleGame     = errors.New(E_STALE_GAME)
+	ErrIllegalMove   = errors.New(E_ILLEGAL_MOVE)
+	ErrGameFinished  = errors.New(E_GAME_FINISHED)
+	ErrNothingToUndo = errors.New(E_NOTHING_TO_UNDO)
+	ErrGameNotFound  = errors.New(E_GAME_NOT_FOUND)
+	ErrGameClosed    = errors.New(E_GAME_CLOSED)
+)
+
+// ANY_PLY disables the optimistic concurrency check of SafeGame.MakeMove and
+// SafeGame.Undo
+const ANY_PLY = -1
+
+// MoveEvent is sent to subscribers of a SafeGame after every change
+type MoveEvent struct {
+	GameID string
+	// Move that was played, or taken back when Undone is set
+	Move   Move
+	San    string
+	Undone bool
+	// Set when Move is a premove, played as soon as its turn came
+	Premove bool
+	// State of the game after the change
+	Ply         int
+	Fen         string
+	Hash        uint64
+	Result      string
+	Termination Termination
+}
+
+// GameSnapshot is a copy of the state of a SafeGame. It shares nothing with
+// the game, so it can be read without holding any lock.
+type GameSnapshot struct {
+	GameID   string
+	Position Position
+	Fen      string
+	// Number of plies played since the game was started
+	Ply int
+	// Moves played since the game was started, in order, with their SAN
+	Moves      []Move
+	San        []string
+	LegalMoves []Move
+	// Premoves queued by the side not to move, the next one to play first
+	Premoves    []Move
+	IsCheck     bool
+	Result      string
+	Termination Termination
+}
+
+// SafeGame wraps a Game for concurrent use. Readers take snapshots, writers
+// are serialized by a per-game lock and can make their change conditional on
+// the ply or hash they last saw, so that two clients racing to move on the
+// same position cannot both succeed.
+type SafeGame struct {
+	mu          sync.RWMutex
+	id          string
+	game        *Game
+	san         []string
+	subscribers map[int]chan MoveEvent
+	nextSub     int
+	closed      bool
+}
+
+// NewSafeGame takes ownership of g; the caller must not use g afterwards.
+// The moves already played in g can be taken back with Undo.
+func NewSafeGame(id string, g *Game) *SafeGame {
+	g.GenerateLegalMoves()
+	return &SafeGame{
+		id:          id,
+		game:        g,
+		san:         g.SANHistory(),
+		subscribers: map[int]chan MoveEvent{},
+	}
+}
+
+// NewSafeGameFromPGN loads the main line of pgn into a new SafeGame. Its
+// moves are part of the game's history and can be taken back with Undo.
+func NewSafeGameFromPGN(id string, pgn string) (*SafeGame, error) {
+	g, err := LoadPGNGame(pgn)
+	if err != nil {
+		return nil, err
+	}
+	return NewSafeGame(id, g), nil
+}
+
+func (s *SafeGame) ID() string {
+	return s.id
+}
+
+// Ply returns the number of plies played since the game was started
+func (s *SafeGame) Ply() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.game.History)
+}
+
+// Hash returns the Zobrist hash of the current position
+func (s *SafeGame) Hash() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.game.ZobristHash
+}
+
+// Snapshot returns a copy of the current state of the game
+func (s *SafeGame) Snapshot() GameSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result, termination := s.game.Result()
+	return GameSnapshot{
+		GameID:      s.id,
+		Position:    s.game.Position,
+		Fen:         s.game.ToFen(),
+		Ply:         len(s.game.History),
+		Moves:       s.game.Moves(),
+		San:         slices.Clone(s.san),
+		LegalMoves:  slices.Clone(s.game.LegalMoves),
+		Premoves:    s.game.Premoves(),
+		IsCheck:     s.game.IsCheck,
+		Result:      result,
+		Termination: termination,
+	}
+}
+
+// Read calls fn with the game while holding a read lock. fn must not modify
+// the game nor keep a reference to it after returning.
+func (s *SafeGame) Read(fn func(g *Game)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.game)
+}
+
+// MakeMove plays m if the game is still at expectedPly (ANY_PLY to skip the
+// check). Only the from and to squares and the promotion of m are looked at,
+// so the move does not need to carry the captured piece. When m lets a
+// premove of the opponent play, subscribers get an event for each of the two
+// plies and the one of m is returned.
+func (s *SafeGame) MakeMove(m Move, expectedPly int) (MoveEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if expectedPly != ANY_PLY && expectedPly != len(s.game.History) {
+		return MoveEvent{}, ErrStaleGame
+	}
+	return s.play(m)
+}
+
+// MakeMoveIfHash plays m if the current position still has the given hash
+func (s *SafeGame) MakeMoveIfHash(m Move, expectedHash uint64) (MoveEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if expectedHash != s.game.ZobristHash {
+		return MoveEvent{}, ErrStaleGame
+	}
+	return s.play(m)
+}
+
+// Undo takes back the last move if the game is still at expectedPly (ANY_PLY
+// to skip the check)
+func (s *SafeGame) Undo(expectedPly int) (MoveEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return MoveEvent{}, ErrGameClosed
+	}
+	if expectedPly != ANY_PLY && expectedPly != len(s.game.History) {
+		return MoveEvent{}, ErrStaleGame
+	}
+	m, err := s.game.Undo()
+	if err != nil {
+		return MoveEvent{}, err
+	}
+	san := s.san[len(s.san)-1]
+	s.san = s.san[:len(s.san)-1]
+	event := s.event(m, san)
+	event.Undone = true
+	s.publish(event)
+	return event, nil
+}
+
+// QueuePremove queues m as a premove of color, see Game.QueuePremove. It
+// fails with ErrStaleGame when color is to move, the opponent having moved
+// since the caller looked at the game: m can then be played as a move.
+func (s *SafeGame) QueuePremove(m Move, color Color) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrGameClosed
+	}
+	if color == s.game.Turn {
+		return ErrStaleGame
+	}
+	return s.game.QueuePremove(m)
+}
+
+// CancelPremoves drops every queued premove
+func (s *SafeGame) CancelPremoves() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.game.CancelPremoves()
+}
+
+// SetPremoveMode sets how many premoves the game queues, see
+// Game.SetPremoveMode
+func (s *SafeGame) SetPremoveMode(mode PremoveMode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.game.SetPremoveMode(mode)
+}
+
+// Subscribe returns a channel receiving every change of the game, and a
+// function ending the subscription. Events are never allowed to hold up the
+// game: a subscriber whose buffer is full is dropped and its channel closed,
+// after which it should take a new snapshot and subscribe again.
+func (s *SafeGame) Subscribe(buffer int) (<-chan MoveEvent, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := make(chan MoveEvent, buffer)
+	if s.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	id := s.nextSub
+	s.nextSub++
+	s.subscribers[id] = ch
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.unsubscribe(id)
+	}
+}
+
+// Close ends every subscription and rejects further changes
+func (s *SafeGame) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for id := range s.subscribers {
+		s.unsubscribe(id)
+	}
+}
+
+// Plays m; the caller holds the write lock
+func (s *SafeGame) play(m Move) (MoveEvent, error) {
+	if s.closed {
+		return MoveEvent{}, ErrGameClosed
+	}
+	if s.game.IsFinished {
+		return MoveEvent{}, ErrGameFinished
+	}
+	legal, ok := s.game.matchLegalMove(m)
+	if !ok {
+		return MoveEvent{}, ErrIllegalMove
+	}
+	// m may let a premove play, each ply is recorded in the state it left
+	var events []MoveEvent
+	id := s.game.AddObserver(ObserverFunc(func(g *Game, e GameEvent) {
+		if e.Kind == EVENT_MOVE_PLAYED {
+			event := s.event(e.Move, e.San)
+			event.Premove = e.Flags&MOVE_FLAG_PREMOVE > 0
+			events = append(events, event)
+		}
+	}))
+	s.game.MakeMove(legal)
+	s.game.RemoveObserver(id)
+	for _, event := range events {
+		s.san = append(s.san, event.San)
+		s.publish(event)
+	}
+	return events[0], nil
+}
+
+func (s *SafeGame) event(m Move, san string) MoveEvent {
+	result, termination := s.game.Result()
+	return MoveEvent{
+		GameID:      s.id,
+		Move:        m,
+		San:         san,
+		Ply:         len(s.game.History),
+		Fen:         s.game.ToFen(),
+		Hash:        s.game.ZobristHash,
+		Result:      result,
+		Termination: termination,
+	}
+}
+
+// Sends event to every subscriber without blocking; the caller holds the write lock
+func (s *SafeGame) publish(event MoveEvent) {
+	for id, ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+			s.unsubscribe(id)
+		}
+	}
+}
+
+// Closes the channel of subscriber id, if still subscribed; the caller holds the write lock
+func (s *SafeGame) unsubscribe(id int) {
+	if ch, ok := s.subscribers[id]; ok {
+		delete(s.subscribers, id)
+		close(ch)
+	}
+}
+
+// Finds the legal move with the from and to squares and promotion of m
+func (g *Game) matchLegalMove(m Move) (Move, bool) {
+	for _, legal := range g.LegalMoves {
+		if legal.From() == m.From() && legal.To() == m.To() && legal.GetPromotionTo() == m.GetPromotionTo() {
+			return legal, true
+		}
+	}
+	return 0, false
+}
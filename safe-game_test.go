@@ -0,0 +1,166 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func coordsMove(from, to string) Move {
+	return NewMove(COORDS_TO_SQUARE[from], COORDS_TO_SQUARE[to], EMPTY)
+}
+
+func TestSafeGameOptimisticMoves(t *testing.T) {
+	sg := NewSafeGame("g", NewGame())
+
+	event, err := sg.MakeMove(coordsMove("e2", "e4"), 0)
+	require.NoError(t, err)
+	require.Equal(t, "e4", event.San)
+	require.Equal(t, 1, event.Ply)
+	require.Equal(t, RESULT_ONGOING, event.Result)
+
+	// a second client still looking at ply 0 loses the race
+	_, err = sg.MakeMove(coordsMove("d2", "d4"), 0)
+	require.ErrorIs(t, err, ErrStaleGame)
+
+	_, err = sg.MakeMove(coordsMove("e4", "e5"), ANY_PLY)
+	require.ErrorIs(t, err, ErrIllegalMove)
+
+	_, err = sg.MakeMoveIfHash(coordsMove("e7", "e5"), event.Hash+1)
+	require.ErrorIs(t, err, ErrStaleGame)
+	event, err = sg.MakeMoveIfHash(coordsMove("e7", "e5"), event.Hash)
+	require.NoError(t, err)
+	require.Equal(t, 2, sg.Ply())
+	require.Equal(t, event.Hash, sg.Hash())
+
+	snapshot := sg.Snapshot()
+	require.Equal(t, []string{"e4", "e5"}, snapshot.San)
+	require.Equal(t, "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2", snapshot.Fen)
+	require.Len(t, snapshot.LegalMoves, 29)
+
+	_, err = sg.Undo(1)
+	require.ErrorIs(t, err, ErrStaleGame)
+	event, err = sg.Undo(2)
+	require.NoError(t, err)
+	require.True(t, event.Undone)
+	require.Equal(t, "e5", event.San)
+	require.Equal(t, 1, sg.Ply())
+
+	// the snapshot taken before is not affected
+	require.Equal(t, 2, snapshot.Ply)
+	require.Equal(t, []string{"e4", "e5"}, snapshot.San)
+
+	_, err = sg.Undo(ANY_PLY)
+	require.NoError(t, err)
+	_, err = sg.Undo(ANY_PLY)
+	require.ErrorIs(t, err, ErrNothingToUndo)
+}
+
+func TestSafeGameRejectsMovesWhenFinished(t *testing.T) {
+	g := &Game{}
+	require.NoError(t, g.LoadFen("rnbqkbnr/ppppp2p/5p2/6p1/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 3"))
+	sg := NewSafeGame("g", g)
+	event, err := sg.MakeMove(coordsMove("d1", "h5"), ANY_PLY)
+	require.NoError(t, err)
+	require.Equal(t, "Qh5#", event.San)
+	require.Equal(t, RESULT_WHITE_WINS, event.Result)
+	require.Equal(t, TERMINATION_CHECKMATE, event.Termination)
+
+	_, err = sg.MakeMove(coordsMove("e8", "f7"), ANY_PLY)
+	require.ErrorIs(t, err, ErrGameFinished)
+}
+
+func TestSafeGameSubscriptions(t *testing.T) {
+	sg := NewSafeGame("g", NewGame())
+	events, unsubscribe := sg.Subscribe(4)
+	slow, _ := sg.Subscribe(0)
+
+	_, err := sg.MakeMove(coordsMove("g1", "f3"), ANY_PLY)
+	require.NoError(t, err)
+	_, err = sg.Undo(ANY_PLY)
+	require.NoError(t, err)
+
+	played := <-events
+	require.Equal(t, "Nf3", played.San)
+	require.False(t, played.Undone)
+	undone := <-events
+	require.True(t, undone.Undone)
+	require.Equal(t, 0, undone.Ply)
+
+	// the subscriber that could not keep up was dropped
+	_, open := <-slow
+	require.False(t, open)
+
+	unsubscribe()
+	unsubscribe()
+	_, open = <-events
+	require.False(t, open)
+
+	closing, _ := sg.Subscribe(1)
+	sg.Close()
+	_, open = <-closing
+	require.False(t, open)
+	_, err = sg.MakeMove(coordsMove("g1", "f3"), ANY_PLY)
+	require.ErrorIs(t, err, ErrGameClosed)
+	afterClose, _ := sg.Subscribe(1)
+	_, open = <-afterClose
+	require.False(t, open)
+}
+
+func TestSafeGamePremoves(t *testing.T) {
+	sg := NewSafeGame("g", NewGame())
+	require.ErrorIs(t, sg.QueuePremove(coordsMove("e2", "e4"), WHITE), ErrStaleGame)
+	require.ErrorIs(t, sg.QueuePremove(coordsMove("e7", "e4"), BLACK), ErrInvalidPremove)
+	require.NoError(t, sg.QueuePremove(coordsMove("e7", "e5"), BLACK))
+	require.Equal(t, []Move{coordsMove("e7", "e5")}, sg.Snapshot().Premoves)
+	events, _ := sg.Subscribe(4)
+
+	event, err := sg.MakeMove(coordsMove("e2", "e4"), 0)
+	require.NoError(t, err)
+	require.Equal(t, "e4", event.San)
+	require.Equal(t, 1, event.Ply)
+	require.False(t, event.Premove)
+	require.Equal(t, event, <-events)
+	premoved := <-events
+	require.Equal(t, "e5", premoved.San)
+	require.Equal(t, 2, premoved.Ply)
+	require.True(t, premoved.Premove)
+	require.Equal(t, "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2", premoved.Fen)
+
+	snapshot := sg.Snapshot()
+	require.Equal(t, []string{"e4", "e5"}, snapshot.San)
+	require.Len(t, snapshot.Moves, 2)
+	require.Empty(t, snapshot.Premoves)
+	event, err = sg.Undo(2)
+	require.NoError(t, err)
+	require.Equal(t, "e5", event.San)
+
+	sg.SetPremoveMode(PREMOVE_MULTI)
+	require.NoError(t, sg.QueuePremove(coordsMove("g1", "f3"), WHITE))
+	require.NoError(t, sg.QueuePremove(coordsMove("f3", "g5"), WHITE))
+	sg.CancelPremoves()
+	require.Empty(t, sg.Snapshot().Premoves)
+}
+
+func TestSafeGameFromPGN(t *testing.T) {
+	sg, err := NewSafeGameFromPGN("g", "1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7#")
+	require.NoError(t, err)
+	snapshot := sg.Snapshot()
+	require.Equal(t, []string{"e4", "e5", "Qh5", "Nc6", "Bc4", "Nf6", "Qxf7#"}, snapshot.San)
+	require.Equal(t, 7, snapshot.Ply)
+	require.Equal(t, RESULT_WHITE_WINS, snapshot.Result)
+
+	event, err := sg.Undo(7)
+	require.NoError(t, err)
+	require.Equal(t, "Qxf7#", event.San)
+	require.Len(t, sg.Snapshot().LegalMoves, 43)
+
+	_, err = NewSafeGameFromPGN("g", "1. e4 e5 2. Ke3")
+	require.Error(t, err)
+
+	gm := NewGameManager()
+	sg, err = gm.CreateFromPGN("1. d4 d5")
+	require.NoError(t, err)
+	require.NotEmpty(t, sg.ID())
+	require.Equal(t, []string{"d4", "d5"}, sg.Snapshot().San)
+}
@@ -0,0 +1,86 @@
+package chessongo
+
+// Returns the least valuable piece of the given color attacking sq, EMPTY if none
+func (g *Game) leastValuableAttacker(sq Square, by Color, occupied Bitboard) (Square, Piece) {
+	attackers := g.attackersOf(sq, by, occupied)
+	if attackers == 0 {
+		return 0, EMPTY
+	}
+	pieces := g.piecesOf(by)
+	for _, kind := range [6]Piece{PAWN, KNIGHT, BISHOP, ROOK, QUEEN, KING} {
+		if bb := attackers & pieces[kind]; bb > 0 {
+			from := Square(bb.lsbIndex())
+			return from, g.Squares[from]
+		}
+	}
+	return 0, EMPTY
+}
+
+// SEE statically evaluates the exchange started by m on its target square
+// and returns the expected material balance for the moving side, in
+// centipawns (see PIECE_VALUES). Both sides recapture with their least
+// valuable attacker and may stop whenever continuing would lose material.
+// Sliders behind the capturing pieces join in as the line opens; pawns
+// reaching the last rank count as queens. Pins are not taken into account.
+// m must be played from the current position.
+func (g *Game) SEE(m Move) int {
+	from, to := m.From(), m.To()
+	mover := g.Squares[from]
+	if mover == EMPTY || m.IsCastlingMove() {
+		return 0
+	}
+	var gain [32]int
+	occupied := g.Occupied &^ (Bitboard(1) << from)
+	onValue := mover.Value()
+	if m.IsEnPassant() {
+		gain[0] = PIECE_VALUES[PAWN]
+		if mover.Color() == WHITE {
+			occupied &^= Bitboard(1) << (to + 8)
+		} else {
+			occupied &^= Bitboard(1) << (to - 8)
+		}
+	} else {
+		gain[0] = g.Squares[to].Value()
+	}
+	if promoteTo := m.GetPromotionTo(); promoteTo > 0 {
+		gain[0] += PIECE_VALUES[promoteTo] - PIECE_VALUES[PAWN]
+		onValue = PIECE_VALUES[promoteTo]
+	}
+	promotionRank := (RANK1_MASK|RANK8_MASK)&(Bitboard(1)<<to) > 0
+
+	depth := 0
+	side := opponentColor(mover.Color())
+	for depth < len(gain)-1 {
+		sq, attacker := g.leastValuableAttacker(to, side, occupied)
+		if attacker == EMPTY {
+			break
+		}
+		// the king can only take if nothing defends the square any more
+		if attacker.Kind() == KING && g.attackersOf(to, opponentColor(side), occupied&^(Bitboard(1)<<sq)) > 0 {
+			break
+		}
+		depth++
+		gain[depth] = onValue - gain[depth-1]
+		onValue = attacker.Value()
+		if attacker.Kind() == PAWN && promotionRank {
+			gain[depth] += PIECE_VALUES[QUEEN] - PIECE_VALUES[PAWN]
+			onValue = PIECE_VALUES[QUEEN]
+		}
+		occupied &^= Bitboard(1) << sq
+		side = opponentColor(side)
+	}
+	// each side picks the better of standing pat and continuing the exchange
+	for ; depth > 0; depth-- {
+		best := gain[depth]
+		if -gain[depth-1] > best {
+			best = -gain[depth-1]
+		}
+		gain[depth-1] = -best
+	}
+	return gain[0]
+}
+
+// SEEGreaterOrEqual tells whether SEE(m) is at least threshold
+func (g *Game) SEEGreaterOrEqual(m Move, threshold int) bool {
+	return g.SEE(m) >= threshold
+}
@@ -0,0 +1,47 @@
+package chessongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func findMove(t *testing.T, g *Game, from, to string) Move {
+	g.GenerateLegalMoves()
+	for _, m := range g.LegalMoves {
+		if m.From() == COORDS_TO_SQUARE[from] && m.To() == COORDS_TO_SQUARE[to] {
+			return m
+		}
+	}
+	t.Fatalf("move %s%s not found", from, to)
+	return 0
+}
+
+func TestSEE(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		from, to string
+		expect   int
+	}{
+		{"freePawn", "1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1", "e1", "e5", 100},
+		{"defendedPawnByQueen", "4k3/8/3p4/4p3/8/8/8/4QK2 w - - 0 1", "e1", "e5", 100 - 900},
+		{"knightTakesDefendedPawn", "1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1", "d3", "e5", 100 - 320},
+		{"xrayRookBehindRook", "3r3k/8/8/3p4/8/8/3R4/3R3K w - - 0 1", "d2", "d5", 100},
+		{"doubledRooksOnBothSides", "3r3k/3r4/8/3p4/8/8/3R4/3R3K w - - 0 1", "d2", "d5", 100 - 500},
+		{"xrayBattery", "3r3k/3r4/8/3n4/8/8/3R4/3Q3K w - - 0 1", "d2", "d5", 320 - 500},
+		{"enPassant", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5", "d6", 100},
+		{"promotionCapture", "1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a7", "b8", 500 + 800},
+		{"quietHangingQueen", "4k3/8/8/2p5/8/8/8/3QK3 w - - 0 1", "d1", "d4", -900},
+		{"kingRecapturesUndefended", "8/8/4k3/4p3/8/3N4/8/K7 w - - 0 1", "d3", "e5", 100 - 320},
+		{"kingCannotRecaptureDefended", "8/8/4k3/4p3/8/3N4/8/K3R3 w - - 0 1", "d3", "e5", 100},
+	}
+	for _, tt := range tests {
+		g := &Game{}
+		require.NoError(t, g.LoadFen(tt.fen))
+		m := findMove(t, g, tt.from, tt.to)
+		require.Equalf(t, tt.expect, g.SEE(m), "SEE %s", tt.name)
+		require.Truef(t, g.SEEGreaterOrEqual(m, tt.expect), "SEEGreaterOrEqual %s", tt.name)
+		require.Falsef(t, g.SEEGreaterOrEqual(m, tt.expect+1), "SEEGreaterOrEqual %s", tt.name)
+	}
+}
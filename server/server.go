@@ -0,0 +1,521 @@
+// Package server hosts chess games over HTTP.
+//
+// REST endpoints, all exchanging JSON:
+//
+//	POST   /games                create a game from {"fen"} or {"pgn"}, with an
+//	                             optional {"timeControl"}, {"allowUndo"} and
+//	                             {"multiPremove"}
+//	GET    /games/{id}           state: FEN, legal moves, SAN history, result
+//	DELETE /games/{id}           remove the game
+//	POST   /games/{id}/moves     play {"move"} given in SAN or UCI
+//	POST   /games/{id}/undo      take back the last move, if the game allows it
+//	POST   /games/{id}/resign    {"color"} resigns
+//	POST   /games/{id}/draw      {"color"} offers a draw, or accepts the
+//	                             opponent's offer
+//	POST   /games/{id}/premoves  {"color"} queues {"move"}, given in UCI, to
+//	                             play as soon as the opponent has moved
+//	DELETE /games/{id}/premoves  {"color"} cancels its premoves
+//
+// Premoves are only reported to the player queuing them, in the response
+// listing them. Once its turn comes, a premove is played with no time spent
+// on the clock, and pushed to WebSocket clients like any other move.
+//
+// Requests that change the game may carry the ply the client last saw as
+// {"ply"}; they fail with 409 Conflict if the game has moved on since.
+//
+// GET /games/{id}/ws upgrades to a WebSocket pushing the state on connect,
+// then every move, undo and state change, plus clock updates while a clock
+// is running.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"chessongo"
+)
+
+const (
+	E_BAD_REQUEST          = "e:bad-request"
+	E_UNDO_NOT_ALLOWED     = "e:undo:not-allowed"
+	E_INVALID_COLOR        = "e:invalid:color"
+	E_MISSING_POSITION     = "e:missing:position"
+	E_INVALID_TIME_CONTROL = "e:invalid:time-control"
+)
+
+const defaultClockInterval = time.Second
+
+var errUndoNotAllowed = errors.New(E_UNDO_NOT_ALLOWED)
+
+type Options struct {
+	// Interval between clock updates pushed to WebSocket clients, one second
+	// by default
+	ClockInterval time.Duration
+	// Source of the current time, time.Now by default
+	Now func() time.Time
+}
+
+// Server is an http.Handler serving the games it hosts. Create it with New
+// and release it with Close.
+type Server struct {
+	opts   Options
+	games  *chessongo.GameManager
+	mux    *http.ServeMux
+	mu     sync.Mutex
+	tables map[string]*table
+	closed chan struct{}
+}
+
+func New(opts Options) *Server {
+	if opts.ClockInterval <= 0 {
+		opts.ClockInterval = defaultClockInterval
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	s := &Server{
+		opts:   opts,
+		games:  chessongo.NewGameManager(),
+		mux:    http.NewServeMux(),
+		tables: map[string]*table{},
+		closed: make(chan struct{}),
+	}
+	s.mux.HandleFunc("POST /games", s.handleCreate)
+	s.mux.HandleFunc("GET /games/{id}", s.withTable(s.handleState))
+	s.mux.HandleFunc("DELETE /games/{id}", s.handleDelete)
+	s.mux.HandleFunc("POST /games/{id}/moves", s.withTable(s.handleMove))
+	s.mux.HandleFunc("POST /games/{id}/undo", s.withTable(s.handleUndo))
+	s.mux.HandleFunc("POST /games/{id}/resign", s.withTable(s.handleResign))
+	s.mux.HandleFunc("POST /games/{id}/draw", s.withTable(s.handleDraw))
+	s.mux.HandleFunc("POST /games/{id}/premoves", s.withTable(s.handleQueuePremove))
+	s.mux.HandleFunc("DELETE /games/{id}/premoves", s.withTable(s.handleCancelPremoves))
+	s.mux.HandleFunc("GET /games/{id}/ws", s.handleWebsocket)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close stops the clocks' update loops and disconnects every WebSocket client
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.closed:
+		return
+	default:
+	}
+	close(s.closed)
+	for _, t := range s.tables {
+		t.close()
+	}
+}
+
+type timeControlRequest struct {
+	BaseSeconds      int `json:"baseSeconds"`
+	IncrementSeconds int `json:"incrementSeconds"`
+}
+
+type createRequest struct {
+	Fen          string              `json:"fen"`
+	Pgn          string              `json:"pgn"`
+	TimeControl  *timeControlRequest `json:"timeControl"`
+	AllowUndo    bool                `json:"allowUndo"`
+	MultiPremove bool                `json:"multiPremove"`
+}
+
+type moveRequest struct {
+	Move string `json:"move"`
+	Ply  *int   `json:"ply"`
+}
+
+type colorRequest struct {
+	Color string `json:"color"`
+	Ply   *int   `json:"ply"`
+}
+
+type premoveRequest struct {
+	Color string         `json:"color"`
+	Move  chessongo.Move `json:"move"`
+}
+
+type premovesResponse struct {
+	Premoves []string `json:"premoves"`
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	var sg *chessongo.SafeGame
+	var err error
+	switch {
+	case req.Pgn != "":
+		sg, err = s.games.CreateFromPGN(req.Pgn)
+	case req.Fen != "":
+		sg, err = s.games.Create(req.Fen)
+	default:
+		writeError(w, http.StatusBadRequest, E_MISSING_POSITION)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.MultiPremove {
+		sg.SetPremoveMode(chessongo.PREMOVE_MULTI)
+	}
+	t := newTable(sg, req.AllowUndo)
+	if tc := req.TimeControl; tc != nil {
+		if tc.BaseSeconds <= 0 || tc.IncrementSeconds < 0 {
+			s.games.Remove(sg.ID())
+			writeError(w, http.StatusBadRequest, E_INVALID_TIME_CONTROL)
+			return
+		}
+		t.clock = chessongo.NewClock(chessongo.TimeControl{
+			Base:      time.Duration(tc.BaseSeconds) * time.Second,
+			Increment: time.Duration(tc.IncrementSeconds) * time.Second,
+		})
+	}
+	s.mu.Lock()
+	s.tables[sg.ID()] = t
+	s.mu.Unlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	writeJSON(w, http.StatusCreated, t.state(s.opts.Now()))
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	t, ok := s.tables[id]
+	delete(s.tables, id)
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, chessongo.E_GAME_NOT_FOUND)
+		return
+	}
+	s.games.Remove(id)
+	t.close()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) table(id string) (*table, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tables[id]
+	return t, ok
+}
+
+// Looks up the game of the request and calls handler with its table locked
+func (s *Server) withTable(handler func(w http.ResponseWriter, r *http.Request, t *table, now time.Time)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t, ok := s.table(r.PathValue("id"))
+		if !ok {
+			writeError(w, http.StatusNotFound, chessongo.E_GAME_NOT_FOUND)
+			return
+		}
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		now := s.opts.Now()
+		if t.checkFlag(now) {
+			t.broadcast(message{Type: MESSAGE_STATE, State: t.state(now)})
+		}
+		handler(w, r, t, now)
+	}
+}
+
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request, t *table, now time.Time) {
+	writeJSON(w, http.StatusOK, t.state(now))
+}
+
+func (s *Server) handleMove(w http.ResponseWriter, r *http.Request, t *table, now time.Time) {
+	var req moveRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if t.isOver() {
+		writeLibraryError(w, chessongo.ErrGameFinished)
+		return
+	}
+	var m chessongo.Move
+	var mover chessongo.Color
+	var parseErr error
+	t.game.Read(func(g *chessongo.Game) {
+		mover = g.Turn
+		m, parseErr = g.ParseMove(req.Move)
+	})
+	if parseErr != nil {
+		writeError(w, http.StatusBadRequest, chessongo.E_ILLEGAL_MOVE)
+		return
+	}
+	event, err := t.game.MakeMove(m, expectedPly(req.Ply))
+	if err != nil {
+		writeLibraryError(w, err)
+		return
+	}
+	// the move may have let a premove of the opponent play
+	snapshot := t.game.Snapshot()
+	played := []moveJSON{{Uci: event.Move.Uci(), San: event.San}}
+	for ply := event.Ply; ply < snapshot.Ply; ply++ {
+		played = append(played, moveJSON{Uci: snapshot.Moves[ply].Uci(), San: snapshot.San[ply]})
+	}
+	if t.drawOffer != chessongo.NO_COLOR && (t.drawOffer != mover || len(played) > 1) {
+		// moving on declines the opponent's offer
+		t.drawOffer = chessongo.NO_COLOR
+	}
+	if t.clock != nil {
+		if t.clock.Running() == chessongo.NO_COLOR {
+			// the first move of the game is not timed
+			t.clock.Start(opponent(mover), now)
+		} else {
+			t.clock.Press(now)
+		}
+		if len(played) > 1 {
+			t.clock.PressAfter(now, 0)
+		}
+		if snapshot.Result != chessongo.RESULT_ONGOING {
+			t.clock.Stop(now)
+		} else {
+			s.startTicker(t)
+		}
+	}
+	state := t.state(now)
+	for _, move := range played {
+		t.broadcast(message{Type: MESSAGE_MOVE, Move: &move, State: state})
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+func (s *Server) handleQueuePremove(w http.ResponseWriter, r *http.Request, t *table, now time.Time) {
+	var req premoveRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	color, ok := parseColor(req.Color)
+	if !ok {
+		writeError(w, http.StatusBadRequest, E_INVALID_COLOR)
+		return
+	}
+	if t.isOver() {
+		writeLibraryError(w, chessongo.ErrGameFinished)
+		return
+	}
+	if err := t.game.QueuePremove(req.Move, color); err != nil {
+		writeLibraryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t.premoves())
+}
+
+func (s *Server) handleCancelPremoves(w http.ResponseWriter, r *http.Request, t *table, now time.Time) {
+	color, ok := decodeColor(w, r)
+	if !ok {
+		return
+	}
+	// only the side not to move has premoves
+	var turn chessongo.Color
+	t.game.Read(func(g *chessongo.Game) { turn = g.Turn })
+	if color != turn {
+		t.game.CancelPremoves()
+	}
+	writeJSON(w, http.StatusOK, t.premoves())
+}
+
+func (s *Server) handleUndo(w http.ResponseWriter, r *http.Request, t *table, now time.Time) {
+	var req moveRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if !t.allowUndo {
+		writeLibraryError(w, errUndoNotAllowed)
+		return
+	}
+	if t.result != "" {
+		writeLibraryError(w, chessongo.ErrGameFinished)
+		return
+	}
+	event, err := t.game.Undo(expectedPly(req.Ply))
+	if err != nil {
+		writeLibraryError(w, err)
+		return
+	}
+	t.drawOffer = chessongo.NO_COLOR
+	if t.clock != nil && event.Ply > 0 {
+		t.clock.Start(t.game.Snapshot().Position.Turn, now)
+		s.startTicker(t)
+	} else if t.clock != nil {
+		t.clock.Stop(now)
+	}
+	state := t.state(now)
+	t.broadcast(message{Type: MESSAGE_UNDO, Move: &moveJSON{Uci: event.Move.Uci(), San: event.San}, State: state})
+	writeJSON(w, http.StatusOK, state)
+}
+
+func (s *Server) handleResign(w http.ResponseWriter, r *http.Request, t *table, now time.Time) {
+	color, ok := decodeColor(w, r)
+	if !ok {
+		return
+	}
+	if t.isOver() {
+		writeLibraryError(w, chessongo.ErrGameFinished)
+		return
+	}
+	t.finish(winnerAgainst(color), chessongo.TERMINATION_RESIGNATION, now)
+	state := t.state(now)
+	t.broadcast(message{Type: MESSAGE_STATE, State: state})
+	writeJSON(w, http.StatusOK, state)
+}
+
+func (s *Server) handleDraw(w http.ResponseWriter, r *http.Request, t *table, now time.Time) {
+	color, ok := decodeColor(w, r)
+	if !ok {
+		return
+	}
+	if t.isOver() {
+		writeLibraryError(w, chessongo.ErrGameFinished)
+		return
+	}
+	if t.drawOffer == opponent(color) {
+		t.finish(chessongo.RESULT_DRAW, chessongo.TERMINATION_DRAW_AGREEMENT, now)
+	} else {
+		t.drawOffer = color
+	}
+	state := t.state(now)
+	t.broadcast(message{Type: MESSAGE_STATE, State: state})
+	writeJSON(w, http.StatusOK, state)
+}
+
+func (s *Server) handleWebsocket(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.table(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, chessongo.E_GAME_NOT_FOUND)
+		return
+	}
+	ws, err := upgradeWebsocket(w, r)
+	if err != nil {
+		return
+	}
+	c := &client{ws: ws, send: make(chan []byte, clientBuffer)}
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		ws.Close()
+		return
+	}
+	t.clients[c] = struct{}{}
+	t.sendTo(c, message{Type: MESSAGE_STATE, State: t.state(s.opts.Now())})
+	t.mu.Unlock()
+
+	go c.writeLoop()
+	// Clients have nothing to say; reading only serves to notice them leave
+	for {
+		if _, err := ws.ReadMessage(); err != nil {
+			break
+		}
+	}
+	t.mu.Lock()
+	t.drop(c)
+	t.mu.Unlock()
+}
+
+// Starts pushing clock updates for t, unless already doing so. The caller
+// holds t.mu.
+func (s *Server) startTicker(t *table) {
+	if t.ticking || t.closed {
+		return
+	}
+	t.ticking = true
+	go func() {
+		ticker := time.NewTicker(s.opts.ClockInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.closed:
+				return
+			case <-t.done:
+				return
+			case <-ticker.C:
+			}
+			t.mu.Lock()
+			now := s.opts.Now()
+			if t.checkFlag(now) {
+				t.broadcast(message{Type: MESSAGE_STATE, State: t.state(now)})
+			}
+			if t.clock.Running() == chessongo.NO_COLOR {
+				t.ticking = false
+				t.mu.Unlock()
+				return
+			}
+			t.broadcast(message{Type: MESSAGE_CLOCK, Clock: t.clockState(now)})
+			t.mu.Unlock()
+		}
+	}()
+}
+
+func expectedPly(ply *int) int {
+	if ply == nil {
+		return chessongo.ANY_PLY
+	}
+	return *ply
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if r.ContentLength == 0 {
+		return true
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, E_BAD_REQUEST)
+		return false
+	}
+	return true
+}
+
+func decodeColor(w http.ResponseWriter, r *http.Request) (chessongo.Color, bool) {
+	var req colorRequest
+	if !decode(w, r, &req) {
+		return chessongo.NO_COLOR, false
+	}
+	color, ok := parseColor(req.Color)
+	if !ok {
+		writeError(w, http.StatusBadRequest, E_INVALID_COLOR)
+	}
+	return color, ok
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, errorResponse{Error: code})
+}
+
+// Maps the errors of SafeGame and of the server to HTTP statuses
+func writeLibraryError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, chessongo.ErrIllegalMove), errors.Is(err, chessongo.ErrInvalidPremove):
+		status = http.StatusBadRequest
+	case errors.Is(err, errUndoNotAllowed):
+		status = http.StatusForbidden
+	case errors.Is(err, chessongo.ErrGameNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, chessongo.ErrStaleGame), errors.Is(err, chessongo.ErrGameFinished),
+		errors.Is(err, chessongo.ErrNothingToUndo), errors.Is(err, chessongo.ErrGameClosed):
+		status = http.StatusConflict
+	}
+	writeError(w, status, err.Error())
+}
@@ -0,0 +1,351 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"chessongo"
+	"github.com/stretchr/testify/require"
+)
+
+// Time source the tests move forward by hand
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestServer(t *testing.T, opts Options) *httptest.Server {
+	srv := New(opts)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		srv.Close()
+		ts.Close()
+	})
+	return ts
+}
+
+func call(t *testing.T, ts *httptest.Server, method, path string, body any) (int, *GameState, string) {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, ts.URL+path, reader)
+	require.NoError(t, err)
+	resp, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var raw json.RawMessage
+	if resp.StatusCode == http.StatusNoContent {
+		return resp.StatusCode, nil, ""
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&raw))
+	if resp.StatusCode >= 400 {
+		var e errorResponse
+		require.NoError(t, json.Unmarshal(raw, &e))
+		return resp.StatusCode, nil, e.Error
+	}
+	var state GameState
+	require.NoError(t, json.Unmarshal(raw, &state))
+	return resp.StatusCode, &state, ""
+}
+
+func createGame(t *testing.T, ts *httptest.Server, body map[string]any) *GameState {
+	status, state, code := call(t, ts, "POST", "/games", body)
+	require.Equal(t, http.StatusCreated, status, code)
+	return state
+}
+
+func TestServerCreateAndMove(t *testing.T) {
+	ts := newTestServer(t, Options{})
+	state := createGame(t, ts, map[string]any{"fen": chessongo.STARTING_POSITION_FEN})
+	require.Equal(t, "w", state.Turn)
+	require.Equal(t, 0, state.Ply)
+	require.Len(t, state.LegalMoves, 20)
+	require.Equal(t, chessongo.RESULT_ONGOING, state.Result)
+
+	status, state, _ := call(t, ts, "POST", "/games/"+state.ID+"/moves", map[string]any{"move": "e4", "ply": 0})
+	require.Equal(t, http.StatusOK, status)
+	status, state, _ = call(t, ts, "POST", "/games/"+state.ID+"/moves", map[string]any{"move": "e7e5"})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []string{"e4", "e5"}, state.San)
+	require.Equal(t, 2, state.Ply)
+
+	status, got, _ := call(t, ts, "GET", "/games/"+state.ID, nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2", got.Fen)
+
+	status, _, code := call(t, ts, "POST", "/games/"+state.ID+"/moves", map[string]any{"move": "Nf3", "ply": 1})
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, chessongo.E_STALE_GAME, code)
+
+	status, _, code = call(t, ts, "POST", "/games/"+state.ID+"/moves", map[string]any{"move": "Ke3"})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, chessongo.E_ILLEGAL_MOVE, code)
+
+	status, _, _ = call(t, ts, "DELETE", "/games/"+state.ID, nil)
+	require.Equal(t, http.StatusNoContent, status)
+	status, _, code = call(t, ts, "GET", "/games/"+state.ID, nil)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, chessongo.E_GAME_NOT_FOUND, code)
+}
+
+func TestServerCreateErrors(t *testing.T) {
+	ts := newTestServer(t, Options{})
+	status, _, code := call(t, ts, "POST", "/games", map[string]any{})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, E_MISSING_POSITION, code)
+
+	status, _, _ = call(t, ts, "POST", "/games", map[string]any{"fen": "not a fen"})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status, _, code = call(t, ts, "POST", "/games", map[string]any{
+		"fen":         chessongo.STARTING_POSITION_FEN,
+		"timeControl": map[string]any{"baseSeconds": 0},
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, E_INVALID_TIME_CONTROL, code)
+}
+
+func TestServerCreateFromPGN(t *testing.T) {
+	ts := newTestServer(t, Options{})
+	state := createGame(t, ts, map[string]any{"pgn": "1. f3 e5 2. g4 Qh4# 0-1", "allowUndo": true})
+	require.Equal(t, []string{"f3", "e5", "g4", "Qh4#"}, state.San)
+	require.Equal(t, chessongo.RESULT_BLACK_WINS, state.Result)
+	require.Empty(t, state.LegalMoves)
+
+	status, _, code := call(t, ts, "POST", "/games/"+state.ID+"/moves", map[string]any{"move": "Ke2"})
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, chessongo.E_GAME_FINISHED, code)
+
+	status, state, _ = call(t, ts, "POST", "/games/"+state.ID+"/undo", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []string{"f3", "e5", "g4"}, state.San)
+	require.Equal(t, chessongo.RESULT_ONGOING, state.Result)
+}
+
+func TestServerUndo(t *testing.T) {
+	ts := newTestServer(t, Options{})
+	state := createGame(t, ts, map[string]any{"fen": chessongo.STARTING_POSITION_FEN})
+	status, _, code := call(t, ts, "POST", "/games/"+state.ID+"/undo", nil)
+	require.Equal(t, http.StatusForbidden, status)
+	require.Equal(t, E_UNDO_NOT_ALLOWED, code)
+
+	state = createGame(t, ts, map[string]any{"fen": chessongo.STARTING_POSITION_FEN, "allowUndo": true})
+	status, _, code = call(t, ts, "POST", "/games/"+state.ID+"/undo", nil)
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, chessongo.E_NOTHING_TO_UNDO, code)
+
+	call(t, ts, "POST", "/games/"+state.ID+"/moves", map[string]any{"move": "d4"})
+	status, state, _ = call(t, ts, "POST", "/games/"+state.ID+"/undo", map[string]any{"ply": 1})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 0, state.Ply)
+	require.Equal(t, chessongo.STARTING_POSITION_FEN, state.Fen)
+}
+
+func TestServerResignAndDraw(t *testing.T) {
+	ts := newTestServer(t, Options{})
+	state := createGame(t, ts, map[string]any{"fen": chessongo.STARTING_POSITION_FEN})
+	status, state, _ := call(t, ts, "POST", "/games/"+state.ID+"/resign", map[string]any{"color": "white"})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, chessongo.RESULT_BLACK_WINS, state.Result)
+	require.Equal(t, chessongo.TERMINATION_RESIGNATION.String(), state.Termination)
+	require.Empty(t, state.LegalMoves)
+
+	status, _, code := call(t, ts, "POST", "/games/"+state.ID+"/moves", map[string]any{"move": "e4"})
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, chessongo.E_GAME_FINISHED, code)
+
+	state = createGame(t, ts, map[string]any{"fen": chessongo.STARTING_POSITION_FEN})
+	status, _, code = call(t, ts, "POST", "/games/"+state.ID+"/draw", map[string]any{"color": "red"})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, E_INVALID_COLOR, code)
+
+	_, state, _ = call(t, ts, "POST", "/games/"+state.ID+"/draw", map[string]any{"color": "w"})
+	require.Equal(t, "w", state.DrawOffer)
+	// White offering again does not accept its own offer
+	_, state, _ = call(t, ts, "POST", "/games/"+state.ID+"/draw", map[string]any{"color": "w"})
+	require.Equal(t, chessongo.RESULT_ONGOING, state.Result)
+	_, state, _ = call(t, ts, "POST", "/games/"+state.ID+"/draw", map[string]any{"color": "b"})
+	require.Equal(t, chessongo.RESULT_DRAW, state.Result)
+	require.Equal(t, chessongo.TERMINATION_DRAW_AGREEMENT.String(), state.Termination)
+	require.Empty(t, state.DrawOffer)
+}
+
+func TestServerDrawOfferDeclinedByMoving(t *testing.T) {
+	ts := newTestServer(t, Options{})
+	state := createGame(t, ts, map[string]any{"fen": chessongo.STARTING_POSITION_FEN})
+	call(t, ts, "POST", "/games/"+state.ID+"/draw", map[string]any{"color": "b"})
+	_, state, _ = call(t, ts, "POST", "/games/"+state.ID+"/moves", map[string]any{"move": "e4"})
+	require.Empty(t, state.DrawOffer)
+}
+
+func TestServerClock(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ts := newTestServer(t, Options{Now: clock.Now, ClockInterval: time.Hour})
+	state := createGame(t, ts, map[string]any{
+		"fen":         chessongo.STARTING_POSITION_FEN,
+		"timeControl": map[string]any{"baseSeconds": 60, "incrementSeconds": 1},
+	})
+	require.Equal(t, &clockJSON{WhiteMs: 60000, BlackMs: 60000}, state.Clock)
+
+	// The first move is not timed and starts black's clock
+	clock.Advance(10 * time.Second)
+	_, state, _ = call(t, ts, "POST", "/games/"+state.ID+"/moves", map[string]any{"move": "e4"})
+	require.Equal(t, &clockJSON{WhiteMs: 60000, BlackMs: 60000, Running: "b"}, state.Clock)
+
+	clock.Advance(5 * time.Second)
+	_, state, _ = call(t, ts, "POST", "/games/"+state.ID+"/moves", map[string]any{"move": "e5"})
+	require.Equal(t, &clockJSON{WhiteMs: 60000, BlackMs: 56000, Running: "w"}, state.Clock)
+
+	clock.Advance(61 * time.Second)
+	_, state, _ = call(t, ts, "GET", "/games/"+state.ID, nil)
+	require.Equal(t, chessongo.RESULT_BLACK_WINS, state.Result)
+	require.Equal(t, chessongo.TERMINATION_TIMEOUT.String(), state.Termination)
+	require.Empty(t, state.Clock.Running)
+	require.Zero(t, state.Clock.WhiteMs)
+}
+
+// Queues a premove and returns the premoves listed in the response
+func queuePremove(t *testing.T, ts *httptest.Server, id, color, uci string) []string {
+	body, err := json.Marshal(map[string]any{"color": color, "move": uci})
+	require.NoError(t, err)
+	resp, err := ts.Client().Post(ts.URL+"/games/"+id+"/premoves", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var premoves premovesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&premoves))
+	return premoves.Premoves
+}
+
+func TestServerPremoves(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ts := newTestServer(t, Options{Now: clock.Now, ClockInterval: time.Hour})
+	state := createGame(t, ts, map[string]any{
+		"fen":          chessongo.STARTING_POSITION_FEN,
+		"timeControl":  map[string]any{"baseSeconds": 60, "incrementSeconds": 1},
+		"multiPremove": true,
+	})
+	id := state.ID
+	call(t, ts, "POST", "/games/"+id+"/moves", map[string]any{"move": "e4"})
+
+	status, _, code := call(t, ts, "POST", "/games/"+id+"/premoves", map[string]any{"color": "b", "move": "e7e5"})
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, chessongo.E_STALE_GAME, code)
+	status, _, code = call(t, ts, "POST", "/games/"+id+"/premoves", map[string]any{"color": "w", "move": "g1g3"})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, chessongo.E_INVALID_PREMOVE, code)
+	require.Equal(t, []string{"g1f3"}, queuePremove(t, ts, id, "w", "g1f3"))
+	require.Equal(t, []string{"g1f3", "f1c4"}, queuePremove(t, ts, id, "w", "f1c4"))
+
+	ws := dialWebsocket(t, ts, "/games/"+id+"/ws")
+	readMessage(t, ws)
+
+	// the premove is played right after black's move and takes no time
+	clock.Advance(5 * time.Second)
+	_, state, _ = call(t, ts, "POST", "/games/"+id+"/moves", map[string]any{"move": "e5"})
+	require.Equal(t, []string{"e4", "e5", "Nf3"}, state.San)
+	require.Equal(t, &clockJSON{WhiteMs: 61000, BlackMs: 56000, Running: "b"}, state.Clock)
+	for _, san := range []string{"e5", "Nf3"} {
+		msg := readMessage(t, ws)
+		require.Equal(t, MESSAGE_MOVE, msg.Type)
+		require.Equal(t, san, msg.Move.San)
+	}
+
+	status, _, _ = call(t, ts, "DELETE", "/games/"+id+"/premoves", map[string]any{"color": "w"})
+	require.Equal(t, http.StatusOK, status)
+	_, state, _ = call(t, ts, "POST", "/games/"+id+"/moves", map[string]any{"move": "Nc6"})
+	require.Equal(t, []string{"e4", "e5", "Nf3", "Nc6"}, state.San)
+}
+
+// Opens a WebSocket to path the way a browser would
+func dialWebsocket(t *testing.T, ts *httptest.Server, path string) *wsConn {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	req, err := http.NewRequest("GET", ts.URL+path, nil)
+	require.NoError(t, err)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	require.NoError(t, req.Write(conn))
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+	return &wsConn{conn: conn, br: br, mask: true}
+}
+
+func readMessage(t *testing.T, ws *wsConn) message {
+	ws.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	data, err := ws.ReadMessage()
+	require.NoError(t, err)
+	var msg message
+	require.NoError(t, json.Unmarshal(data, &msg))
+	return msg
+}
+
+func TestServerWebsocket(t *testing.T) {
+	ts := newTestServer(t, Options{ClockInterval: 10 * time.Millisecond})
+	state := createGame(t, ts, map[string]any{
+		"fen":         chessongo.STARTING_POSITION_FEN,
+		"timeControl": map[string]any{"baseSeconds": 300},
+	})
+	player := dialWebsocket(t, ts, "/games/"+state.ID+"/ws")
+	spectator := dialWebsocket(t, ts, "/games/"+state.ID+"/ws")
+	for _, ws := range []*wsConn{player, spectator} {
+		msg := readMessage(t, ws)
+		require.Equal(t, MESSAGE_STATE, msg.Type)
+		require.Equal(t, state.ID, msg.State.ID)
+	}
+
+	call(t, ts, "POST", "/games/"+state.ID+"/moves", map[string]any{"move": "Nf3"})
+	for _, ws := range []*wsConn{player, spectator} {
+		msg := readMessage(t, ws)
+		require.Equal(t, MESSAGE_MOVE, msg.Type)
+		require.Equal(t, &moveJSON{Uci: "g1f3", San: "Nf3"}, msg.Move)
+		require.Equal(t, 1, msg.State.Ply)
+	}
+
+	msg := readMessage(t, spectator)
+	require.Equal(t, MESSAGE_CLOCK, msg.Type)
+	require.Equal(t, "b", msg.Clock.Running)
+	require.LessOrEqual(t, msg.Clock.BlackMs, int64(300000))
+}
+
+func TestServerWebsocketRejectsPlainRequests(t *testing.T) {
+	ts := newTestServer(t, Options{})
+	state := createGame(t, ts, map[string]any{"fen": chessongo.STARTING_POSITION_FEN})
+	resp, err := ts.Client().Get(ts.URL + "/games/" + state.ID + "/ws")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
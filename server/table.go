@@ -0,0 +1,257 @@
+package server
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"chessongo"
+)
+
+// Types of the messages pushed to WebSocket clients
+const (
+	MESSAGE_STATE = "state"
+	MESSAGE_MOVE  = "move"
+	MESSAGE_UNDO  = "undo"
+	MESSAGE_CLOCK = "clock"
+)
+
+// Messages a WebSocket client may fall behind by before it is disconnected
+const clientBuffer = 64
+
+type moveJSON struct {
+	Uci string `json:"uci"`
+	San string `json:"san"`
+}
+
+type clockJSON struct {
+	WhiteMs int64  `json:"whiteMs"`
+	BlackMs int64  `json:"blackMs"`
+	Running string `json:"running,omitempty"`
+}
+
+// GameState is the JSON representation of a hosted game
+type GameState struct {
+	ID          string     `json:"id"`
+	Fen         string     `json:"fen"`
+	Turn        string     `json:"turn"`
+	Ply         int        `json:"ply"`
+	LegalMoves  []string   `json:"legalMoves"`
+	San         []string   `json:"san"`
+	Check       bool       `json:"check"`
+	Result      string     `json:"result"`
+	Termination string     `json:"termination"`
+	DrawOffer   string     `json:"drawOffer,omitempty"`
+	AllowUndo   bool       `json:"allowUndo"`
+	Clock       *clockJSON `json:"clock,omitempty"`
+}
+
+type message struct {
+	Type  string     `json:"type"`
+	Move  *moveJSON  `json:"move,omitempty"`
+	State *GameState `json:"state,omitempty"`
+	Clock *clockJSON `json:"clock,omitempty"`
+}
+
+type client struct {
+	ws   *wsConn
+	send chan []byte
+}
+
+func (c *client) writeLoop() {
+	for data := range c.send {
+		if err := c.ws.WriteText(data); err != nil {
+			// unblocks the read loop, which drops the client
+			c.ws.conn.Close()
+			return
+		}
+	}
+	c.ws.Close()
+}
+
+// table is a hosted game with what the server adds around the board: the
+// clock, draw offers, results decided off the board and the WebSocket
+// clients. All fields are guarded by mu.
+type table struct {
+	mu        sync.Mutex
+	game      *chessongo.SafeGame
+	clock     *chessongo.Clock
+	allowUndo bool
+	drawOffer chessongo.Color
+	// Set when the game was decided off the board
+	result      string
+	termination chessongo.Termination
+	clients     map[*client]struct{}
+	ticking     bool
+	closed      bool
+	done        chan struct{}
+}
+
+func newTable(sg *chessongo.SafeGame, allowUndo bool) *table {
+	return &table{
+		game:      sg,
+		allowUndo: allowUndo,
+		drawOffer: chessongo.NO_COLOR,
+		clients:   map[*client]struct{}{},
+		done:      make(chan struct{}),
+	}
+}
+
+// Lists the queued premoves in UCI
+func (t *table) premoves() premovesResponse {
+	premoves := []string{}
+	for _, m := range t.game.Snapshot().Premoves {
+		premoves = append(premoves, m.Uci())
+	}
+	return premovesResponse{Premoves: premoves}
+}
+
+func (t *table) isOver() bool {
+	return t.result != "" || t.game.Snapshot().Result != chessongo.RESULT_ONGOING
+}
+
+// Ends the game off the board
+func (t *table) finish(result string, termination chessongo.Termination, now time.Time) {
+	t.result, t.termination = result, termination
+	t.drawOffer = chessongo.NO_COLOR
+	if t.clock != nil {
+		t.clock.Stop(now)
+	}
+}
+
+// Ends the game if the side to move ran out of time, reporting whether it did
+func (t *table) checkFlag(now time.Time) bool {
+	if t.clock == nil || t.isOver() {
+		return false
+	}
+	color, flagged := t.clock.Flagged(now)
+	if !flagged {
+		return false
+	}
+	t.finish(winnerAgainst(color), chessongo.TERMINATION_TIMEOUT, now)
+	return true
+}
+
+func (t *table) clockState(now time.Time) *clockJSON {
+	if t.clock == nil {
+		return nil
+	}
+	return &clockJSON{
+		WhiteMs: max(t.clock.Remaining(chessongo.WHITE, now), 0).Milliseconds(),
+		BlackMs: max(t.clock.Remaining(chessongo.BLACK, now), 0).Milliseconds(),
+		Running: colorName(t.clock.Running()),
+	}
+}
+
+func (t *table) state(now time.Time) *GameState {
+	snapshot := t.game.Snapshot()
+	state := &GameState{
+		ID:          snapshot.GameID,
+		Fen:         snapshot.Fen,
+		Turn:        colorName(snapshot.Position.Turn),
+		Ply:         snapshot.Ply,
+		LegalMoves:  []string{},
+		San:         snapshot.San,
+		Check:       snapshot.IsCheck,
+		Result:      snapshot.Result,
+		Termination: snapshot.Termination.String(),
+		DrawOffer:   colorName(t.drawOffer),
+		AllowUndo:   t.allowUndo,
+		Clock:       t.clockState(now),
+	}
+	if state.San == nil {
+		state.San = []string{}
+	}
+	if t.result != "" {
+		state.Result, state.Termination = t.result, t.termination.String()
+	}
+	if state.Result == chessongo.RESULT_ONGOING {
+		for _, m := range snapshot.LegalMoves {
+			state.LegalMoves = append(state.LegalMoves, m.Uci())
+		}
+	}
+	return state
+}
+
+// Queues msg for every client, dropping those that fell too far behind
+func (t *table) broadcast(msg message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	for c := range t.clients {
+		t.queue(c, data)
+	}
+}
+
+func (t *table) sendTo(c *client, msg message) {
+	if data, err := json.Marshal(msg); err == nil {
+		t.queue(c, data)
+	}
+}
+
+func (t *table) queue(c *client, data []byte) {
+	select {
+	case c.send <- data:
+	default:
+		t.drop(c)
+	}
+}
+
+// Disconnects c; its write loop closes the connection once the queue is drained
+func (t *table) drop(c *client) {
+	if _, ok := t.clients[c]; ok {
+		delete(t.clients, c)
+		close(c.send)
+	}
+}
+
+// Disconnects every client and stops the clock updates. The caller must not
+// hold t.mu.
+func (t *table) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.closed = true
+	close(t.done)
+	for c := range t.clients {
+		t.drop(c)
+	}
+}
+
+func colorName(c chessongo.Color) string {
+	switch c {
+	case chessongo.WHITE:
+		return "w"
+	case chessongo.BLACK:
+		return "b"
+	}
+	return ""
+}
+
+func parseColor(s string) (chessongo.Color, bool) {
+	switch s {
+	case "w", "white":
+		return chessongo.WHITE, true
+	case "b", "black":
+		return chessongo.BLACK, true
+	}
+	return chessongo.NO_COLOR, false
+}
+
+func opponent(c chessongo.Color) chessongo.Color {
+	if c == chessongo.WHITE {
+		return chessongo.BLACK
+	}
+	return chessongo.WHITE
+}
+
+// Returns the result of a game lost by color
+func winnerAgainst(color chessongo.Color) string {
+	if color == chessongo.WHITE {
+		return chessongo.RESULT_BLACK_WINS
+	}
+	return chessongo.RESULT_WHITE_WINS
+}